@@ -0,0 +1,87 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 8:50
+ */
+
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+const (
+	API_KEY_PREFIX     = "fak_"
+	TOKEN_KIND_API_KEY = "apikey"
+)
+
+type ApiKeyRepository interface {
+	Save(key ApiKey) error
+	FindByName(name string) (ApiKey, bool)
+	FindByDigest(digest string) (ApiKey, bool)
+	FindAll() []ApiKey
+	Delete(name string) (bool, error)
+	Touch(name string, usedAt time.Time, address string)
+}
+
+// ApiKey is long-lived credential for automation (e.g. CI pipeline).
+// key itself is never stored, only its sha256 digest. zero ExpireAt means no expiry
+type ApiKey struct {
+	Name            string
+	Digest          string
+	Role            Role
+	Scope           string
+	CreatedBy       string
+	CreatedAt       time.Time
+	ExpireAt        time.Time
+	LastUsedAt      time.Time
+	LastUsedAddress string
+}
+
+func (k ApiKey) IsExpired() bool {
+	return !k.ExpireAt.IsZero() && time.Now().After(k.ExpireAt)
+}
+
+// AsSession returns session of key. scoped key is bound to its scope only
+func (k ApiKey) AsSession() Session {
+	session := Session{}
+	session.Id = k.Digest
+	session.UserId = API_KEY_PREFIX + k.Name
+	session.Role = k.Role
+	session.IssuedAt = k.CreatedAt
+	session.ExpireAt = k.ExpireAt
+	session.Kind = TOKEN_KIND_API_KEY
+	if len(k.Scope) > 0 {
+		session.Bindings = []RoleBinding{{Scope: k.Scope, Role: k.Role}}
+	}
+	return session
+}
+
+func IsApiKey(token string) bool {
+	return strings.HasPrefix(token, API_KEY_PREFIX)
+}
+
+func DigestApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 19. 오후 5:20
+ */
+package domain
+
+import "time"
+
+const (
+	AUDIT_RESULT_SUCCESS = "success"
+	AUDIT_RESULT_FAIL    = "fail"
+
+	// action is named after uri. e.g) /user/create/v1 is user.create
+	AUDIT_ACTION_DEPLOY = "deploy.insert"
+)
+
+// AuditEvent is single record of security audit trail
+type AuditEvent struct {
+	Time          time.Time `json:"time"`
+	UserId        string    `json:"user_id,omitempty"`
+	Role          string    `json:"role,omitempty"`
+	ClientAddress string    `json:"client_address,omitempty"`
+	Action        string    `json:"action"`
+	Target        string    `json:"target,omitempty"`
+	Result        string    `json:"result"`
+	Message       string    `json:"message,omitempty"`
+}
+
+// AuditFilter selects events. zero value of each field matches every event
+type AuditFilter struct {
+	From   time.Time
+	To     time.Time
+	UserId string
+	Action string
+	Limit  int
+}
+
+func (f AuditFilter) Matches(event AuditEvent) bool {
+	if !f.From.IsZero() && event.Time.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && event.Time.After(f.To) {
+		return false
+	}
+	if len(f.UserId) > 0 && f.UserId != event.UserId {
+		return false
+	}
+	if len(f.Action) > 0 && f.Action != event.Action {
+		return false
+	}
+	return true
+}
+
+// AuditRepository is append-only store of audit events. Find returns newest event first
+type AuditRepository interface {
+	Append(event AuditEvent) error
+	Find(filter AuditFilter) ([]AuditEvent, error)
+}
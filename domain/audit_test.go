@@ -0,0 +1,56 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 7:35
+ */
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAuditFilterMatches(t *testing.T) {
+	now := time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC)
+	event := AuditEvent{Time: now, UserId: "alice", Action: "login", Result: AUDIT_RESULT_SUCCESS}
+
+	cases := []struct {
+		name    string
+		filter  AuditFilter
+		matches bool
+	}{
+		{"zero filter", AuditFilter{}, true},
+		{"user", AuditFilter{UserId: "alice"}, true},
+		{"other user", AuditFilter{UserId: "bob"}, false},
+		{"action", AuditFilter{Action: "login"}, true},
+		{"other action", AuditFilter{Action: "logout"}, false},
+		{"in range", AuditFilter{From: now.Add(-time.Hour), To: now.Add(time.Hour)}, true},
+		{"range edge", AuditFilter{From: now, To: now}, true},
+		{"before from", AuditFilter{From: now.Add(time.Second)}, false},
+		{"after to", AuditFilter{To: now.Add(-time.Second)}, false},
+		{"user and other action", AuditFilter{UserId: "alice", Action: "logout"}, false},
+	}
+
+	for _, c := range cases {
+		if c.filter.Matches(event) != c.matches {
+			t.Fatalf("%s : matches %t", c.name, !c.matches)
+		}
+	}
+}
@@ -15,7 +15,10 @@
 
 package domain
 
+// SqlProvider gives sql statements of database dialect. see infra.NewSqliteProvider
 type SqlProvider interface {
+	// GetSqlMigrations returns schema changes in order. index+1 is schema version
+	GetSqlMigrations() []string
 	GetSqlFindAllDep() string
 	GetSqlFindDepByPoint() string
 	GetSqlFindDepByAddress(ipAddress string) string
@@ -28,10 +31,17 @@ type SqlProvider interface {
 	GetSqlDeleteOldToken() string
 	GetSqlInsertToken() string
 	GetSqlFindRoleFromToken() string
+	GetSqlFindAllTokens() string
+	GetSqlDeleteToken() string
+	GetSqlExtendToken() string
 	GetSqlCountUserById() string
 	GetSqlInsertUser() string
 	GetSqlUpdateUser() string
 	GetSqlFindUserById() string
 	GetSqlDeleteUserById() string
 	GetSqlCountAllUsers() string
+	GetSqlFindAllUsers() string
+	GetSqlFindAllHealth() string
+	GetSqlDeleteAllHealth() string
+	GetSqlInsertHealth() string
 }
@@ -27,4 +27,8 @@ type Encdec interface {
 	Encrypt(content string) string
 	Decrypt(content string) string
 	Hash(content string) string
+	// Verify compares content with hashed value in constant time
+	Verify(content string, hashed string) bool
+	// NeedRehash returns true when hashed value is legacy or made by another algorithm
+	NeedRehash(hashed string) bool
 }
@@ -44,7 +44,13 @@ type JunoRepository interface {
 	FindByEndpoint(endpoint string) *JunoPackage
 	Save(data JunoRegistration)
 	Delete(endpoint string)
-	SaveAll()
+	// Update runs update on stored summary exclusively and persists it when update returns true.
+	// Find functions return copy, so modification on them is not stored
+	Update(update func(summary *JunoSummary) bool)
+	// FindHealthHistory returns recent health results of junos kept with registry, oldest first
+	FindHealthHistory() map[string][]HealthResult
+	// SaveHealthHistory replaces health results kept with registry
+	SaveHealthHistory(histories map[string][]HealthResult) error
 }
 
 type Juno struct {
@@ -55,12 +61,27 @@ type Juno struct {
 }
 
 type JunoPackage struct {
-	Endpoint   string       `json:"endpoint"`
-	Host       string       `json:"host"`
-	Name       string       `json:"name"`
-	RegistDate interface{}  `json:"regist_date"`
-	Status     string       `json:"status"`
-	Platform   PlatformInfo `json:"platform"`
+	Endpoint     string       `json:"endpoint"`
+	Host         string       `json:"host"`
+	Name         string       `json:"name"`
+	RegistDate   interface{}  `json:"regist_date"`
+	Status       string       `json:"status"`
+	Platform     PlatformInfo `json:"platform"`
+	KeyId        string       `json:"key_id,omitempty"`
+	LastChecked  interface{}  `json:"last_checked,omitempty"`
+	LeaseSeconds int          `json:"lease_seconds,omitempty"`
+	// Labels are key/value given on registration. see LabelSelector
+	Labels map[string]string `json:"labels,omitempty"`
+	// LeaseExpire and Health are filled on report only. they are kept in memory
+	LeaseExpire interface{} `json:"lease_expire,omitempty"`
+	Health      *JunoHealth `json:"health,omitempty"`
+}
+
+// Copy returns copy of package which does not share labels
+func (jp *JunoPackage) Copy() JunoPackage {
+	p := *jp
+	p.Labels = copyLabels(jp.Labels)
+	return p
 }
 
 func (jp *JunoPackage) Format(location *time.Location) JunoPackage {
@@ -69,6 +90,11 @@ func (jp *JunoPackage) Format(location *time.Location) JunoPackage {
 	juno.Host = jp.Host
 	juno.Name = jp.Name
 	juno.Status = jp.Status
+	juno.KeyId = jp.KeyId
+	juno.LastChecked = formatUnixTime(jp.LastChecked, location)
+	juno.LeaseSeconds = jp.LeaseSeconds
+	juno.LeaseExpire = formatUnixTime(jp.LeaseExpire, location)
+	juno.Labels = copyLabels(jp.Labels)
 	if juno.Status == JUNO_STATUS_DEAD {
 		juno.RegistDate = "-"
 		return juno
@@ -89,6 +115,17 @@ func (jp *JunoPackage) Format(location *time.Location) JunoPackage {
 	return juno
 }
 
+// formatUnixTime formats unix seconds (int64 or float64 from json) on location. empty value is kept
+func formatUnixTime(value interface{}, location *time.Location) interface{} {
+	switch v := value.(type) {
+	case float64:
+		return time.Unix(int64(v), 0).In(location).Format(TIME_YYYYMMDDHHMMSS)
+	case int64:
+		return time.Unix(v, 0).In(location).Format(TIME_YYYYMMDDHHMMSS)
+	}
+	return value
+}
+
 type JunoRegistration struct {
 	Group string
 	JunoPackage
@@ -102,6 +139,10 @@ func (jr *JunoRegistration) AsJunoPackage() JunoPackage {
 	pack.RegistDate = jr.RegistDate
 	pack.Status = jr.Status
 	pack.Platform = jr.Platform
+	pack.KeyId = jr.KeyId
+	pack.LastChecked = jr.LastChecked
+	pack.LeaseSeconds = jr.LeaseSeconds
+	pack.Labels = copyLabels(jr.Labels)
 	return pack
 }
 
@@ -117,6 +158,18 @@ type JunoSummary struct {
 	Groups       []JunoGroup `json:"groups,omitempty"`
 }
 
+// Copy returns deep copy of summary
+func (js *JunoSummary) Copy() *JunoSummary {
+	summary := *js
+	if js.Groups != nil {
+		summary.Groups = make([]JunoGroup, len(js.Groups))
+		for i := range js.Groups {
+			summary.Groups[i] = js.Groups[i].Copy()
+		}
+	}
+	return &summary
+}
+
 func (js *JunoSummary) UpdateHostCount() {
 	hostMap := make(map[string]int)
 	for _, g := range js.Groups {
@@ -201,6 +254,17 @@ func (jg *JunoGroup) Delete(index int) {
 	jg.Packages = append(jg.Packages[:index], jg.Packages[index+1:]...)
 }
 
+func (jg *JunoGroup) Copy() JunoGroup {
+	g := *jg
+	if jg.Packages != nil {
+		g.Packages = make([]JunoPackage, len(jg.Packages))
+		for i := range jg.Packages {
+			g.Packages[i] = jg.Packages[i].Copy()
+		}
+	}
+	return g
+}
+
 func (jg *JunoGroup) Clone(location *time.Location) JunoGroup {
 	g := JunoGroup{}
 	g.Name = jg.Name
@@ -0,0 +1,132 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 19. 오후 9:10
+ */
+package domain
+
+import "time"
+
+const (
+	JUNO_HEALTH_ALIVE    = "alive"
+	JUNO_HEALTH_DEAD     = "dead"
+	JUNO_HEALTH_FLAPPING = "flapping"
+)
+
+// HealthResult is single health check of juno. empty Error means success
+type HealthResult struct {
+	Time    time.Time     `json:"time"`
+	Latency time.Duration `json:"latency"`
+	Error   string        `json:"error,omitempty"`
+}
+
+func (r HealthResult) IsAlive() bool {
+	return len(r.Error) == 0
+}
+
+// HealthHistory is bounded ring of recent health results. the oldest result is dropped when full
+type HealthHistory struct {
+	results []HealthResult
+	next    int
+	full    bool
+}
+
+func NewHealthHistory(capacity int) *HealthHistory {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &HealthHistory{results: make([]HealthResult, capacity)}
+}
+
+func (h *HealthHistory) Add(result HealthResult) {
+	h.results[h.next] = result
+	h.next = (h.next + 1) % len(h.results)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// Results returns results oldest first
+func (h *HealthHistory) Results() []HealthResult {
+	if !h.full {
+		return append([]HealthResult(nil), h.results[:h.next]...)
+	}
+	list := make([]HealthResult, 0, len(h.results))
+	list = append(list, h.results[h.next:]...)
+	return append(list, h.results[:h.next]...)
+}
+
+// Uptime returns percentage of successful results
+func (h *HealthHistory) Uptime() float64 {
+	results := h.Results()
+	if len(results) == 0 {
+		return 0
+	}
+
+	alive := 0
+	for _, r := range results {
+		if r.IsAlive() {
+			alive++
+		}
+	}
+	return float64(alive) * 100 / float64(len(results))
+}
+
+func (h *HealthHistory) ConsecutiveFailures() int {
+	results := h.Results()
+	count := 0
+	for i := len(results) - 1; i >= 0 && !results[i].IsAlive(); i-- {
+		count++
+	}
+	return count
+}
+
+// LastSeenAlive returns time of the latest success in history. zero time when there is none
+func (h *HealthHistory) LastSeenAlive() time.Time {
+	results := h.Results()
+	for i := len(results) - 1; i >= 0; i-- {
+		if results[i].IsAlive() {
+			return results[i].Time
+		}
+	}
+	return time.Time{}
+}
+
+// Transitions returns count of alive/dead changes in history
+func (h *HealthHistory) Transitions() int {
+	results := h.Results()
+	count := 0
+	for i := 1; i < len(results); i++ {
+		if results[i].IsAlive() != results[i-1].IsAlive() {
+			count++
+		}
+	}
+	return count
+}
+
+// JunoHealth is health report of juno package computed from its history
+type JunoHealth struct {
+	State               string  `json:"state"`
+	Uptime              float64 `json:"uptime"`
+	LastSeenAlive       string  `json:"last_seen_alive,omitempty"`
+	ConsecutiveFailures int     `json:"consecutive_failures"`
+	Transitions         int     `json:"transitions"`
+	Samples             int     `json:"samples"`
+}
@@ -0,0 +1,95 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 8:40
+ */
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestHealthHistory adds results of pattern in order. 'o' is success and 'x' is failure
+func newTestHealthHistory(capacity int, pattern string, base time.Time) *HealthHistory {
+	h := NewHealthHistory(capacity)
+	for i, c := range pattern {
+		result := HealthResult{Time: base.Add(time.Second * time.Duration(i))}
+		if c == 'x' {
+			result.Error = "connection refused"
+		}
+		h.Add(result)
+	}
+	return h
+}
+
+func TestHealthHistory(t *testing.T) {
+	base := time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name        string
+		capacity    int
+		pattern     string
+		samples     int
+		uptime      float64
+		failures    int
+		transitions int
+		lastAlive   int // index of last success in pattern, -1 for none
+	}{
+		{"empty", 4, "", 0, 0, 0, 0, -1},
+		{"all alive", 4, "ooo", 3, 100, 0, 0, 2},
+		{"all dead", 4, "xxx", 3, 0, 3, 0, -1},
+		{"trailing failures", 4, "ooxx", 4, 50, 2, 1, 1},
+		{"flapping", 8, "oxoxoxox", 8, 50, 1, 7, 6},
+		{"oldest dropped", 4, "xxxxoooo", 4, 100, 0, 0, 7},
+		{"ring wrapped", 4, "ooooxoxx", 4, 25, 2, 2, 5},
+		{"zero capacity", 0, "ox", 1, 0, 1, 0, -1},
+	}
+
+	for _, c := range cases {
+		h := newTestHealthHistory(c.capacity, c.pattern, base)
+		results := h.Results()
+		if len(results) != c.samples {
+			t.Fatalf("%s : %d samples, expected %d", c.name, len(results), c.samples)
+		}
+		for i := 1; i < len(results); i++ {
+			if !results[i-1].Time.Before(results[i].Time) {
+				t.Fatalf("%s : results are not in added order", c.name)
+			}
+		}
+		if h.Uptime() != c.uptime {
+			t.Fatalf("%s : uptime %f, expected %f", c.name, h.Uptime(), c.uptime)
+		}
+		if h.ConsecutiveFailures() != c.failures {
+			t.Fatalf("%s : %d failures, expected %d", c.name, h.ConsecutiveFailures(), c.failures)
+		}
+		if h.Transitions() != c.transitions {
+			t.Fatalf("%s : %d transitions, expected %d", c.name, h.Transitions(), c.transitions)
+		}
+
+		expected := time.Time{}
+		if c.lastAlive >= 0 {
+			expected = base.Add(time.Second * time.Duration(c.lastAlive))
+		}
+		if !h.LastSeenAlive().Equal(expected) {
+			t.Fatalf("%s : last seen alive %s, expected %s", c.name, h.LastSeenAlive(), expected)
+		}
+	}
+}
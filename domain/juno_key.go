@@ -0,0 +1,64 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 2:10
+ */
+
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	JUNO_KEY_KIND_SECRET = "secret"
+	JUNO_KEY_KIND_HOST   = "host"
+)
+
+type JunoKeyRepository interface {
+	Find() JunoKeyData
+	Save(data JunoKeyData) error
+}
+
+// JunoKeyData holds credentials which juno agents have to present
+// when they mutate the registry (regist, unregist, remove)
+type JunoKeyData struct {
+	Secret         string            `json:"secret"`
+	PreviousSecret string            `json:"previous_secret,omitempty"`
+	PreviousExpire int64             `json:"previous_expire,omitempty"`
+	Hosts          map[string]string `json:"hosts,omitempty"`
+}
+
+func (kd JunoKeyData) FindHostKey(host string) (string, bool) {
+	if kd.Hosts == nil {
+		return "", false
+	}
+	key, ok := kd.Hosts[strings.ToLower(host)]
+	return key, ok
+}
+
+// BuildJunoKeyId returns key identifier which is recorded on registered juno package.
+// key itself is never recorded, only its kind and fingerprint
+func BuildJunoKeyId(kind string, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return kind + ":" + hex.EncodeToString(sum[:4])
+}
@@ -0,0 +1,259 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오전 2:00
+ */
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	LABEL_MAX_COUNT        = 32
+	LABEL_MAX_KEY_LENGTH   = 63
+	LABEL_MAX_VALUE_LENGTH = 63
+
+	labelOpEquals    = "="
+	labelOpNotEquals = "!="
+	labelOpIn        = "in"
+	labelOpNotIn     = "notin"
+	labelOpExists    = "exists"
+	labelOpNotExists = "!"
+)
+
+var (
+	labelKeyPattern   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]*[A-Za-z0-9])?$`)
+	labelValuePattern = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?)?$`)
+	labelSetPattern   = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+)
+
+func validateLabelKey(key string) error {
+	if len(key) == 0 || len(key) > LABEL_MAX_KEY_LENGTH || !labelKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid label key %q", key)
+	}
+	return nil
+}
+
+func validateLabelValue(value string) error {
+	if len(value) > LABEL_MAX_VALUE_LENGTH || !labelValuePattern.MatchString(value) {
+		return fmt.Errorf("invalid label value %q", value)
+	}
+	return nil
+}
+
+// ValidateLabels checks label keys and values. key is alphanumeric with '-', '_', '.', '/' inside
+// and value is alphanumeric with '-', '_', '.' inside or empty. both are up to 63 characters
+func ValidateLabels(labels map[string]string) error {
+	if len(labels) > LABEL_MAX_COUNT {
+		return fmt.Errorf("too many labels. max %d", LABEL_MAX_COUNT)
+	}
+	for k, v := range labels {
+		if err := validateLabelKey(k); err != nil {
+			return err
+		}
+		if err := validateLabelValue(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+	m := make(map[string]string, len(labels))
+	for k, v := range labels {
+		m[k] = v
+	}
+	return m
+}
+
+type labelRequirement struct {
+	key    string
+	op     string
+	values []string
+}
+
+func (r labelRequirement) matches(labels map[string]string) bool {
+	value, ok := labels[r.key]
+	switch r.op {
+	case labelOpExists:
+		return ok
+	case labelOpNotExists:
+		return !ok
+	case labelOpEquals, labelOpIn:
+		return ok && r.contains(value)
+	case labelOpNotEquals, labelOpNotIn:
+		return !ok || !r.contains(value)
+	}
+	return false
+}
+
+func (r labelRequirement) contains(value string) bool {
+	for _, v := range r.values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (r labelRequirement) String() string {
+	switch r.op {
+	case labelOpExists:
+		return r.key
+	case labelOpNotExists:
+		return "!" + r.key
+	case labelOpIn, labelOpNotIn:
+		return fmt.Sprintf("%s %s (%s)", r.key, r.op, strings.Join(r.values, ","))
+	}
+	return r.key + r.op + r.values[0]
+}
+
+// LabelSelector chooses packages by labels like kubernetes label selector.
+// every requirement has to match. empty selector matches every package
+type LabelSelector []labelRequirement
+
+// ParseLabelSelector parses comma separated requirements.
+// e.g) env=prod,region in (kr1,kr2),tier!=batch,canary,!legacy
+func ParseLabelSelector(selector string) (LabelSelector, error) {
+	s := make(LabelSelector, 0)
+	for _, term := range splitSelector(selector) {
+		term = strings.TrimSpace(term)
+		if len(term) == 0 {
+			return nil, fmt.Errorf("empty requirement on selector %q", selector)
+		}
+
+		r, err := parseRequirement(term)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, r)
+	}
+	return s, nil
+}
+
+// splitSelector splits selector by comma which is not in parentheses
+func splitSelector(selector string) []string {
+	if len(strings.TrimSpace(selector)) == 0 {
+		return nil
+	}
+
+	terms := make([]string, 0)
+	depth, start := 0, 0
+	for i, c := range selector {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				terms = append(terms, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(terms, selector[start:])
+}
+
+func parseRequirement(term string) (labelRequirement, error) {
+	var r labelRequirement
+	if m := labelSetPattern.FindStringSubmatch(term); m != nil {
+		r = labelRequirement{key: m[1], op: m[2]}
+		if len(strings.TrimSpace(m[3])) == 0 {
+			return r, fmt.Errorf("empty value set on selector requirement %q", term)
+		}
+		for _, v := range strings.Split(m[3], ",") {
+			v = strings.TrimSpace(v)
+			if err := validateLabelValue(v); err != nil {
+				return r, err
+			}
+			r.values = append(r.values, v)
+		}
+		sort.Strings(r.values)
+	} else if idx := strings.Index(term, "!="); idx >= 0 {
+		r = labelRequirement{key: strings.TrimSpace(term[:idx]), op: labelOpNotEquals, values: []string{strings.TrimSpace(term[idx+2:])}}
+	} else if idx := strings.Index(term, "="); idx >= 0 {
+		value := strings.TrimPrefix(term[idx+1:], "=")
+		r = labelRequirement{key: strings.TrimSpace(term[:idx]), op: labelOpEquals, values: []string{strings.TrimSpace(value)}}
+	} else if strings.HasPrefix(term, "!") {
+		r = labelRequirement{key: strings.TrimSpace(term[1:]), op: labelOpNotExists}
+	} else {
+		r = labelRequirement{key: term, op: labelOpExists}
+	}
+
+	if err := validateLabelKey(r.key); err != nil {
+		return r, fmt.Errorf("%s on selector requirement %q", err.Error(), term)
+	}
+	for _, v := range r.values {
+		if err := validateLabelValue(v); err != nil {
+			return r, fmt.Errorf("%s on selector requirement %q", err.Error(), term)
+		}
+	}
+	return r, nil
+}
+
+func (s LabelSelector) IsEmpty() bool {
+	return len(s) == 0
+}
+
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		if !r.matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s LabelSelector) String() string {
+	terms := make([]string, 0, len(s))
+	for _, r := range s {
+		terms = append(terms, r.String())
+	}
+	return strings.Join(terms, ",")
+}
+
+// SelectGroups returns groups with packages matched by selector. groups without matched package are dropped
+func (s LabelSelector) SelectGroups(groups []JunoGroup) []JunoGroup {
+	if s.IsEmpty() {
+		return groups
+	}
+
+	selected := make([]JunoGroup, 0, len(groups))
+	for _, g := range groups {
+		packages := make([]JunoPackage, 0, len(g.Packages))
+		for _, p := range g.Packages {
+			if s.Matches(p.Labels) {
+				packages = append(packages, p)
+			}
+		}
+		if len(packages) > 0 {
+			selected = append(selected, JunoGroup{Name: g.Name, Packages: packages})
+		}
+	}
+	return selected
+}
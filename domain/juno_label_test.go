@@ -0,0 +1,66 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 12:20
+ */
+
+package domain
+
+import "testing"
+
+func TestParseLabelSelector(t *testing.T) {
+	labels := map[string]string{"env": "prod", "region": "kr1"}
+	cases := []struct {
+		selector string
+		matches  bool
+	}{
+		{"", true},
+		{"env=prod", true},
+		{"env==prod,region!=kr2", true},
+		{"region in (kr1, kr2)", true},
+		{"region notin (kr1)", false},
+		{"env,!tier", true},
+		{"tier", false},
+	}
+
+	for _, c := range cases {
+		s, err := ParseLabelSelector(c.selector)
+		if err != nil {
+			t.Fatalf("%q : %s", c.selector, err.Error())
+		}
+		if s.Matches(labels) != c.matches {
+			t.Fatalf("%q : matches %t", c.selector, !c.matches)
+		}
+	}
+}
+
+func TestParseLabelSelectorInvalid(t *testing.T) {
+	for _, selector := range []string{
+		"env in ()",
+		"env notin ( )",
+		"env=prod,,region=kr1",
+		"env=prod!",
+		"=prod",
+	} {
+		if _, err := ParseLabelSelector(selector); err == nil {
+			t.Fatalf("%q is accepted", selector)
+		}
+	}
+}
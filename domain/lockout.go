@@ -0,0 +1,58 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 10:10
+ */
+
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	LOCKOUT_KIND_USER   = "user"
+	LOCKOUT_KIND_CLIENT = "client"
+)
+
+// Lockout is failed login state of user id or client address
+type Lockout struct {
+	Kind        string
+	Key         string
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+func (l Lockout) IsLocked() bool {
+	return time.Now().Before(l.LockedUntil)
+}
+
+// LockoutError is returned when login is rejected before authentication because of previous failures
+type LockoutError struct {
+	Kind       string
+	Key        string
+	RetryAfter time.Duration
+}
+
+func (e LockoutError) Error() string {
+	return fmt.Sprintf("too many failed login of %s %s. retry after %d seconds", e.Kind, e.Key, int(e.RetryAfter.Seconds()+0.5))
+}
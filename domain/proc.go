@@ -28,5 +28,6 @@ type ProcRequest struct {
 	GroupId       string `json:"group_id,omitempty"`
 	Group         string `json:"group,omitempty"`
 	Package       string `json:"package,omitempty"`
+	Selector      string `json:"selector,omitempty"`
 	ClientAddress string `json:"client_address,omitempty"`
 }
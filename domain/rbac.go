@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 8:02
+ */
+
+package domain
+
+import "strings"
+
+const (
+	SCOPE_ALL = "*"
+)
+
+// RoleBinding grants role on scope. scope is group name, host:package or * (every group)
+type RoleBinding struct {
+	Scope string
+	Role  Role
+}
+
+func (rb RoleBinding) IsPackageScope() bool {
+	return strings.Contains(rb.Scope, ":")
+}
+
+// Grant is role of user with optional bindings.
+// without bindings, Role applies to every group. with bindings, only bound scopes are accessible
+type Grant struct {
+	Role     Role
+	Bindings []RoleBinding
+}
+
+// HasRole returns true when role is granted on any scope
+func (g Grant) HasRole(role Role) bool {
+	if len(g.Bindings) == 0 {
+		return g.Role.Acceptable(role)
+	}
+	for _, b := range g.Bindings {
+		if b.Role.Acceptable(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// Permits returns true when role is granted on package of group.
+// most specific binding wins : host:package, group, *
+func (g Grant) Permits(group string, point PackagePoint, role Role) bool {
+	if len(g.Bindings) == 0 {
+		return g.Role.Acceptable(role)
+	}
+
+	bound, ok := g.findBinding(group, point)
+	if !ok {
+		return false
+	}
+	return bound.Acceptable(role)
+}
+
+// PermitsAll returns true when role is granted on every group (e.g. user management)
+func (g Grant) PermitsAll(role Role) bool {
+	if len(g.Bindings) == 0 {
+		return g.Role.Acceptable(role)
+	}
+	for _, b := range g.Bindings {
+		if b.Scope == SCOPE_ALL {
+			return b.Role.Acceptable(role)
+		}
+	}
+	return false
+}
+
+// PermitsGroup returns true when role is granted on group itself (not just some packages in it)
+func (g Grant) PermitsGroup(group string, role Role) bool {
+	return g.Permits(group, PackagePoint{}, role)
+}
+
+func (g Grant) findBinding(group string, point PackagePoint) (Role, bool) {
+	if len(point.Host) > 0 {
+		comp := strings.ToLower(point.Host + ":" + point.Name)
+		for _, b := range g.Bindings {
+			if strings.ToLower(b.Scope) == comp {
+				return b.Role, true
+			}
+		}
+	}
+
+	comp := strings.ToLower(group)
+	for _, b := range g.Bindings {
+		if strings.ToLower(b.Scope) == comp {
+			return b.Role, true
+		}
+	}
+
+	for _, b := range g.Bindings {
+		if b.Scope == SCOPE_ALL {
+			return b.Role, true
+		}
+	}
+
+	return ROLE_UNKNOWN, false
+}
+
+func NewRoleBinding(scope string, role string) RoleBinding {
+	return RoleBinding{Scope: strings.TrimSpace(scope), Role: ToRole(role)}
+}
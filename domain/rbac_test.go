@@ -0,0 +1,97 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 5:10
+ */
+package domain
+
+import "testing"
+
+func TestGrantPermits(t *testing.T) {
+	scoped := Grant{Role: ROLE_MONITOR, Bindings: []RoleBinding{
+		NewRoleBinding("Payment", "OPERATOR"),
+		NewRoleBinding("billing", "MONITOR"),
+		NewRoleBinding("host-1:Batch", "MONITOR"),
+		NewRoleBinding("host-2:batch", "OPERATOR"),
+	}}
+	wildcard := Grant{Role: ROLE_MONITOR, Bindings: []RoleBinding{
+		NewRoleBinding("*", "MONITOR"),
+		NewRoleBinding("payment", "OPERATOR"),
+	}}
+
+	cases := []struct {
+		name    string
+		grant   Grant
+		group   string
+		point   PackagePoint
+		role    Role
+		permits bool
+	}{
+		{"global operator", Grant{Role: ROLE_OPERATOR}, "any", PackagePoint{}, ROLE_OPERATOR, true},
+		{"global operator monitors", Grant{Role: ROLE_OPERATOR}, "any", PackagePoint{}, ROLE_MONITOR, true},
+		{"global monitor", Grant{Role: ROLE_MONITOR}, "any", PackagePoint{}, ROLE_OPERATOR, false},
+		{"unknown role", Grant{Role: ROLE_UNKNOWN}, "any", PackagePoint{}, ROLE_MONITOR, false},
+		{"bound group", scoped, "payment", PackagePoint{Host: "host-9", Name: "api"}, ROLE_OPERATOR, true},
+		{"group case", scoped, "PAYMENT", PackagePoint{}, ROLE_OPERATOR, true},
+		{"monitor group", scoped, "billing", PackagePoint{}, ROLE_OPERATOR, false},
+		{"foreign group", scoped, "shipping", PackagePoint{}, ROLE_MONITOR, false},
+		{"role without binding", scoped, "shipping", PackagePoint{Host: "host-9", Name: "api"}, ROLE_MONITOR, false},
+		{"package narrows group", scoped, "payment", PackagePoint{Host: "host-1", Name: "batch"}, ROLE_OPERATOR, false},
+		{"package widens group", scoped, "billing", PackagePoint{Host: "host-2", Name: "batch"}, ROLE_OPERATOR, true},
+		{"package in foreign group", scoped, "shipping", PackagePoint{Host: "host-1", Name: "batch"}, ROLE_MONITOR, true},
+		{"wildcard", wildcard, "shipping", PackagePoint{}, ROLE_MONITOR, true},
+		{"wildcard role", wildcard, "shipping", PackagePoint{}, ROLE_OPERATOR, false},
+		{"group over wildcard", wildcard, "payment", PackagePoint{}, ROLE_OPERATOR, true},
+	}
+
+	for _, c := range cases {
+		if c.grant.Permits(c.group, c.point, c.role) != c.permits {
+			t.Fatalf("%s : permits %t", c.name, !c.permits)
+		}
+	}
+}
+
+func TestGrantPermitsAll(t *testing.T) {
+	cases := []struct {
+		name    string
+		grant   Grant
+		role    Role
+		permits bool
+		hasRole bool
+	}{
+		{"global operator", Grant{Role: ROLE_OPERATOR}, ROLE_OPERATOR, true, true},
+		{"global monitor", Grant{Role: ROLE_MONITOR}, ROLE_OPERATOR, false, false},
+		{"wildcard operator", Grant{Bindings: []RoleBinding{NewRoleBinding("*", "OPERATOR")}}, ROLE_OPERATOR, true, true},
+		{"group operator", Grant{Bindings: []RoleBinding{NewRoleBinding("payment", "OPERATOR")}}, ROLE_OPERATOR, false, true},
+		{"wildcard monitor", Grant{Bindings: []RoleBinding{
+			NewRoleBinding("*", "MONITOR"),
+			NewRoleBinding("payment", "OPERATOR"),
+		}}, ROLE_OPERATOR, false, true},
+	}
+
+	for _, c := range cases {
+		if c.grant.PermitsAll(c.role) != c.permits {
+			t.Fatalf("%s : permits all %t", c.name, !c.permits)
+		}
+		if c.grant.HasRole(c.role) != c.hasRole {
+			t.Fatalf("%s : has role %t", c.name, !c.hasRole)
+		}
+	}
+}
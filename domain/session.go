@@ -0,0 +1,73 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 5:48
+ */
+
+package domain
+
+import "time"
+
+const (
+	TOKEN_KIND_ACCESS  = "access"
+	TOKEN_KIND_INSTANT = "instant"
+	TOKEN_KIND_REFRESH = "refresh"
+	// TOKEN_KIND_CERTIFICATE is session of verified client certificate. it is not stored
+	TOKEN_KIND_CERTIFICATE = "certificate"
+)
+
+// Session describes owner of token. Id is digest of token, token itself is never exposed
+type Session struct {
+	Id            string
+	UserId        string
+	Role          Role
+	IssuedAt      time.Time
+	ExpireAt      time.Time
+	ClientAddress string
+	UserAgent     string
+	Kind          string
+	Bindings      []RoleBinding
+	// AuthenticatedAt is login time. tokens issued by refresh keep login time of refresh token
+	AuthenticatedAt time.Time
+}
+
+func (s Session) Grant() Grant {
+	return Grant{Role: s.Role, Bindings: s.Bindings}
+}
+
+// AuthTime returns login time of session. IssuedAt is used for session stored without login time
+func (s Session) AuthTime() time.Time {
+	if s.AuthenticatedAt.IsZero() {
+		return s.IssuedAt
+	}
+	return s.AuthenticatedAt
+}
+
+func (s Session) IsExpired() bool {
+	return time.Now().After(s.ExpireAt)
+}
+
+func (s Session) IsRefresh() bool {
+	return s.Kind == TOKEN_KIND_REFRESH
+}
+
+func (s Session) IsPending() bool {
+	return s.Kind == TOKEN_KIND_PENDING
+}
@@ -0,0 +1,47 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 19. 오후 1:30
+ */
+package domain
+
+// TOKEN_KIND_PENDING is short-lived token issued after password when second factor is still required
+const TOKEN_KIND_PENDING = "pending"
+
+// Totp is TOTP(RFC 6238) enrollment of user. Secret is base32 encoded.
+// enrollment is not used for login until it is confirmed with first code.
+// RecoveryCodes keeps sha256 digest of single-use recovery codes
+type Totp struct {
+	Secret        string
+	Confirmed     bool
+	LastStep      int64
+	RecoveryCodes []string
+}
+
+func (t Totp) IsEnabled() bool {
+	return len(t.Secret) > 0 && t.Confirmed
+}
+
+// TotpEnrollment is returned to user once on enrollment
+type TotpEnrollment struct {
+	Secret        string   `json:"secret"`
+	Uri           string   `json:"uri"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -24,6 +24,7 @@
 package domain
 
 import (
+	"errors"
 	"strings"
 	"time"
 )
@@ -81,30 +82,54 @@ func ToRoleString(role Role) string {
 }
 
 type User struct {
-	Id       string `json:"id"`
-	Password string `json:"passwd"`
-	Role     Role
+	Id       string        `json:"id"`
+	Password string        `json:"passwd"`
+	Role     Role          `json:"-"`
+	Bindings []RoleBinding `json:"-"`
+	Totp     Totp          `json:"-"`
+}
+
+func (u User) Grant() Grant {
+	return Grant{Role: u.Role, Bindings: u.Bindings}
 }
 
 type UserRepository interface {
-	Save(user User)
+	Save(user User) error
 	FindById(id string) *User
-	Delete(id string)
+	FindAll() []User
+	Delete(id string) error
 	Exists(id string) bool
 	Count() int
 }
 
 type TokenRepository interface {
-	Save(token string, role Role, ttlSeconds time.Duration)
-	FindById(token string) (Role, bool)
+	Save(token string, session Session, ttlSeconds time.Duration)
+	FindById(token string) (Session, bool)
+	FindAll() []Session
+	Delete(token string)
+	DeleteSession(id string) bool
+	Extend(token string, expireAt time.Time) bool
 }
 
+// ErrNotMine is returned (wrapped) by authenticator which does not own the user or cannot decide now.
+// chained authenticator passes the attempt on to next authenticator
+var ErrNotMine = errors.New("not mine")
+
 type Authenticate interface {
 	UserAuthenticate(id, password string) (Role, error)
 }
 
 type TokenService interface {
-	GenerateInstantToken(role Role) (string, error)
-	GenerateToken(role Role) (string, error)
-	ValidateToken(token string, role Role) error
+	GenerateInstantToken(owner Session) (string, error)
+	GenerateToken(owner Session) (string, error)
+	GenerateRefreshToken(owner Session) (string, error)
+	RefreshToken(refreshToken string) (string, string, error)
+	ValidateToken(token string, role Role) (Session, error)
+	GeneratePendingToken(owner Session) (string, error)
+	// RedeemPendingToken returns owner of pending token. the token is not usable anymore
+	RedeemPendingToken(token string) (Session, error)
+	RevokeToken(token string)
+	RevokeSession(id string) bool
+	RevokeUserSessions(userId string) int
+	FindAllSessions() []Session
 }
@@ -0,0 +1,40 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 19. 오전 9:10
+ */
+
+package domain
+
+// UserProfile is user information of directory
+type UserProfile struct {
+	Id          string
+	DisplayName string
+	Email       string
+	Groups      []string
+}
+
+// UserDirectory looks up users and groups of external directory (e.g. ldap helper v2)
+type UserDirectory interface {
+	LookupUser(id string) (UserProfile, error)
+	ListGroups(prefix string) ([]string, error)
+	// ValidateUser checks user is still valid without password. ErrNotMine is returned for unknown user
+	ValidateUser(id string) (Role, error)
+}
@@ -54,6 +54,8 @@ type JupiterHttpServer struct {
 	router        *mux.Router
 	loggingRouter http.Handler
 	listenAddress string
+	tlsReloader   *tlsReloader
+	interactor    *service.DomainInteractor
 }
 
 func (server *JupiterHttpServer) Initialize() bool {
@@ -72,12 +74,21 @@ func (server *JupiterHttpServer) Initialize() bool {
 	server.listenAddress = fmt.Sprintf("%s:%s", server.listenAddress, v)
 	log.Info("web server listen : %s", server.listenAddress)
 
+	if useTls, err := server.fatimaRuntime.GetConfig().GetBool(PropWebServerTls); err == nil && useTls {
+		server.tlsReloader, err = newTlsReloader(server.fatimaRuntime)
+		if err != nil {
+			log.Warn("fail to prepare web server TLS : %s", err.Error())
+			return false
+		}
+	}
+
 	domainInteractor, err := service.NewDomainInteractor(server.fatimaRuntime)
 	if err != nil {
 		log.Warn("fail to create interactor  %s", err.Error())
 		return false
 	}
 
+	server.interactor = domainInteractor
 	server.webService = web.GetWebService()
 	server.webService.Regist(v1.NewWebService(domainInteractor))
 
@@ -109,10 +120,14 @@ func (server *JupiterHttpServer) access(access string) {
 
 func (server *JupiterHttpServer) Bootup() {
 	log.Info("JupiterHttpServer Bootup()")
+	server.interactor.StartJunoHealthMonitor()
+	server.interactor.StartJunoLeaseReaper()
 }
 
 func (server *JupiterHttpServer) Shutdown() {
 	log.Info("JupiterHttpServer Shutdown()")
+	server.interactor.StopJunoLeaseReaper()
+	server.interactor.StopJunoHealthMonitor()
 }
 
 func (server *JupiterHttpServer) GetType() fatima.FatimaComponentType {
@@ -129,8 +144,15 @@ func (server *JupiterHttpServer) StartListening() {
 		ReadTimeout:  60 * time.Second,
 	}
 
-	log.Info("start web server listening...")
-	err := srv.ListenAndServe()
+	var err error
+	if server.tlsReloader != nil {
+		srv.TLSConfig = server.tlsReloader.TLSConfig()
+		log.Info("start web server listening with TLS...")
+		err = srv.ListenAndServeTLS("", "")
+	} else {
+		log.Info("start web server listening...")
+		err = srv.ListenAndServe()
+	}
 	if err != nil {
 		log.Error("fail to start web server : %s", err.Error())
 		server.fatimaRuntime.Stop()
@@ -0,0 +1,210 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 19. 오후 4:10
+ */
+package engine
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/fatima-go/fatima-core"
+	"github.com/fatima-go/fatima-log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// webserver.tls=true
+// webserver.tls.cert.file=/path/to/server.crt
+// webserver.tls.key.file=/path/to/server.key
+const (
+	PropWebServerTls                   = "webserver.tls"
+	PropWebServerTlsCertFile           = "webserver.tls.cert.file"
+	PropWebServerTlsKeyFile            = "webserver.tls.key.file"
+	PropWebServerTlsClientCaFile       = "webserver.tls.client.ca.file"
+	PropWebServerTlsClientAuth         = "webserver.tls.client.auth"
+	PropWebServerTlsReloadSeconds      = "webserver.tls.reload.seconds"
+	ValueWebServerTlsClientAuthNone    = "none"
+	ValueWebServerTlsClientAuthRequest = "request"
+	ValueWebServerTlsClientAuthRequire = "require"
+	defaultWebServerTlsReloadSeconds   = 30
+)
+
+// newTlsReloader loads certificate files of web server. files are checked periodically
+// and reloaded when modified, so renewed certificate applies to next handshake without restart
+func newTlsReloader(fatimaRuntime fatima.FatimaRuntime) (*tlsReloader, error) {
+	config := fatimaRuntime.GetConfig()
+	reloader := &tlsReloader{clientAuth: tls.NoClientCert}
+
+	var err error
+	reloader.certFile, err = config.GetString(PropWebServerTlsCertFile)
+	if err != nil {
+		return nil, fmt.Errorf("%s is required for TLS", PropWebServerTlsCertFile)
+	}
+	reloader.keyFile, err = config.GetString(PropWebServerTlsKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("%s is required for TLS", PropWebServerTlsKeyFile)
+	}
+	reloader.caFile, _ = config.GetValue(PropWebServerTlsClientCaFile)
+
+	clientAuth, ok := config.GetValue(PropWebServerTlsClientAuth)
+	if !ok {
+		clientAuth = ValueWebServerTlsClientAuthNone
+	}
+	switch strings.ToLower(clientAuth) {
+	case ValueWebServerTlsClientAuthNone:
+	case ValueWebServerTlsClientAuthRequest:
+		reloader.clientAuth = tls.VerifyClientCertIfGiven
+	case ValueWebServerTlsClientAuthRequire:
+		reloader.clientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("unknown %s %s", PropWebServerTlsClientAuth, clientAuth)
+	}
+	if reloader.clientAuth != tls.NoClientCert && len(reloader.caFile) == 0 {
+		return nil, fmt.Errorf("%s is required for client certificate", PropWebServerTlsClientCaFile)
+	}
+
+	err = reloader.load()
+	if err != nil {
+		return nil, err
+	}
+
+	seconds, err := config.GetInt(PropWebServerTlsReloadSeconds)
+	if err != nil {
+		seconds = defaultWebServerTlsReloadSeconds
+	}
+	if seconds > 0 {
+		ticker := time.NewTicker(time.Second * time.Duration(seconds))
+		go func() {
+			for range ticker.C {
+				reloader.reloadIfModified()
+			}
+		}()
+	}
+
+	log.Info("web server TLS. cert : %s, client auth : %s, reload : %d seconds", reloader.certFile, clientAuth, seconds)
+	return reloader, nil
+}
+
+type tlsReloader struct {
+	certFile   string
+	keyFile    string
+	caFile     string
+	clientAuth tls.ClientAuthType
+	modTime    time.Time
+	cert       *tls.Certificate
+	clientCAs  *x509.CertPool
+	mutex      sync.RWMutex
+}
+
+// TLSConfig returns server config which picks up current certificate and client CA on each handshake
+func (r *tlsReloader) TLSConfig() *tls.Config {
+	base := &tls.Config{MinVersion: tls.VersionTLS12, ClientAuth: r.clientAuth}
+	base.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		r.mutex.RLock()
+		defer r.mutex.RUnlock()
+		return r.cert, nil
+	}
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mutex.RLock()
+		defer r.mutex.RUnlock()
+		c := base.Clone()
+		c.GetConfigForClient = nil
+		c.ClientCAs = r.clientCAs
+		return c, nil
+	}
+	return base
+}
+
+// lastModified returns latest modification time of certificate files
+func (r *tlsReloader) lastModified() (time.Time, error) {
+	latest := time.Time{}
+	for _, f := range []string{r.certFile, r.keyFile, r.caFile} {
+		if len(f) == 0 {
+			continue
+		}
+		info, err := os.Stat(f)
+		if err != nil {
+			return latest, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (r *tlsReloader) load() error {
+	modTime, err := r.lastModified()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("fail to load web server certificate : %s", err.Error())
+	}
+
+	var clientCAs *x509.CertPool
+	if len(r.caFile) > 0 {
+		b, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("fail to read %s : %s", r.caFile, err.Error())
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(b) {
+			return errors.New(fmt.Sprintf("no certificate in %s", r.caFile))
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.modTime = modTime
+	return nil
+}
+
+// reloadIfModified keeps current certificate when new files are broken (e.g. half written)
+func (r *tlsReloader) reloadIfModified() {
+	modTime, err := r.lastModified()
+	if err != nil {
+		log.Warn("fail to check web server certificate : %s", err.Error())
+		return
+	}
+
+	r.mutex.RLock()
+	changed := modTime.After(r.modTime)
+	r.mutex.RUnlock()
+	if !changed {
+		return
+	}
+
+	err = r.load()
+	if err != nil {
+		log.Warn("keep current web server certificate : %s", err.Error())
+		return
+	}
+	log.Info("web server certificate reloaded")
+}
@@ -0,0 +1,154 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 7:20
+ */
+package engine
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCertificate writes self signed certificate and key of common name, and sets modification time
+func writeTestCertificate(t *testing.T, certFile string, keyFile string, cn string, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("fail to generate key : %s", err.Error())
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("fail to create certificate : %s", err.Error())
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("fail to marshal key : %s", err.Error())
+	}
+
+	writeTestFile(t, certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), modTime)
+	writeTestFile(t, keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), modTime)
+}
+
+func writeTestFile(t *testing.T, file string, content []byte, modTime time.Time) {
+	if err := os.WriteFile(file, content, 0600); err != nil {
+		t.Fatalf("fail to write %s : %s", file, err.Error())
+	}
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatalf("fail to change time of %s : %s", file, err.Error())
+	}
+}
+
+func TestTlsReloaderReload(t *testing.T) {
+	dir := t.TempDir()
+	reloader := &tlsReloader{certFile: filepath.Join(dir, "server.crt"), keyFile: filepath.Join(dir, "server.key")}
+	base := time.Now().Add(-time.Hour)
+	writeTestCertificate(t, reloader.certFile, reloader.keyFile, "first", base)
+	if err := reloader.load(); err != nil {
+		t.Fatalf("fail to load certificate : %s", err.Error())
+	}
+
+	config := reloader.TLSConfig()
+	commonName := func() string {
+		cert, err := config.GetCertificate(nil)
+		if err != nil {
+			t.Fatalf("fail to get certificate : %s", err.Error())
+		}
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatalf("fail to parse certificate : %s", err.Error())
+		}
+		return leaf.Subject.CommonName
+	}
+
+	cases := []struct {
+		name    string
+		prepare func()
+		cn      string
+	}{
+		{"not modified", func() {}, "first"},
+		{"renewed", func() {
+			writeTestCertificate(t, reloader.certFile, reloader.keyFile, "second", base.Add(time.Minute))
+		}, "second"},
+		{"broken certificate", func() {
+			writeTestFile(t, reloader.certFile, []byte("half written"), base.Add(time.Minute*2))
+		}, "second"},
+		{"missing key", func() {
+			os.Remove(reloader.keyFile)
+		}, "second"},
+		{"renewed after failure", func() {
+			writeTestCertificate(t, reloader.certFile, reloader.keyFile, "third", base.Add(time.Minute*3))
+		}, "third"},
+	}
+
+	for _, c := range cases {
+		c.prepare()
+		reloader.reloadIfModified()
+		if cn := commonName(); cn != c.cn {
+			t.Fatalf("%s : certificate %s, expected %s", c.name, cn, c.cn)
+		}
+	}
+}
+
+func TestTlsReloaderClientCA(t *testing.T) {
+	dir := t.TempDir()
+	reloader := &tlsReloader{
+		certFile: filepath.Join(dir, "server.crt"),
+		keyFile:  filepath.Join(dir, "server.key"),
+		caFile:   filepath.Join(dir, "client-ca.crt"),
+	}
+	base := time.Now().Add(-time.Hour)
+	writeTestCertificate(t, reloader.certFile, reloader.keyFile, "server", base)
+	writeTestCertificate(t, reloader.caFile, filepath.Join(dir, "client-ca.key"), "first ca", base)
+	if err := reloader.load(); err != nil {
+		t.Fatalf("fail to load certificate : %s", err.Error())
+	}
+
+	config := reloader.TLSConfig()
+	first, _ := config.GetConfigForClient(nil)
+
+	writeTestCertificate(t, reloader.caFile, filepath.Join(dir, "client-ca.key"), "second ca", base.Add(time.Minute))
+	reloader.reloadIfModified()
+	second, _ := config.GetConfigForClient(nil)
+	if first.ClientCAs == nil || second.ClientCAs == nil || first.ClientCAs.Equal(second.ClientCAs) {
+		t.Fatalf("client CA is not reloaded")
+	}
+
+	writeTestFile(t, reloader.caFile, []byte("no certificate"), base.Add(time.Minute*2))
+	reloader.reloadIfModified()
+	kept, _ := config.GetConfigForClient(nil)
+	if !kept.ClientCAs.Equal(second.ClientCAs) {
+		t.Fatalf("broken client CA replaced current one")
+	}
+}
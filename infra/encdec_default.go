@@ -23,7 +23,10 @@
 
 package infra
 
-import "github.com/fatima-go/jupiter/domain"
+import (
+	"crypto/subtle"
+	"github.com/fatima-go/jupiter/domain"
+)
 
 func NewDefaultEncdec() domain.Encdec {
 	encdec := DefaultEncdec{}
@@ -44,3 +47,14 @@ func (handler *DefaultEncdec) Decrypt(content string) string {
 func (handler *DefaultEncdec) Hash(content string) string {
 	return content
 }
+
+func (handler *DefaultEncdec) Verify(content string, hashed string) bool {
+	if len(hashed) == 0 {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(handler.Hash(content)), []byte(hashed)) == 1
+}
+
+func (handler *DefaultEncdec) NeedRehash(hashed string) bool {
+	return false
+}
@@ -0,0 +1,195 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 4:40
+ */
+
+package infra
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+const (
+	HASH_ALGORITHM_BCRYPT   = "bcrypt"
+	HASH_ALGORITHM_ARGON2ID = "argon2id"
+
+	bcryptCost = 12
+
+	// argon2id parameters (OWASP recommendation)
+	argon2Memory  = 19 * 1024
+	argon2Time    = 2
+	argon2Threads = 1
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+
+	// stored argon2id parameters out of these bounds are rejected without hashing
+	argon2MaxMemory  = 1024 * 1024 // KiB, 1 GiB
+	argon2MaxTime    = 16
+	argon2MaxThreads = 255
+	argon2MinKeyLen  = 16
+	argon2MaxKeyLen  = 64
+)
+
+// NewHashEncdec returns Encdec which stores salted adaptive hash.
+// bcrypt hash is stored as modular crypt string($2a$...),
+// argon2id hash is stored as PHC string($argon2id$v=19$m=...,t=...,p=...$salt$hash)
+func NewHashEncdec(algorithm string) (domain.Encdec, error) {
+	switch strings.ToLower(algorithm) {
+	case HASH_ALGORITHM_BCRYPT:
+		return &HashEncdec{algorithm: HASH_ALGORITHM_BCRYPT}, nil
+	case HASH_ALGORITHM_ARGON2ID:
+		return &HashEncdec{algorithm: HASH_ALGORITHM_ARGON2ID}, nil
+	}
+	return nil, fmt.Errorf("unsupported hash algorithm %s", algorithm)
+}
+
+type HashEncdec struct {
+	algorithm string
+}
+
+func (handler *HashEncdec) Encrypt(content string) string {
+	return content
+}
+
+func (handler *HashEncdec) Decrypt(content string) string {
+	return content
+}
+
+func (handler *HashEncdec) Hash(content string) string {
+	switch handler.algorithm {
+	case HASH_ALGORITHM_ARGON2ID:
+		return hashArgon2id(content)
+	}
+
+	b, err := bcrypt.GenerateFromPassword([]byte(content), bcryptCost)
+	if err != nil {
+		log.Error("fail to build bcrypt hash : %s", err.Error())
+		return ""
+	}
+	return string(b)
+}
+
+func (handler *HashEncdec) Verify(content string, hashed string) bool {
+	if len(hashed) == 0 {
+		return false
+	}
+
+	if isBcryptHash(hashed) {
+		return bcrypt.CompareHashAndPassword([]byte(hashed), []byte(content)) == nil
+	}
+
+	if strings.HasPrefix(hashed, "$argon2id$") {
+		return verifyArgon2id(content, hashed)
+	}
+
+	// legacy plain text
+	return subtle.ConstantTimeCompare([]byte(content), []byte(hashed)) == 1
+}
+
+func (handler *HashEncdec) NeedRehash(hashed string) bool {
+	switch handler.algorithm {
+	case HASH_ALGORITHM_ARGON2ID:
+		params, _, _, err := parseArgon2id(hashed)
+		if err != nil {
+			return true
+		}
+		return params != fmt.Sprintf("m=%d,t=%d,p=%d", argon2Memory, argon2Time, argon2Threads)
+	}
+
+	if !isBcryptHash(hashed) {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(hashed))
+	return err != nil || cost != bcryptCost
+}
+
+func isBcryptHash(hashed string) bool {
+	return strings.HasPrefix(hashed, "$2a$") ||
+		strings.HasPrefix(hashed, "$2b$") ||
+		strings.HasPrefix(hashed, "$2y$")
+}
+
+func hashArgon2id(content string) string {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		log.Error("fail to build argon2id salt : %s", err.Error())
+		return ""
+	}
+
+	key := argon2.IDKey([]byte(content), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, argon2Memory, argon2Time, argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key))
+}
+
+func verifyArgon2id(content string, hashed string) bool {
+	params, salt, key, err := parseArgon2id(hashed)
+	if err != nil {
+		log.Warn("invalid argon2id hash : %s", err.Error())
+		return false
+	}
+
+	var memory, iterations, threads uint64
+	_, err = fmt.Sscanf(params, "m=%d,t=%d,p=%d", &memory, &iterations, &threads)
+	if err != nil {
+		log.Warn("invalid argon2id parameter : %s", err.Error())
+		return false
+	}
+	if memory > argon2MaxMemory || iterations < 1 || iterations > argon2MaxTime ||
+		threads < 1 || threads > argon2MaxThreads || len(key) < argon2MinKeyLen || len(key) > argon2MaxKeyLen {
+		log.Warn("argon2id parameter out of bounds : %s, key=%d bytes", params, len(key))
+		return false
+	}
+
+	comp := argon2.IDKey([]byte(content), salt, uint32(iterations), uint32(memory), uint8(threads), uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, comp) == 1
+}
+
+// parseArgon2id splits $argon2id$v=19$m=19456,t=2,p=1$salt$hash
+func parseArgon2id(hashed string) (params string, salt []byte, key []byte, err error) {
+	fields := strings.Split(hashed, "$")
+	if len(fields) != 6 || fields[1] != HASH_ALGORITHM_ARGON2ID {
+		err = fmt.Errorf("not argon2id hash")
+		return
+	}
+
+	if fields[2] != fmt.Sprintf("v=%d", argon2.Version) {
+		err = fmt.Errorf("unsupported argon2 version %s", fields[2])
+		return
+	}
+
+	params = fields[3]
+	salt, err = base64.RawStdEncoding.DecodeString(fields[4])
+	if err != nil {
+		return
+	}
+	key, err = base64.RawStdEncoding.DecodeString(fields[5])
+	return
+}
@@ -0,0 +1,139 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 3:50
+ */
+package infra
+
+import (
+	"encoding/base64"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"testing"
+)
+
+func TestHashEncdecVerify(t *testing.T) {
+	for _, algorithm := range []string{HASH_ALGORITHM_BCRYPT, HASH_ALGORITHM_ARGON2ID} {
+		encdec, err := NewHashEncdec(algorithm)
+		if err != nil {
+			t.Fatalf("%s : %s", algorithm, err.Error())
+		}
+
+		hashed := encdec.Hash("secret-password")
+		if len(hashed) == 0 || hashed == "secret-password" {
+			t.Fatalf("%s : password is not hashed", algorithm)
+		}
+		if other := encdec.Hash("secret-password"); other == hashed {
+			t.Fatalf("%s : hash is not salted", algorithm)
+		}
+
+		cases := []struct {
+			content string
+			hashed  string
+			matches bool
+		}{
+			{"secret-password", hashed, true},
+			{"secret-passwore", hashed, false},
+			{"", hashed, false},
+			{"secret-password", "", false},
+			{"plain-password", "plain-password", true},
+			{"plain-password", "plain-passwore", false},
+			{"secret-password", "$argon2id$v=19$broken", false},
+		}
+		for _, c := range cases {
+			if encdec.Verify(c.content, c.hashed) != c.matches {
+				t.Fatalf("%s : verify %q with %q is %t", algorithm, c.content, c.hashed, !c.matches)
+			}
+		}
+		if encdec.NeedRehash(hashed) {
+			t.Fatalf("%s : fresh hash needs rehash", algorithm)
+		}
+	}
+}
+
+func TestHashEncdecNeedRehash(t *testing.T) {
+	weakBcrypt, _ := bcrypt.GenerateFromPassword([]byte("secret-password"), bcrypt.MinCost)
+	salt := base64.RawStdEncoding.EncodeToString([]byte(strings.Repeat("s", argon2SaltLen)))
+	weakArgon2id := fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, 8*1024, 1, 1, salt,
+		base64.RawStdEncoding.EncodeToString(argon2.IDKey([]byte("secret-password"), []byte(strings.Repeat("s", argon2SaltLen)), 1, 8*1024, 1, argon2KeyLen)))
+
+	bcryptEncdec, _ := NewHashEncdec(HASH_ALGORITHM_BCRYPT)
+	argon2Encdec, _ := NewHashEncdec(HASH_ALGORITHM_ARGON2ID)
+	bcryptHash := bcryptEncdec.Hash("secret-password")
+	argon2Hash := argon2Encdec.Hash("secret-password")
+
+	cases := []struct {
+		name      string
+		algorithm string
+		hashed    string
+		rehash    bool
+	}{
+		{"bcrypt current", HASH_ALGORITHM_BCRYPT, bcryptHash, false},
+		{"bcrypt weak cost", HASH_ALGORITHM_BCRYPT, string(weakBcrypt), true},
+		{"bcrypt from argon2id", HASH_ALGORITHM_BCRYPT, argon2Hash, true},
+		{"bcrypt from plain", HASH_ALGORITHM_BCRYPT, "secret-password", true},
+		{"argon2id current", HASH_ALGORITHM_ARGON2ID, argon2Hash, false},
+		{"argon2id weak parameter", HASH_ALGORITHM_ARGON2ID, weakArgon2id, true},
+		{"argon2id from bcrypt", HASH_ALGORITHM_ARGON2ID, bcryptHash, true},
+		{"argon2id from plain", HASH_ALGORITHM_ARGON2ID, "secret-password", true},
+	}
+
+	for _, c := range cases {
+		encdec, _ := NewHashEncdec(c.algorithm)
+		if encdec.NeedRehash(c.hashed) != c.rehash {
+			t.Fatalf("%s : need rehash %t", c.name, !c.rehash)
+		}
+		if !encdec.Verify("secret-password", c.hashed) {
+			t.Fatalf("%s : old hash is not verified", c.name)
+		}
+	}
+}
+
+func TestVerifyArgon2idBounds(t *testing.T) {
+	salt := base64.RawStdEncoding.EncodeToString([]byte(strings.Repeat("s", argon2SaltLen)))
+	key := func(n int) string {
+		return base64.RawStdEncoding.EncodeToString([]byte(strings.Repeat("k", n)))
+	}
+
+	cases := []struct {
+		name   string
+		params string
+		key    string
+	}{
+		{"memory over 1 GiB", "m=1048577,t=2,p=1", key(argon2KeyLen)},
+		{"memory overflow", "m=4294967296,t=2,p=1", key(argon2KeyLen)},
+		{"zero time", "m=19456,t=0,p=1", key(argon2KeyLen)},
+		{"time over 16", "m=19456,t=17,p=1", key(argon2KeyLen)},
+		{"zero threads", "m=19456,t=2,p=0", key(argon2KeyLen)},
+		{"threads over 255", "m=19456,t=2,p=256", key(argon2KeyLen)},
+		{"empty key", "m=19456,t=2,p=1", ""},
+		{"short key", "m=19456,t=2,p=1", key(argon2MinKeyLen - 1)},
+		{"long key", "m=19456,t=2,p=1", key(argon2MaxKeyLen + 1)},
+	}
+
+	for _, c := range cases {
+		hashed := fmt.Sprintf("$argon2id$v=19$%s$%s$%s", c.params, salt, c.key)
+		if verifyArgon2id("secret", hashed) {
+			t.Fatalf("%s : %s is accepted", c.name, hashed)
+		}
+	}
+}
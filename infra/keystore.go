@@ -28,7 +28,11 @@ import (
 	"time"
 )
 
+// KeyStore keeps session of token. stores are keyed by token digest, see digestToken
 type KeyStore interface {
-	Put(token string, role domain.Role, ttlSeconds time.Duration)
-	Get(token string) (domain.Role, bool)
+	Put(token string, session domain.Session, ttlSeconds time.Duration)
+	Get(token string) (domain.Session, bool)
+	List() []domain.Session
+	Remove(id string) bool
+	Extend(id string, expireAt time.Time) bool
 }
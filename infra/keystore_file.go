@@ -0,0 +1,240 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 5:12
+ */
+
+package infra
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"os"
+	"sync"
+	"time"
+)
+
+// newFileKeyStore creates key store which keeps tokens in json file.
+// token itself is not written to file, only its sha256 digest
+func newFileKeyStore(filePath string) *FileKeyStore {
+	keyStore := new(FileKeyStore)
+	keyStore.filePath = filePath
+	keyStore.tokens = keyStore.load()
+
+	clearTick := time.NewTicker(time.Second * TOKEN_EXPIRE_SCANNING_TICK_SECONDS)
+	go func() {
+		for range clearTick.C {
+			keyStore.clear()
+		}
+	}()
+
+	return keyStore
+}
+
+type FileTokenAcl struct {
+	Role          string            `json:"role"`
+	ExpireAt      int64             `json:"expire_at"`
+	UserId        string            `json:"user_id,omitempty"`
+	IssuedAt      int64             `json:"issued_at,omitempty"`
+	ClientAddress string            `json:"client_address,omitempty"`
+	UserAgent     string            `json:"user_agent,omitempty"`
+	Kind          string            `json:"kind,omitempty"`
+	Bindings      map[string]string `json:"bindings,omitempty"`
+	AuthAt        int64             `json:"auth_at,omitempty"`
+}
+
+func (acl FileTokenAcl) toSession(id string) domain.Session {
+	session := domain.Session{}
+	session.Id = id
+	session.UserId = acl.UserId
+	session.Role = domain.ToRole(acl.Role)
+	session.IssuedAt = time.Unix(acl.IssuedAt, 0)
+	session.ExpireAt = time.Unix(acl.ExpireAt, 0)
+	session.ClientAddress = acl.ClientAddress
+	session.UserAgent = acl.UserAgent
+	session.Kind = acl.Kind
+	for scope, role := range acl.Bindings {
+		session.Bindings = append(session.Bindings, domain.NewRoleBinding(scope, role))
+	}
+	if acl.AuthAt > 0 {
+		session.AuthenticatedAt = time.Unix(acl.AuthAt, 0)
+	}
+	return session
+}
+
+func newFileTokenAcl(session domain.Session) FileTokenAcl {
+	acl := FileTokenAcl{}
+	acl.Role = session.Role.String()
+	acl.ExpireAt = session.ExpireAt.Unix()
+	acl.UserId = session.UserId
+	acl.IssuedAt = session.IssuedAt.Unix()
+	acl.ClientAddress = session.ClientAddress
+	acl.UserAgent = session.UserAgent
+	acl.Kind = session.Kind
+	if !session.AuthenticatedAt.IsZero() {
+		acl.AuthAt = session.AuthenticatedAt.Unix()
+	}
+	if len(session.Bindings) > 0 {
+		acl.Bindings = make(map[string]string)
+		for _, b := range session.Bindings {
+			acl.Bindings[b.Scope] = b.Role.String()
+		}
+	}
+	return acl
+}
+
+type FileKeyStore struct {
+	filePath string
+	tokens   map[string]FileTokenAcl
+	mutex    sync.RWMutex
+}
+
+func (t *FileKeyStore) load() map[string]FileTokenAcl {
+	tokens := make(map[string]FileTokenAcl)
+
+	b, err := os.ReadFile(t.filePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warn("read file fail : %s", err.Error())
+		}
+		return tokens
+	}
+
+	err = json.Unmarshal(b, &tokens)
+	if err != nil {
+		log.Warn("json fail : %s", err.Error())
+		return make(map[string]FileTokenAcl)
+	}
+
+	now := time.Now().Unix()
+	for k, v := range tokens {
+		if now > v.ExpireAt {
+			delete(tokens, k)
+		}
+	}
+
+	log.Info("%d tokens loaded from %s", len(tokens), t.filePath)
+	return tokens
+}
+
+// sync writes tokens to file. caller should hold write lock
+func (t *FileKeyStore) sync() {
+	b, err := json.Marshal(t.tokens)
+	if err != nil {
+		log.Warn("fail to build token data : %s", err.Error())
+		return
+	}
+
+	err = writeFileAtomic(t.filePath, b, 0600)
+	if err != nil {
+		log.Warn("fail to write token file : %s", err.Error())
+	}
+}
+
+func (t *FileKeyStore) Put(token string, session domain.Session, ttlSeconds time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	session = buildSession(token, session, ttlSeconds)
+	t.tokens[session.Id] = newFileTokenAcl(session)
+	t.sync()
+}
+
+func (t *FileKeyStore) Get(token string) (session domain.Session, ok bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	id := digestToken(token)
+	acl, ok := t.tokens[id]
+	if !ok {
+		return
+	}
+	if time.Now().Unix() > acl.ExpireAt {
+		return session, false
+	}
+	session = acl.toSession(id)
+	return
+}
+
+func (t *FileKeyStore) List() []domain.Session {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	now := time.Now().Unix()
+	list := make([]domain.Session, 0, len(t.tokens))
+	for k, v := range t.tokens {
+		if now <= v.ExpireAt {
+			list = append(list, v.toSession(k))
+		}
+	}
+	return list
+}
+
+func (t *FileKeyStore) Remove(id string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	_, ok := t.tokens[id]
+	if !ok {
+		return false
+	}
+	delete(t.tokens, id)
+	t.sync()
+	return true
+}
+
+func (t *FileKeyStore) Extend(id string, expireAt time.Time) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	acl, ok := t.tokens[id]
+	if !ok {
+		return false
+	}
+	acl.ExpireAt = expireAt.Unix()
+	t.tokens[id] = acl
+	t.sync()
+	return true
+}
+
+func (t *FileKeyStore) clear() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	now := time.Now().Unix()
+	removed := 0
+	for k, v := range t.tokens {
+		if now > v.ExpireAt {
+			log.Debug("token expired : %s", k)
+			delete(t.tokens, k)
+			removed++
+		}
+	}
+	if removed > 0 {
+		t.sync()
+	}
+}
+
+// DigestToken returns id of token in key store
+func DigestToken(token string) string {
+	return digestToken(token)
+}
+
+func digestToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
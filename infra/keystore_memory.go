@@ -52,7 +52,7 @@ func newInMemoryKeyStore() *InMemoryKeyStore {
 var tokenData map[string]TokenAcl
 
 type TokenAcl struct {
-	role     domain.Role
+	session  domain.Session
 	expireAt time.Time
 }
 
@@ -60,23 +60,60 @@ type InMemoryKeyStore struct {
 	mutex sync.RWMutex
 }
 
-func (t *InMemoryKeyStore) Put(token string, role domain.Role, ttlSeconds time.Duration) {
+func (t *InMemoryKeyStore) Put(token string, session domain.Session, ttlSeconds time.Duration) {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
-	tokenData[token] = TokenAcl{role, time.Now().Add(ttlSeconds)}
+	session = buildSession(token, session, ttlSeconds)
+	tokenData[session.Id] = TokenAcl{session, session.ExpireAt}
 }
 
-func (t *InMemoryKeyStore) Get(token string) (role domain.Role, ok bool) {
+func (t *InMemoryKeyStore) Get(token string) (session domain.Session, ok bool) {
 	t.mutex.RLock()
 	defer t.mutex.RUnlock()
-	acl, ok := tokenData[token]
+	acl, ok := tokenData[digestToken(token)]
 	if !ok {
 		return
 	}
-	role = acl.role
+	if time.Now().After(acl.expireAt) {
+		return session, false
+	}
+	session = acl.session
 	return
 }
 
+func (t *InMemoryKeyStore) List() []domain.Session {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	list := make([]domain.Session, 0, len(tokenData))
+	for _, v := range tokenData {
+		if time.Now().Before(v.expireAt) {
+			list = append(list, v.session)
+		}
+	}
+	return list
+}
+
+func (t *InMemoryKeyStore) Remove(id string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	_, ok := tokenData[id]
+	delete(tokenData, id)
+	return ok
+}
+
+func (t *InMemoryKeyStore) Extend(id string, expireAt time.Time) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	acl, ok := tokenData[id]
+	if !ok {
+		return false
+	}
+	acl.session.ExpireAt = expireAt
+	acl.expireAt = expireAt
+	tokenData[id] = acl
+	return true
+}
+
 func (t *InMemoryKeyStore) clear() {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
@@ -87,3 +124,13 @@ func (t *InMemoryKeyStore) clear() {
 		}
 	}
 }
+
+// buildSession fills id and time of session
+func buildSession(token string, session domain.Session, ttlSeconds time.Duration) domain.Session {
+	session.Id = digestToken(token)
+	if session.IssuedAt.IsZero() {
+		session.IssuedAt = time.Now()
+	}
+	session.ExpireAt = time.Now().Add(ttlSeconds)
+	return session
+}
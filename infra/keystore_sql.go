@@ -0,0 +1,129 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오전 12:10
+ */
+package infra
+
+import (
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"time"
+)
+
+// newSqlKeyStore creates key store which keeps tokens in jupiter_token table.
+// like file key store, only sha256 digest of token is stored
+func newSqlKeyStore(database *SqlDatabase) *SqlKeyStore {
+	keyStore := new(SqlKeyStore)
+	keyStore.database = database
+
+	clearTick := time.NewTicker(time.Second * TOKEN_EXPIRE_SCANNING_TICK_SECONDS)
+	go func() {
+		for range clearTick.C {
+			keyStore.clear()
+		}
+	}()
+
+	return keyStore
+}
+
+type SqlKeyStore struct {
+	database *SqlDatabase
+}
+
+func (t *SqlKeyStore) query(query string, args ...interface{}) []domain.Session {
+	list := make([]domain.Session, 0)
+	rows, err := t.database.db.Query(query, args...)
+	if err != nil {
+		log.Warn("fail to find tokens : %s", err.Error())
+		return list
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, bindings string
+		var acl FileTokenAcl
+		err = rows.Scan(&id, &acl.UserId, &acl.Role, &acl.IssuedAt, &acl.ExpireAt,
+			&acl.ClientAddress, &acl.UserAgent, &acl.Kind, &bindings, &acl.AuthAt)
+		if err == nil {
+			err = unmarshalSqlColumn(bindings, &acl.Bindings)
+		}
+		if err != nil {
+			log.Warn("fail to read token : %s", err.Error())
+			continue
+		}
+		list = append(list, acl.toSession(id))
+	}
+	return list
+}
+
+func (t *SqlKeyStore) Put(token string, session domain.Session, ttlSeconds time.Duration) {
+	session = buildSession(token, session, ttlSeconds)
+	acl := newFileTokenAcl(session)
+	bindings, err := marshalSqlColumn(acl.Bindings, len(acl.Bindings) == 0)
+	if err == nil {
+		_, err = t.database.db.Exec(t.database.provider.GetSqlInsertToken(), session.Id, acl.UserId, acl.Role,
+			acl.IssuedAt, acl.ExpireAt, acl.ClientAddress, acl.UserAgent, acl.Kind, bindings, acl.AuthAt)
+	}
+	if err != nil {
+		log.Warn("fail to save token : %s", err.Error())
+	}
+}
+
+func (t *SqlKeyStore) Get(token string) (session domain.Session, ok bool) {
+	list := t.query(t.database.provider.GetSqlFindRoleFromToken(), digestToken(token), time.Now().Unix())
+	if len(list) == 0 {
+		return
+	}
+	return list[0], true
+}
+
+func (t *SqlKeyStore) List() []domain.Session {
+	return t.query(t.database.provider.GetSqlFindAllTokens(), time.Now().Unix())
+}
+
+func (t *SqlKeyStore) exec(query string, args ...interface{}) bool {
+	result, err := t.database.db.Exec(query, args...)
+	if err != nil {
+		log.Warn("fail to change token : %s", err.Error())
+		return false
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0
+}
+
+func (t *SqlKeyStore) Remove(id string) bool {
+	return t.exec(t.database.provider.GetSqlDeleteToken(), id)
+}
+
+func (t *SqlKeyStore) Extend(id string, expireAt time.Time) bool {
+	return t.exec(t.database.provider.GetSqlExtendToken(), expireAt.Unix(), id)
+}
+
+func (t *SqlKeyStore) clear() {
+	result, err := t.database.db.Exec(t.database.provider.GetSqlDeleteOldToken(), time.Now().Unix())
+	if err != nil {
+		log.Warn("fail to clear expired tokens : %s", err.Error())
+		return
+	}
+	if removed, _ := result.RowsAffected(); removed > 0 {
+		log.Debug("%d tokens expired", removed)
+	}
+}
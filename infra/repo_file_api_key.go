@@ -0,0 +1,251 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 9:05
+ */
+
+package infra
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/fatima-go/fatima-core"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	API_KEY_DATA_FILE = "api_key.json"
+
+	// last used time is written to file at most once in this interval per key
+	apiKeyTouchSyncInterval = time.Minute
+)
+
+func NewFileApiKeyRepository(fatimaRuntime fatima.FatimaRuntime) domain.ApiKeyRepository {
+	return NewFileApiKeyRepositoryWithPath(filepath.Join(
+		fatimaRuntime.GetEnv().GetFolderGuide().GetDataFolder(),
+		API_KEY_DATA_FILE))
+}
+
+// NewFileApiKeyRepositoryWithPath creates file api key repository on apiKeyFilePath
+func NewFileApiKeyRepositoryWithPath(apiKeyFilePath string) domain.ApiKeyRepository {
+	repo := new(FileApiKeyRepository)
+	repo.filePath = apiKeyFilePath
+	repo.keys = repo.load()
+	return repo
+}
+
+type FileApiKey struct {
+	Digest          string `json:"digest"`
+	Role            string `json:"role"`
+	Scope           string `json:"scope,omitempty"`
+	CreatedBy       string `json:"created_by,omitempty"`
+	CreatedAt       int64  `json:"created_at"`
+	ExpireAt        int64  `json:"expire_at,omitempty"`
+	LastUsedAt      int64  `json:"last_used_at,omitempty"`
+	LastUsedAddress string `json:"last_used_address,omitempty"`
+
+	// last used time written to file. touch compares with it, not with LastUsedAt
+	syncedAt int64
+}
+
+func (fk FileApiKey) toApiKey(name string) domain.ApiKey {
+	key := domain.ApiKey{}
+	key.Name = name
+	key.Digest = fk.Digest
+	key.Role = domain.ToRole(fk.Role)
+	key.Scope = fk.Scope
+	key.CreatedBy = fk.CreatedBy
+	key.CreatedAt = time.Unix(fk.CreatedAt, 0)
+	if fk.ExpireAt > 0 {
+		key.ExpireAt = time.Unix(fk.ExpireAt, 0)
+	}
+	if fk.LastUsedAt > 0 {
+		key.LastUsedAt = time.Unix(fk.LastUsedAt, 0)
+	}
+	key.LastUsedAddress = fk.LastUsedAddress
+	return key
+}
+
+func newFileApiKey(key domain.ApiKey) FileApiKey {
+	fk := FileApiKey{}
+	fk.Digest = key.Digest
+	fk.Role = key.Role.String()
+	fk.Scope = key.Scope
+	fk.CreatedBy = key.CreatedBy
+	fk.CreatedAt = key.CreatedAt.Unix()
+	if !key.ExpireAt.IsZero() {
+		fk.ExpireAt = key.ExpireAt.Unix()
+	}
+	if !key.LastUsedAt.IsZero() {
+		fk.LastUsedAt = key.LastUsedAt.Unix()
+	}
+	fk.LastUsedAddress = key.LastUsedAddress
+	return fk
+}
+
+// FileApiKeyRepository keeps api keys in json file keyed by name
+type FileApiKeyRepository struct {
+	filePath string
+	keys     map[string]FileApiKey
+	mutex    sync.RWMutex
+}
+
+func (handler *FileApiKeyRepository) load() map[string]FileApiKey {
+	keys := make(map[string]FileApiKey)
+
+	b, err := os.ReadFile(handler.filePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warn("read file fail : %s", err.Error())
+		}
+		return keys
+	}
+
+	err = json.Unmarshal(b, &keys)
+	if err != nil {
+		log.Warn("json fail : %s", err.Error())
+		return make(map[string]FileApiKey)
+	}
+
+	for name, fk := range keys {
+		fk.syncedAt = fk.LastUsedAt
+		keys[name] = fk
+	}
+
+	log.Info("%d api keys loaded from %s", len(keys), handler.filePath)
+	return keys
+}
+
+// sync writes keys to file. caller should hold write lock
+func (handler *FileApiKeyRepository) sync() error {
+	b, err := json.MarshalIndent(handler.keys, "", "  ")
+	if err != nil {
+		return fmt.Errorf("fail to build api key data : %s", err.Error())
+	}
+
+	err = writeFileAtomic(handler.filePath, b, 0600)
+	if err != nil {
+		return fmt.Errorf("fail to write api key file : %s", err.Error())
+	}
+	return nil
+}
+
+func (handler *FileApiKeyRepository) Save(key domain.ApiKey) error {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	prev, exist := handler.keys[key.Name]
+	handler.keys[key.Name] = newFileApiKey(key)
+	err := handler.sync()
+	if err != nil {
+		if exist {
+			handler.keys[key.Name] = prev
+		} else {
+			delete(handler.keys, key.Name)
+		}
+		return err
+	}
+	return nil
+}
+
+func (handler *FileApiKeyRepository) FindByName(name string) (domain.ApiKey, bool) {
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+
+	fk, ok := handler.keys[name]
+	if !ok {
+		return domain.ApiKey{}, false
+	}
+	return fk.toApiKey(name), true
+}
+
+func (handler *FileApiKeyRepository) FindByDigest(digest string) (domain.ApiKey, bool) {
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+
+	for name, fk := range handler.keys {
+		if fk.Digest == digest {
+			return fk.toApiKey(name), true
+		}
+	}
+	return domain.ApiKey{}, false
+}
+
+func (handler *FileApiKeyRepository) FindAll() []domain.ApiKey {
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+
+	list := make([]domain.ApiKey, 0, len(handler.keys))
+	for name, fk := range handler.keys {
+		list = append(list, fk.toApiKey(name))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Delete removes key. key is restored when file sync fails, so revocation is never lost on restart
+func (handler *FileApiKeyRepository) Delete(name string) (bool, error) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	prev, ok := handler.keys[name]
+	if !ok {
+		return false, nil
+	}
+	delete(handler.keys, name)
+	if err := handler.sync(); err != nil {
+		handler.keys[name] = prev
+		return false, err
+	}
+	return true, nil
+}
+
+// Touch records last usage of key. file is written when address changes or interval is passed
+func (handler *FileApiKeyRepository) Touch(name string, usedAt time.Time, address string) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	fk, ok := handler.keys[name]
+	if !ok {
+		return
+	}
+
+	needSync := fk.LastUsedAddress != address ||
+		usedAt.Sub(time.Unix(fk.syncedAt, 0)) >= apiKeyTouchSyncInterval
+	fk.LastUsedAt = usedAt.Unix()
+	fk.LastUsedAddress = address
+	handler.keys[name] = fk
+
+	if !needSync {
+		return
+	}
+	if err := handler.sync(); err != nil {
+		log.Warn("%s", err.Error())
+		return
+	}
+	fk.syncedAt = fk.LastUsedAt
+	handler.keys[name] = fk
+}
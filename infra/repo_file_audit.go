@@ -0,0 +1,214 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 19. 오후 5:40
+ */
+package infra
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/fatima-go/fatima-core"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	AUDIT_FOLDER      = "audit"
+	auditFilePrefix   = "audit-"
+	auditFileSuffix   = ".jsonl"
+	auditFileDate     = "20060102"
+	auditDefaultLimit = 100
+	auditMaxLimit     = 1000
+)
+
+// NewFileAuditRepository creates audit repository writing JSON lines to audit folder of data folder.
+// file is rotated daily(UTC) as audit-YYYYMMDD.jsonl and files older than retentionDays are removed.
+// retentionDays 0 keeps every file
+func NewFileAuditRepository(fatimaRuntime fatima.FatimaRuntime, retentionDays int) (domain.AuditRepository, error) {
+	repo := new(FileAuditRepository)
+	repo.folder = filepath.Join(fatimaRuntime.GetEnv().GetFolderGuide().GetDataFolder(), AUDIT_FOLDER)
+	repo.retentionDays = retentionDays
+
+	err := os.MkdirAll(repo.folder, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create audit folder : %s", err.Error())
+	}
+
+	repo.purge(time.Now().UTC())
+	return repo, nil
+}
+
+type FileAuditRepository struct {
+	folder        string
+	retentionDays int
+	file          *os.File
+	fileDate      string
+	mutex         sync.Mutex
+}
+
+func (handler *FileAuditRepository) Append(event domain.AuditEvent) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	err = handler.rotate(event.Time.UTC())
+	if err != nil {
+		return err
+	}
+
+	_, err = handler.file.Write(append(b, '\n'))
+	return err
+}
+
+// rotate opens file of given date. caller should hold lock
+func (handler *FileAuditRepository) rotate(now time.Time) error {
+	date := now.Format(auditFileDate)
+	if handler.file != nil && handler.fileDate == date {
+		return nil
+	}
+
+	if handler.file != nil {
+		handler.file.Close()
+		handler.file = nil
+		handler.purge(now)
+	}
+
+	path := filepath.Join(handler.folder, auditFilePrefix+date+auditFileSuffix)
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("fail to open audit file : %s", err.Error())
+	}
+
+	handler.file = file
+	handler.fileDate = date
+	return nil
+}
+
+// purge removes files older than retention days
+func (handler *FileAuditRepository) purge(now time.Time) {
+	if handler.retentionDays <= 0 {
+		return
+	}
+
+	limit := now.AddDate(0, 0, -handler.retentionDays).Format(auditFileDate)
+	for _, date := range handler.dates() {
+		if date < limit {
+			path := filepath.Join(handler.folder, auditFilePrefix+date+auditFileSuffix)
+			if err := os.Remove(path); err != nil {
+				log.Warn("fail to remove audit file %s : %s", path, err.Error())
+				continue
+			}
+			log.Info("audit file %s removed", path)
+		}
+	}
+}
+
+// dates returns dates of audit files in ascending order
+func (handler *FileAuditRepository) dates() []string {
+	entries, err := os.ReadDir(handler.folder)
+	if err != nil {
+		log.Warn("fail to read audit folder : %s", err.Error())
+		return nil
+	}
+
+	list := make([]string, 0, len(entries))
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, auditFilePrefix) || !strings.HasSuffix(name, auditFileSuffix) {
+			continue
+		}
+		list = append(list, strings.TrimSuffix(strings.TrimPrefix(name, auditFilePrefix), auditFileSuffix))
+	}
+	sort.Strings(list)
+	return list
+}
+
+func (handler *FileAuditRepository) Find(filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = auditDefaultLimit
+	}
+	if limit > auditMaxLimit {
+		limit = auditMaxLimit
+	}
+
+	from, to := "", "99999999"
+	if !filter.From.IsZero() {
+		from = filter.From.UTC().Format(auditFileDate)
+	}
+	if !filter.To.IsZero() {
+		to = filter.To.UTC().Format(auditFileDate)
+	}
+
+	result := make([]domain.AuditEvent, 0)
+	dates := handler.dates()
+	for i := len(dates) - 1; i >= 0 && len(result) < limit; i-- {
+		if dates[i] < from || dates[i] > to {
+			continue
+		}
+
+		events, err := handler.read(dates[i], filter)
+		if err != nil {
+			return nil, err
+		}
+		for j := len(events) - 1; j >= 0 && len(result) < limit; j-- {
+			result = append(result, events[j])
+		}
+	}
+	return result, nil
+}
+
+// read returns matched events of date file in written order
+func (handler *FileAuditRepository) read(date string, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	file, err := os.Open(filepath.Join(handler.folder, auditFilePrefix+date+auditFileSuffix))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	list := make([]domain.AuditEvent, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var event domain.AuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue // partially written line
+		}
+		if filter.Matches(event) {
+			list = append(list, event)
+		}
+	}
+	return list, scanner.Err()
+}
@@ -0,0 +1,113 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 7:45
+ */
+package infra
+
+import (
+	"github.com/fatima-go/jupiter/domain"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestFileAuditRepository(t *testing.T, retentionDays int) *FileAuditRepository {
+	repo := &FileAuditRepository{folder: t.TempDir(), retentionDays: retentionDays}
+	t.Cleanup(func() {
+		if repo.file != nil {
+			repo.file.Close()
+		}
+	})
+	return repo
+}
+
+func TestFileAuditRepositoryFind(t *testing.T) {
+	repo := newTestFileAuditRepository(t, 0)
+	day1 := time.Date(2026, 10, 18, 23, 0, 0, 0, time.UTC)
+	day2 := day1.Add(time.Hour * 2)
+	events := []domain.AuditEvent{
+		{Time: day1, UserId: "alice", Action: "login", Result: domain.AUDIT_RESULT_SUCCESS},
+		{Time: day1.Add(time.Minute), UserId: "bob", Action: "login", Result: domain.AUDIT_RESULT_FAIL},
+		{Time: day2, UserId: "alice", Action: "deploy", Result: domain.AUDIT_RESULT_SUCCESS},
+		{Time: day2.Add(time.Minute), UserId: "alice", Action: "logout", Result: domain.AUDIT_RESULT_SUCCESS},
+	}
+	for _, e := range events {
+		if err := repo.Append(e); err != nil {
+			t.Fatalf("fail to append audit : %s", err.Error())
+		}
+	}
+
+	// partially written line of crash is skipped
+	file, _ := os.OpenFile(filepath.Join(repo.folder, "audit-20261018.jsonl"), os.O_APPEND|os.O_WRONLY, 0600)
+	file.WriteString(`{"time":"2026-10-18T23:59:00Z","user_`)
+	file.Close()
+
+	cases := []struct {
+		name    string
+		filter  domain.AuditFilter
+		actions []string
+	}{
+		{"all newest first", domain.AuditFilter{}, []string{"logout", "deploy", "login", "login"}},
+		{"user", domain.AuditFilter{UserId: "bob"}, []string{"login"}},
+		{"action", domain.AuditFilter{Action: "login", UserId: "alice"}, []string{"login"}},
+		{"limit", domain.AuditFilter{Limit: 3}, []string{"logout", "deploy", "login"}},
+		{"from", domain.AuditFilter{From: day2}, []string{"logout", "deploy"}},
+		{"to", domain.AuditFilter{To: day1.Add(time.Second)}, []string{"login"}},
+		{"no match", domain.AuditFilter{UserId: "carol"}, []string{}},
+	}
+
+	for _, c := range cases {
+		found, err := repo.Find(c.filter)
+		if err != nil {
+			t.Fatalf("%s : %s", c.name, err.Error())
+		}
+		if len(found) != len(c.actions) {
+			t.Fatalf("%s : %d events, expected %d", c.name, len(found), len(c.actions))
+		}
+		for i, e := range found {
+			if e.Action != c.actions[i] {
+				t.Fatalf("%s : event %d is %s, expected %s", c.name, i, e.Action, c.actions[i])
+			}
+		}
+	}
+}
+
+func TestFileAuditRepositoryRetention(t *testing.T) {
+	repo := newTestFileAuditRepository(t, 7)
+	now := time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)
+
+	days := []int{-10, -8, -7, -1, 0}
+	for _, d := range days {
+		repo.Append(domain.AuditEvent{Time: now.AddDate(0, 0, d), UserId: "alice", Action: "login"})
+	}
+
+	dates := repo.dates()
+	expected := []string{"20261013", "20261019", "20261020"}
+	if len(dates) != len(expected) {
+		t.Fatalf("audit files %v, expected %v", dates, expected)
+	}
+	for i := range dates {
+		if dates[i] != expected[i] {
+			t.Fatalf("audit files %v, expected %v", dates, expected)
+		}
+	}
+}
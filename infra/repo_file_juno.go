@@ -29,232 +29,178 @@ import (
 	"github.com/fatima-go/fatima-core"
 	"github.com/fatima-go/fatima-log"
 	"github.com/fatima-go/jupiter/domain"
-	"io/ioutil"
 	"os"
 	"path/filepath"
-	"strings"
+	"time"
 )
 
 const (
 	JUNO_DEPLOY_DATA_FILE = "juno.json"
+	// health history is kept next to juno data file
+	JUNO_HEALTH_DATA_FILE = "juno_health.json"
 )
 
-func NewFileJunoRepository(fatimaRuntime fatima.FatimaRuntime) domain.JunoRepository {
-	repo := new(FileJunoRepository)
-
-	repo.junoFilePath = filepath.Join(
+func NewFileJunoRepository(fatimaRuntime fatima.FatimaRuntime) (domain.JunoRepository, error) {
+	return NewFileJunoRepositoryWithPath(filepath.Join(
 		fatimaRuntime.GetEnv().GetFolderGuide().GetDataFolder(),
-		JUNO_DEPLOY_DATA_FILE)
+		JUNO_DEPLOY_DATA_FILE))
+}
 
-	summary, ok := repo.load()
-	if !ok {
-		summary = &domain.JunoSummary{GroupCount: 0, HostCount: 0, PackageCount: 0}
-		summary.Groups = make([]domain.JunoGroup, 0)
-		data, err := json.Marshal(summary)
-		if err != nil {
-			panic(fmt.Sprintf("fail to build default juno summary : %s", err.Error()))
-		}
-		ioutil.WriteFile(repo.junoFilePath, data, 0644)
-		log.Info("created default juno data file")
+// NewFileJunoRepositoryWithPath creates file juno repository on junoFilePath.
+// file is loaded once and every change is written to temp file and renamed with fsync.
+// missing file is created. broken file is moved aside and unreadable file fails creation, so registry is never overwritten
+func NewFileJunoRepositoryWithPath(junoFilePath string) (*FileJunoRepository, error) {
+	repo := new(FileJunoRepository)
+	repo.junoFilePath = junoFilePath
+	repo.healthFilePath = filepath.Join(filepath.Dir(junoFilePath), JUNO_HEALTH_DATA_FILE)
+	repo.health = repo.loadHealth()
+
+	summary, err := repo.load()
+	if err != nil {
+		return nil, err
+	}
+	if summary != nil {
+		repo.summary = summary
+		return repo, nil
 	}
 
-	repo.summary = summary
-	return repo
+	repo.summary = newEmptyJunoSummary()
+	if err = repo.sync(); err != nil {
+		return nil, fmt.Errorf("fail to create default juno data file : %s", err.Error())
+	}
+	log.Info("created default juno data file")
+	return repo, nil
 }
 
+// FileJunoRepository is InMemoryJunoRepository which stores summary to json file on every change
 type FileJunoRepository struct {
-	junoFilePath string
-	summary      *domain.JunoSummary
+	InMemoryJunoRepository
+	junoFilePath   string
+	healthFilePath string
 }
 
-func (handler *FileJunoRepository) load() (*domain.JunoSummary, bool) {
-	data, err := ioutil.ReadFile(handler.junoFilePath)
+// load returns nil summary when file does not exist or broken file is moved aside
+func (handler *FileJunoRepository) load() (*domain.JunoSummary, error) {
+	data, err := os.ReadFile(handler.junoFilePath)
 	if err != nil {
-		log.Warn("read file fail : %s", err.Error())
-		return nil, false
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fail to read %s : %s", handler.junoFilePath, err.Error())
 	}
 
 	var summary domain.JunoSummary
 	err = json.Unmarshal(data, &summary)
 	if err != nil {
-		log.Warn("json fail : %s", err.Error())
-		return nil, false
+		broken := fmt.Sprintf("%s.broken.%s", handler.junoFilePath, time.Now().Format("20060102150405"))
+		if renameErr := os.Rename(handler.junoFilePath, broken); renameErr != nil {
+			return nil, fmt.Errorf("broken %s (%s) cannot be moved aside : %s", handler.junoFilePath, err.Error(), renameErr.Error())
+		}
+		log.Error("broken %s is moved to %s : %s", handler.junoFilePath, broken, err.Error())
+		return nil, nil
+	}
+	if summary.Groups == nil {
+		summary.Groups = make([]domain.JunoGroup, 0)
 	}
 
-	return &summary, true
+	return &summary, nil
 }
 
-func (handler *FileJunoRepository) sync() {
+// sync writes summary to json file. caller holds lock
+func (handler *FileJunoRepository) sync() error {
 	if handler.summary == nil {
-		return
+		return nil
 	}
 
 	data, err := json.Marshal(handler.summary)
 	if err != nil {
-		panic(fmt.Sprintf("fail to build default juno summary : %s", err.Error()))
+		return fmt.Errorf("fail to build juno summary : %s", err.Error())
+	}
+
+	err = writeFileAtomic(handler.junoFilePath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("fail to write %s : %s", handler.junoFilePath, err.Error())
 	}
-	os.WriteFile(handler.junoFilePath, data, 0644)
 	log.Debug("juno summary sync to json file")
+	return nil
 }
 
-func (handler *FileJunoRepository) FindAll() *domain.JunoSummary {
-	summary, ok := handler.load()
-	if !ok {
-		return handler.summary
+// syncOrRollback writes summary and restores previous summary when file is not written,
+// so memory never holds change which is lost on restart. caller holds lock
+func (handler *FileJunoRepository) syncOrRollback(prev *domain.JunoSummary) {
+	if err := handler.sync(); err != nil {
+		log.Error("%s. change is rolled back", err.Error())
+		handler.summary = prev
 	}
-	handler.summary = summary
-	return handler.summary
 }
 
-func (handler *FileJunoRepository) FindByPoint(point domain.PackagePoint) *domain.JunoPackage {
-	summary := handler.summary
+func (handler *FileJunoRepository) Save(data domain.JunoRegistration) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
 
-	if summary == nil {
-		return nil
+	prev := handler.summary.Copy()
+	if handler.save(data) {
+		handler.syncOrRollback(prev)
 	}
+}
 
-	if summary.PackageCount == 0 {
-		return nil
-	}
+func (handler *FileJunoRepository) Delete(endpoint string) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
 
-	if len(point.Host) < 1 {
-		if summary.PackageCount == 1 {
-			if len(summary.Groups) == 0 || len(summary.Groups[0].Packages) == 0 {
-				log.Warn("invalid juno summary. JunoPackageCount is 1 but there are no juno data")
-				return nil
-			}
-			return &summary.Groups[0].Packages[0]
-		}
-		return nil
+	prev := handler.summary.Copy()
+	if handler.delete(endpoint) {
+		handler.syncOrRollback(prev)
 	}
-
-	return summary.FindByPoint(point)
 }
 
-func (handler *FileJunoRepository) FindByAddress(address string) *domain.JunoPackage {
-	summary := handler.summary
+func (handler *FileJunoRepository) Update(update func(summary *domain.JunoSummary) bool) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
 
-	if summary == nil {
-		return nil
+	if handler.summary == nil {
+		return
 	}
 
-	if summary.PackageCount == 0 {
-		return nil
-	} else if summary.PackageCount == 1 {
-		return &summary.Groups[0].Packages[0]
+	prev := handler.summary.Copy()
+	if update(handler.summary) {
+		handler.syncOrRollback(prev)
 	}
-
-	ip := ExtractIpAddress(address)
-	for _, g := range summary.Groups {
-		for _, p := range g.Packages {
-			if strings.HasPrefix(p.Endpoint, "http://") {
-				comp := p.Endpoint[7:] // e.g comp => 10.180.37.134:9180/QYNMdOrq/
-				delim := strings.Index(comp, ":")
-				if delim < 0 {
-					if strings.HasPrefix(comp, ip) {
-						return &p
-					}
-				} else {
-					if comp[:delim] == ip {
-						return &p
-					}
-				}
-			} else if strings.HasPrefix(p.Endpoint, ip) {
-				return &p
-			}
-
-		}
-	}
-
-	return nil
 }
 
-func (handler *FileJunoRepository) FindGroup(groupName string) *domain.JunoGroup {
-	summary := handler.summary
+// loadHealth reads health history. missing or broken history is started again, it is not registry
+func (handler *FileJunoRepository) loadHealth() map[string][]domain.HealthResult {
+	histories := make(map[string][]domain.HealthResult)
 
-	comp := strings.ToLower(groupName)
-	for i := 0; i < len(summary.Groups); i++ {
-		if comp == strings.ToLower(summary.Groups[i].Name) {
-			return &summary.Groups[i]
+	data, err := os.ReadFile(handler.healthFilePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warn("read file fail : %s", err.Error())
 		}
+		return histories
 	}
 
-	return nil
-}
-
-func (handler *FileJunoRepository) FindByEndpoint(endpoint string) *domain.JunoPackage {
-	summary := handler.summary
-
-	if summary == nil || len(endpoint) < 1 {
-		return nil
-	}
-
-	if summary.PackageCount == 0 {
-		return nil
-	}
-
-	for i := 0; i < len(summary.Groups); i++ {
-		for j := 0; j < len(summary.Groups[i].Packages); j++ {
-			if summary.Groups[i].Packages[j].Endpoint == endpoint {
-				return &summary.Groups[i].Packages[j]
-			}
-		}
+	err = json.Unmarshal(data, &histories)
+	if err != nil {
+		log.Warn("ignore broken health history %s : %s", handler.healthFilePath, err.Error())
+		return make(map[string][]domain.HealthResult)
 	}
-
-	return nil
+	return histories
 }
 
-func (handler *FileJunoRepository) Save(data domain.JunoRegistration) {
-	summary := handler.summary
-	compGroup := strings.ToLower(data.Group)
-	for i := 0; i < len(summary.Groups); i++ {
-		if strings.ToLower(summary.Groups[i].Name) == compGroup {
-			summary.Groups[i].Append(data.AsJunoPackage())
-			summary.PackageCount = summary.PackageCount + 1
-			summary.UpdateHostCount()
-			handler.sync()
-			return
-		}
-	}
+func (handler *FileJunoRepository) SaveHealthHistory(histories map[string][]domain.HealthResult) error {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
 
-	group := domain.JunoGroup{Name: data.Group}
-	group.Packages = make([]domain.JunoPackage, 1)
-	group.Packages[0] = data.AsJunoPackage()
-	summary.Groups = append(summary.Groups, group)
-	summary.GroupCount = summary.GroupCount + 1
-	summary.PackageCount = summary.PackageCount + 1
-	summary.UpdateHostCount()
-	handler.sync()
-	return
-}
-
-func (handler *FileJunoRepository) Delete(endpoint string) {
-	if handler.summary == nil {
-		return
+	data, err := json.Marshal(histories)
+	if err != nil {
+		return fmt.Errorf("fail to build health history : %s", err.Error())
 	}
 
-	found := -1
-	for i := 0; i < len(handler.summary.Groups); i++ {
-		for i, p := range handler.summary.Groups[i].Packages {
-			if p.Endpoint == endpoint {
-				found = i
-				break
-			}
-		}
-		if found >= 0 {
-			handler.summary.Groups[i].Delete(found)
-			if len(handler.summary.Groups[i].Packages) == 0 {
-				handler.summary.DeleteGroup(i)
-				handler.summary.GroupCount = len(handler.summary.Groups)
-			}
-			handler.summary.PackageCount = handler.summary.PackageCount - 1
-			handler.summary.UpdateHostCount()
-			handler.sync()
-			break
-		}
+	err = writeFileAtomic(handler.healthFilePath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("fail to write %s : %s", handler.healthFilePath, err.Error())
 	}
-}
-
-func (handler *FileJunoRepository) SaveAll() {
-	handler.sync()
+	handler.health = copyHealthHistory(histories)
+	return nil
 }
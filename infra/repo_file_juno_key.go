@@ -0,0 +1,135 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 2:24
+ */
+
+package infra
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/fatima-go/fatima-core"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	JUNO_KEY_DATA_FILE = "juno_key.json"
+)
+
+func NewFileJunoKeyRepository(fatimaRuntime fatima.FatimaRuntime) domain.JunoKeyRepository {
+	repo := new(FileJunoKeyRepository)
+	repo.keyFilePath = filepath.Join(
+		fatimaRuntime.GetEnv().GetFolderGuide().GetDataFolder(),
+		JUNO_KEY_DATA_FILE)
+	repo.load()
+	return repo
+}
+
+// FileJunoKeyRepository keeps juno keys in json file.
+// operator can edit the file directly, changes are applied on next access without restart
+type FileJunoKeyRepository struct {
+	keyFilePath string
+	data        domain.JunoKeyData
+	modTime     time.Time
+	mutex       sync.RWMutex
+}
+
+func (handler *FileJunoKeyRepository) load() {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	stat, err := os.Stat(handler.keyFilePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warn("fail to stat juno key file : %s", err.Error())
+		}
+		return
+	}
+
+	if stat.ModTime().Equal(handler.modTime) {
+		return
+	}
+
+	b, err := os.ReadFile(handler.keyFilePath)
+	if err != nil {
+		log.Warn("read file fail : %s", err.Error())
+		return
+	}
+
+	var data domain.JunoKeyData
+	err = json.Unmarshal(b, &data)
+	if err != nil {
+		log.Warn("json fail : %s", err.Error())
+		return
+	}
+
+	hosts := make(map[string]string)
+	for k, v := range data.Hosts {
+		hosts[strings.ToLower(k)] = v
+	}
+	data.Hosts = hosts
+
+	handler.data = data
+	handler.modTime = stat.ModTime()
+	log.Debug("juno key file loaded")
+}
+
+func (handler *FileJunoKeyRepository) Find() domain.JunoKeyData {
+	handler.load()
+
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+
+	data := handler.data
+	data.Hosts = make(map[string]string)
+	for k, v := range handler.data.Hosts {
+		data.Hosts[k] = v
+	}
+	return data
+}
+
+func (handler *FileJunoKeyRepository) Save(data domain.JunoKeyData) error {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	b, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("fail to build juno key data : %s", err.Error())
+	}
+
+	err = writeFileAtomic(handler.keyFilePath, b, 0600)
+	if err != nil {
+		return fmt.Errorf("fail to write juno key file : %s", err.Error())
+	}
+
+	handler.data = data
+	if stat, err := os.Stat(handler.keyFilePath); err == nil {
+		handler.modTime = stat.ModTime()
+	}
+	log.Debug("juno key data sync to json file")
+	return nil
+}
@@ -0,0 +1,134 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오전 11:20
+ */
+
+package infra
+
+import (
+	"github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/infra/repotest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestFileJunoRepository(t *testing.T, junoFilePath string) *FileJunoRepository {
+	repo, err := NewFileJunoRepositoryWithPath(junoFilePath)
+	if err != nil {
+		t.Fatalf("fail to create file juno repository : %s", err.Error())
+	}
+	return repo
+}
+
+func newTestJunoRegistration(endpoint string) domain.JunoRegistration {
+	registration := domain.JunoRegistration{Group: "payment"}
+	registration.Endpoint = endpoint
+	registration.Host = "host-1"
+	registration.Name = "default"
+	registration.Status = domain.JUNO_STATUS_ALIVE
+	registration.RegistDate = time.Now().Unix()
+	return registration
+}
+
+// TestFileJunoRepositoryHammer should be run with -race
+func TestFileJunoRepositoryHammer(t *testing.T) {
+	junoFilePath := filepath.Join(t.TempDir(), JUNO_DEPLOY_DATA_FILE)
+	repo := newTestFileJunoRepository(t, junoFilePath)
+
+	workers, rounds := 8, 20
+	if err := repotest.Hammer(repo, workers, rounds); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := newTestFileJunoRepository(t, junoFilePath)
+	if err := repotest.CheckCounts(reloaded.FindAll(), workers*(rounds/2)); err != nil {
+		t.Fatalf("reloaded file : %s", err.Error())
+	}
+}
+
+func TestFileJunoRepositoryBrokenFile(t *testing.T) {
+	dir := t.TempDir()
+	junoFilePath := filepath.Join(dir, JUNO_DEPLOY_DATA_FILE)
+	if err := os.WriteFile(junoFilePath, []byte("{broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := newTestFileJunoRepository(t, junoFilePath)
+	if err := repotest.CheckCounts(repo.FindAll(), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	moved, _ := filepath.Glob(junoFilePath + ".broken.*")
+	if len(moved) != 1 {
+		t.Fatalf("broken file is not moved aside : %v", moved)
+	}
+	if b, _ := os.ReadFile(moved[0]); string(b) != "{broken" {
+		t.Fatalf("content of broken file is changed : %s", b)
+	}
+}
+
+func TestFileJunoRepositoryUnreadableFile(t *testing.T) {
+	junoFilePath := filepath.Join(t.TempDir(), JUNO_DEPLOY_DATA_FILE)
+	if err := os.Mkdir(junoFilePath, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewFileJunoRepositoryWithPath(junoFilePath); err == nil {
+		t.Fatalf("unreadable juno data file is replaced")
+	}
+}
+
+func TestFileJunoRepositoryRollback(t *testing.T) {
+	junoFilePath := filepath.Join(t.TempDir(), JUNO_DEPLOY_DATA_FILE)
+	repo := newTestFileJunoRepository(t, junoFilePath)
+	repo.Save(newTestJunoRegistration("http://10.0.0.1:9180/a/"))
+
+	// directory on data file path makes every sync fail
+	if err := os.Remove(junoFilePath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(junoFilePath, "block"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	repo.Save(newTestJunoRegistration("http://10.0.0.2:9180/b/"))
+	if repo.FindByEndpoint("http://10.0.0.2:9180/b/") != nil {
+		t.Fatalf("save is kept although sync failed")
+	}
+
+	repo.Delete("http://10.0.0.1:9180/a/")
+	if repo.FindByEndpoint("http://10.0.0.1:9180/a/") == nil {
+		t.Fatalf("delete is kept although sync failed")
+	}
+
+	repo.Update(func(summary *domain.JunoSummary) bool {
+		summary.FindByEndpoint("http://10.0.0.1:9180/a/").Status = domain.JUNO_STATUS_DEAD
+		return true
+	})
+	if repo.FindByEndpoint("http://10.0.0.1:9180/a/").Status != domain.JUNO_STATUS_ALIVE {
+		t.Fatalf("update is kept although sync failed")
+	}
+	if err := repotest.CheckCounts(repo.FindAll(), 1); err != nil {
+		t.Fatal(err)
+	}
+}
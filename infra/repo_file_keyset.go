@@ -0,0 +1,224 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 7:12
+ */
+
+package infra
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/fatima-go/fatima-core"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	TOKEN_KEYSET_FILE = "token_keyset.json"
+	signingKeyLength  = 32
+
+	// keyset file is checked for modification at most once in this interval
+	keysetCheckInterval = time.Second
+)
+
+/*
+	{
+		"active": "k1",
+		"keys": [
+			{"kid": "k1", "secret": "base64 encoded secret"},
+			{"kid": "k0", "secret": "..."}
+		]
+	}
+*/
+type KeySetData struct {
+	Active string          `json:"active"`
+	Keys   []KeySetElement `json:"keys"`
+}
+
+type KeySetElement struct {
+	Id     string `json:"kid"`
+	Secret string `json:"secret"`
+}
+
+// NewFileSigningKeyRepository loads keyset file in data folder.
+// default keyset is created when there is no file. file is reloaded when it is modified,
+// so key rotation is done by adding new key and changing active kid without restart
+func NewFileSigningKeyRepository(fatimaRuntime fatima.FatimaRuntime) (domain.SigningKeyRepository, error) {
+	return NewFileSigningKeyRepositoryWithPath(filepath.Join(
+		fatimaRuntime.GetEnv().GetFolderGuide().GetDataFolder(),
+		TOKEN_KEYSET_FILE))
+}
+
+// NewFileSigningKeyRepositoryWithPath creates file signing key repository on keysetFilePath
+func NewFileSigningKeyRepositoryWithPath(keysetFilePath string) (*FileSigningKeyRepository, error) {
+	repo := new(FileSigningKeyRepository)
+	repo.filePath = keysetFilePath
+
+	if _, err := os.Stat(repo.filePath); os.IsNotExist(err) {
+		err = createDefaultKeySet(repo.filePath)
+		if err != nil {
+			return nil, err
+		}
+		log.Info("created default token keyset file")
+	}
+
+	repo.checkedAt = time.Now()
+	err := repo.load()
+	if err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+func createDefaultKeySet(filePath string) error {
+	secret := make([]byte, signingKeyLength)
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("fail to create signing key : %s", err.Error())
+	}
+
+	kid := time.Now().Format("20060102150405")
+	data := KeySetData{Active: kid}
+	data.Keys = []KeySetElement{{Id: kid, Secret: base64.StdEncoding.EncodeToString(secret)}}
+	b, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("fail to build keyset : %s", err.Error())
+	}
+	return writeFileAtomic(filePath, b, 0600)
+}
+
+type FileSigningKeyRepository struct {
+	filePath  string
+	active    string
+	keys      map[string]domain.SigningKey
+	modTime   time.Time
+	checkedAt time.Time
+	// failed is true when keyset file of failedModTime (zero for missing file) is not loadable
+	failed        bool
+	failedModTime time.Time
+	mutex         sync.RWMutex
+}
+
+// load reads keyset file when it is modified since last load or failure.
+// error is returned once for each modification of invalid file
+func (handler *FileSigningKeyRepository) load() error {
+	var modTime time.Time
+	stat, err := os.Stat(handler.filePath)
+	if err == nil {
+		modTime = stat.ModTime()
+	}
+
+	handler.mutex.RLock()
+	same := (err == nil && modTime.Equal(handler.modTime)) || (handler.failed && modTime.Equal(handler.failedModTime))
+	handler.mutex.RUnlock()
+	if same {
+		return nil
+	}
+
+	if err != nil {
+		err = fmt.Errorf("fail to stat keyset file : %s", err.Error())
+	} else {
+		err = handler.read(modTime)
+	}
+	if err != nil {
+		handler.mutex.Lock()
+		handler.failed = true
+		handler.failedModTime = modTime
+		handler.mutex.Unlock()
+	}
+	return err
+}
+
+func (handler *FileSigningKeyRepository) read(modTime time.Time) error {
+	b, err := os.ReadFile(handler.filePath)
+	if err != nil {
+		return fmt.Errorf("fail to read keyset file : %s", err.Error())
+	}
+
+	var data KeySetData
+	err = json.Unmarshal(b, &data)
+	if err != nil {
+		return fmt.Errorf("invalid keyset file : %s", err.Error())
+	}
+
+	keys := make(map[string]domain.SigningKey)
+	for _, k := range data.Keys {
+		secret, err := base64.StdEncoding.DecodeString(k.Secret)
+		if err != nil || len(secret) < signingKeyLength {
+			return fmt.Errorf("invalid secret of key %s", k.Id)
+		}
+		keys[k.Id] = domain.SigningKey{Id: k.Id, Secret: secret}
+	}
+
+	if _, ok := keys[data.Active]; !ok {
+		return fmt.Errorf("not found active key %s in keyset", data.Active)
+	}
+
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+	handler.active = data.Active
+	handler.keys = keys
+	handler.modTime = modTime
+	handler.failed = false
+	log.Info("token keyset loaded. active=%s, keys=%d", data.Active, len(keys))
+	return nil
+}
+
+// reload checks keyset file at most once in keysetCheckInterval
+func (handler *FileSigningKeyRepository) reload() {
+	now := time.Now()
+	handler.mutex.Lock()
+	due := now.Sub(handler.checkedAt) >= keysetCheckInterval
+	if due {
+		handler.checkedAt = now
+	}
+	handler.mutex.Unlock()
+	if !due {
+		return
+	}
+
+	if err := handler.load(); err != nil {
+		log.Warn("keep previous keyset : %s", err.Error())
+	}
+}
+
+func (handler *FileSigningKeyRepository) FindActive() (domain.SigningKey, bool) {
+	handler.reload()
+
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+	key, ok := handler.keys[handler.active]
+	return key, ok
+}
+
+func (handler *FileSigningKeyRepository) FindById(id string) (domain.SigningKey, bool) {
+	handler.reload()
+
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+	key, ok := handler.keys[id]
+	return key, ok
+}
@@ -0,0 +1,101 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 3:25
+ */
+package infra
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTestKeySet(t *testing.T, keysetFilePath string, content string, modTime time.Time) {
+	if err := os.WriteFile(keysetFilePath, []byte(content), 0600); err != nil {
+		t.Fatalf("fail to write keyset : %s", err.Error())
+	}
+	if err := os.Chtimes(keysetFilePath, modTime, modTime); err != nil {
+		t.Fatalf("fail to change keyset time : %s", err.Error())
+	}
+}
+
+func buildTestKeySet(active string) string {
+	secret := base64.StdEncoding.EncodeToString([]byte(strings.Repeat(active, signingKeyLength)[:signingKeyLength]))
+	b, _ := json.Marshal(KeySetData{Active: active, Keys: []KeySetElement{{Id: active, Secret: secret}}})
+	return string(b)
+}
+
+func TestFileSigningKeyRepositoryReload(t *testing.T) {
+	keysetFilePath := filepath.Join(t.TempDir(), TOKEN_KEYSET_FILE)
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	writeTestKeySet(t, keysetFilePath, buildTestKeySet("k1"), base)
+
+	repo, err := NewFileSigningKeyRepositoryWithPath(keysetFilePath)
+	if err != nil {
+		t.Fatalf("fail to load keyset : %s", err.Error())
+	}
+
+	cases := []struct {
+		name    string
+		content string
+		modTime time.Time
+		elapsed bool
+		active  string
+		failed  bool
+	}{
+		{"rotated within check interval", buildTestKeySet("k2"), base.Add(time.Second), false, "k1", false},
+		{"rotated after check interval", buildTestKeySet("k2"), base.Add(time.Second), true, "k2", false},
+		{"broken file keeps keyset", "{", base.Add(2 * time.Second), true, "k2", true},
+		{"broken file is not read again", "{", base.Add(2 * time.Second), true, "k2", true},
+		{"fixed file is loaded", buildTestKeySet("k3"), base.Add(3 * time.Second), true, "k3", false},
+	}
+
+	for _, c := range cases {
+		writeTestKeySet(t, keysetFilePath, c.content, c.modTime)
+		if c.elapsed {
+			repo.checkedAt = time.Now().Add(-keysetCheckInterval)
+		}
+
+		key, ok := repo.FindActive()
+		if !ok || key.Id != c.active {
+			t.Fatalf("%s : active key %s", c.name, key.Id)
+		}
+		if repo.failed != c.failed {
+			t.Fatalf("%s : failed %t", c.name, repo.failed)
+		}
+		if c.failed && !repo.failedModTime.Equal(c.modTime) {
+			t.Fatalf("%s : failed time %s", c.name, repo.failedModTime)
+		}
+	}
+
+	// failure is reported once until file changes
+	writeTestKeySet(t, keysetFilePath, "{", base.Add(4*time.Second))
+	if repo.load() == nil {
+		t.Fatalf("broken keyset is loaded")
+	}
+	if err = repo.load(); err != nil {
+		t.Fatalf("same broken keyset is reported again : %s", err.Error())
+	}
+}
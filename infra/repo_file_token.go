@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 5:20
+ */
+
+package infra
+
+import (
+	"github.com/fatima-go/fatima-core"
+	"github.com/fatima-go/jupiter/domain"
+	"path/filepath"
+	"time"
+)
+
+const (
+	TOKEN_DATA_FILE = "token.json"
+)
+
+func NewFileTokenRepository(fatimaRuntime fatima.FatimaRuntime) domain.TokenRepository {
+	return NewFileTokenRepositoryWithPath(filepath.Join(
+		fatimaRuntime.GetEnv().GetFolderGuide().GetDataFolder(),
+		TOKEN_DATA_FILE))
+}
+
+// NewFileTokenRepositoryWithPath creates file token repository on tokenFilePath
+func NewFileTokenRepositoryWithPath(tokenFilePath string) domain.TokenRepository {
+	repo := new(FileTokenRepository)
+	repo.keyStore = newFileKeyStore(tokenFilePath)
+	return repo
+}
+
+type FileTokenRepository struct {
+	keyStore KeyStore
+}
+
+func (handler *FileTokenRepository) Save(token string, session domain.Session, ttlSeconds time.Duration) {
+	handler.keyStore.Put(token, session, ttlSeconds)
+}
+
+func (handler *FileTokenRepository) FindById(token string) (domain.Session, bool) {
+	return handler.keyStore.Get(token)
+}
+
+func (handler *FileTokenRepository) FindAll() []domain.Session {
+	return handler.keyStore.List()
+}
+
+func (handler *FileTokenRepository) Delete(token string) {
+	handler.keyStore.Remove(digestToken(token))
+}
+
+func (handler *FileTokenRepository) DeleteSession(id string) bool {
+	return handler.keyStore.Remove(id)
+}
+
+func (handler *FileTokenRepository) Extend(token string, expireAt time.Time) bool {
+	return handler.keyStore.Extend(digestToken(token), expireAt)
+}
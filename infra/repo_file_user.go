@@ -32,6 +32,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sync"
 )
 
 const (
@@ -39,9 +40,27 @@ const (
 )
 
 type GatewayUser struct {
-	Id       string `xml:"id,attr"`
-	Password string `xml:"passwd,attr"`
-	Role     string `xml:"role,attr"`
+	Id       string           `xml:"id,attr"`
+	Password string           `xml:"passwd,attr"`
+	Role     string           `xml:"role,attr"`
+	Bindings []GatewayBinding `xml:"binding,omitempty"`
+	Totp     *GatewayTotp     `xml:"totp,omitempty"`
+}
+
+// GatewayBinding grants role on scope(group name, host:package or *)
+// e.g) <user id="alice" passwd="..." role="MONITOR"><binding scope="payment" role="OPERATOR"/></user>
+type GatewayBinding struct {
+	Scope string `xml:"scope,attr" json:"scope"`
+	Role  string `xml:"role,attr" json:"role"`
+}
+
+// GatewayTotp is TOTP enrollment of user. recovery keeps sha256 digest of unused recovery code
+// e.g) <totp secret="..." confirmed="true" last_step="..."><recovery>...</recovery></totp>
+type GatewayTotp struct {
+	Secret    string   `xml:"secret,attr" json:"secret"`
+	Confirmed bool     `xml:"confirmed,attr" json:"confirmed"`
+	LastStep  int64    `xml:"last_step,attr,omitempty" json:"last_step,omitempty"`
+	Recovery  []string `xml:"recovery,omitempty" json:"recovery,omitempty"`
 }
 
 type GatewayUserData struct {
@@ -50,15 +69,20 @@ type GatewayUserData struct {
 }
 
 func NewFileUserRepository(fatimaRuntime fatima.FatimaRuntime) domain.UserRepository {
+	return NewFileUserRepositoryWithPath(filepath.Join(fatimaRuntime.GetEnv().GetFolderGuide().GetDataFolder(), USER_DATA_FILE))
+}
+
+// NewFileUserRepositoryWithPath creates file user repository on userFilePath. missing file is created with default admin user
+func NewFileUserRepositoryWithPath(userFilePath string) domain.UserRepository {
 	repo := new(FileUserRepository)
 
-	repo.xmlUserData = loadXmlUserData(fatimaRuntime.GetEnv().GetFolderGuide())
+	repo.filePath = userFilePath
+	repo.xmlUserData = loadXmlUserData(repo.filePath)
 	repo.build()
 	return repo
 }
 
-func loadXmlUserData(folderGuide fatima.FolderGuide) GatewayUserData {
-	filePath := filepath.Join(folderGuide.GetDataFolder(), USER_DATA_FILE)
+func loadXmlUserData(filePath string) GatewayUserData {
 	log.Debug("using xml : %s", filePath)
 	data, err := ioutil.ReadFile(filePath)
 	var xmlUserData GatewayUserData
@@ -71,7 +95,7 @@ func loadXmlUserData(folderGuide fatima.FolderGuide) GatewayUserData {
 			if err != nil {
 				panic(fmt.Sprintf("fail to create default gateway user file : %s", err.Error()))
 			}
-			ioutil.WriteFile(filePath, data, 0644)
+			ioutil.WriteFile(filePath, data, 0600)
 			log.Info("created default gateway user xml file")
 		} else {
 			panic(fmt.Sprintf("fail to load gateway user file : %s", err.Error()))
@@ -87,8 +111,10 @@ func loadXmlUserData(folderGuide fatima.FolderGuide) GatewayUserData {
 }
 
 type FileUserRepository struct {
+	filePath    string
 	xmlUserData GatewayUserData
 	userDataMap map[string]GatewayUser
+	mutex       sync.RWMutex
 }
 
 func (handler *FileUserRepository) build() {
@@ -100,32 +126,135 @@ func (handler *FileUserRepository) build() {
 	handler.userDataMap = userDataMap
 }
 
-func (handler *FileUserRepository) Save(user domain.User) {
-	log.Warn("not yet implemented")
+// sync writes users to xml file. caller should hold write lock
+func (handler *FileUserRepository) sync(users []GatewayUser) error {
+	xmlUserData := GatewayUserData{Users: users}
+	data, err := xml.MarshalIndent(&xmlUserData, "", "  ")
+	if err != nil {
+		return fmt.Errorf("fail to build user data xml : %s", err.Error())
+	}
+
+	err = writeFileAtomic(handler.filePath, append([]byte(xml.Header), data...), 0600)
+	if err != nil {
+		return fmt.Errorf("fail to write user data xml file : %s", err.Error())
+	}
+
+	handler.xmlUserData = xmlUserData
+	handler.build()
+	log.Debug("user data sync to xml file")
+	return nil
+}
+
+func (handler *FileUserRepository) Save(user domain.User) error {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	saved := toGatewayUser(user)
+	users := make([]GatewayUser, 0, len(handler.xmlUserData.Users)+1)
+	found := false
+	for _, v := range handler.xmlUserData.Users {
+		if v.Id == user.Id {
+			users = append(users, saved)
+			found = true
+			continue
+		}
+		users = append(users, v)
+	}
+	if !found {
+		users = append(users, saved)
+	}
+
+	return handler.sync(users)
 }
 
 func (handler *FileUserRepository) FindById(id string) *domain.User {
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+
 	u, ok := handler.userDataMap[id]
 	if !ok {
 		return nil
 	}
 
-	user := domain.User{}
-	user.Id = u.Id
-	user.Password = u.Password
-	user.Role = domain.ToRole(u.Role)
+	user := toDomainUser(u)
 	return &user
 }
 
-func (handler *FileUserRepository) Delete(id string) {
-	log.Warn("not yet implemented")
+func (handler *FileUserRepository) FindAll() []domain.User {
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+
+	list := make([]domain.User, 0, len(handler.xmlUserData.Users))
+	for _, v := range handler.xmlUserData.Users {
+		list = append(list, toDomainUser(v))
+	}
+	return list
+}
+
+func (handler *FileUserRepository) Delete(id string) error {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	if _, ok := handler.userDataMap[id]; !ok {
+		return fmt.Errorf("not found user %s", id)
+	}
+
+	users := make([]GatewayUser, 0, len(handler.xmlUserData.Users))
+	for _, v := range handler.xmlUserData.Users {
+		if v.Id != id {
+			users = append(users, v)
+		}
+	}
+
+	return handler.sync(users)
 }
 
 func (handler *FileUserRepository) Exists(id string) bool {
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+
 	_, ok := handler.userDataMap[id]
 	return ok
 }
 
 func (handler *FileUserRepository) Count() int {
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+
 	return len(handler.userDataMap)
 }
+
+func toGatewayUser(user domain.User) GatewayUser {
+	u := GatewayUser{Id: user.Id, Password: user.Password, Role: user.Role.String()}
+	for _, b := range user.Bindings {
+		u.Bindings = append(u.Bindings, GatewayBinding{Scope: b.Scope, Role: b.Role.String()})
+	}
+	if len(user.Totp.Secret) > 0 {
+		u.Totp = &GatewayTotp{
+			Secret:    user.Totp.Secret,
+			Confirmed: user.Totp.Confirmed,
+			LastStep:  user.Totp.LastStep,
+			Recovery:  user.Totp.RecoveryCodes,
+		}
+	}
+	return u
+}
+
+func toDomainUser(u GatewayUser) domain.User {
+	user := domain.User{}
+	user.Id = u.Id
+	user.Password = u.Password
+	user.Role = domain.ToRole(u.Role)
+	for _, b := range u.Bindings {
+		user.Bindings = append(user.Bindings, domain.NewRoleBinding(b.Scope, b.Role))
+	}
+	if u.Totp != nil {
+		user.Totp = domain.Totp{
+			Secret:        u.Totp.Secret,
+			Confirmed:     u.Totp.Confirmed,
+			LastStep:      u.Totp.LastStep,
+			RecoveryCodes: u.Totp.Recovery,
+		}
+	}
+	return user
+}
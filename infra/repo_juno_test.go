@@ -0,0 +1,96 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 12:07
+ */
+
+package infra
+
+import (
+	"github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/infra/repotest"
+	"path/filepath"
+	"testing"
+)
+
+// TestJunoRepositoryConformance runs every repotest.JunoCases on each backend with fresh repository
+func TestJunoRepositoryConformance(t *testing.T) {
+	backends := []struct {
+		name    string
+		newRepo func(t *testing.T) domain.JunoRepository
+	}{
+		{"file", func(t *testing.T) domain.JunoRepository {
+			return newTestFileJunoRepository(t, filepath.Join(t.TempDir(), JUNO_DEPLOY_DATA_FILE))
+		}},
+		{"memory", func(t *testing.T) domain.JunoRepository { return NewMemoryDeployRepository() }},
+		{"sql", func(t *testing.T) domain.JunoRepository { return NewSqlJunoRepository(openTestSqlDatabase(t)) }},
+	}
+
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			repotest.RunJunoConformance(t, func() domain.JunoRepository { return b.newRepo(t) })
+		})
+	}
+}
+
+// TestJunoRepositoryReload checks that file and sql backends keep registrations and health history across reopen
+func TestJunoRepositoryReload(t *testing.T) {
+	dir := t.TempDir()
+	junoFilePath := filepath.Join(dir, JUNO_DEPLOY_DATA_FILE)
+	sqlFilePath := filepath.Join(dir, SQL_DATA_FILE)
+
+	backends := []struct {
+		name string
+		open func(t *testing.T) domain.JunoRepository
+	}{
+		{"file", func(t *testing.T) domain.JunoRepository { return newTestFileJunoRepository(t, junoFilePath) }},
+		{"sql", func(t *testing.T) domain.JunoRepository {
+			database, err := OpenSqlDatabase(sqlFilePath)
+			if err != nil {
+				t.Fatalf("fail to open database : %s", err.Error())
+			}
+			t.Cleanup(func() { database.Close() })
+			return NewSqlJunoRepository(database)
+		}},
+	}
+
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			endpoint := "http://10.0.0.1:9180/a/"
+			repo := b.open(t)
+			registration := newTestJunoRegistration(endpoint)
+			registration.Labels = map[string]string{"env": "prod"}
+			repo.Save(registration)
+			err := repo.SaveHealthHistory(map[string][]domain.HealthResult{endpoint: {{Error: "timeout"}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			reopened := b.open(t)
+			pack := reopened.FindByEndpoint(endpoint)
+			if pack == nil || pack.Labels["env"] != "prod" {
+				t.Fatalf("registration is not kept : %v", pack)
+			}
+			if results := reopened.FindHealthHistory()[endpoint]; len(results) != 1 || results[0].Error != "timeout" {
+				t.Fatalf("health history is not kept : %v", results)
+			}
+		})
+	}
+}
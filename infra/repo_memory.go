@@ -24,12 +24,20 @@
 package infra
 
 import (
+	"fmt"
+	"github.com/fatima-go/fatima-log"
 	"github.com/fatima-go/jupiter/domain"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 )
 
 func NewMemoryUserRepository() domain.UserRepository {
-	return new(InMemoryUserRepository)
+	repo := new(InMemoryUserRepository)
+	repo.users = make(map[string]domain.User)
+	repo.users["admin"] = domain.User{Id: "admin", Password: "admin", Role: domain.ROLE_OPERATOR}
+	return repo
 }
 
 func NewMemoryTokenRepository() domain.TokenRepository {
@@ -38,77 +46,311 @@ func NewMemoryTokenRepository() domain.TokenRepository {
 	return repo
 }
 
+// NewMemoryDeployRepository creates juno repository which keeps registrations in memory only.
+// it has same semantics with file juno repository
 func NewMemoryDeployRepository() domain.JunoRepository {
-	return new(InMemoryJunoRepository)
+	repo := new(InMemoryJunoRepository)
+	repo.summary = newEmptyJunoSummary()
+	return repo
 }
 
 type InMemoryUserRepository struct {
+	users map[string]domain.User
+	mutex sync.RWMutex
 }
 
-func (handler *InMemoryUserRepository) Save(user domain.User) {
+func (handler *InMemoryUserRepository) Save(user domain.User) error {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+	handler.users[user.Id] = user
+	return nil
 }
 
 func (handler *InMemoryUserRepository) FindById(id string) *domain.User {
-	user := domain.User{}
-	user.Id = "admin"
-	user.Password = "admin"
-	user.Role = domain.ROLE_OPERATOR
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+	user, ok := handler.users[id]
+	if !ok {
+		return nil
+	}
 	return &user
 }
 
-func (handler *InMemoryUserRepository) Delete(id string) {
+func (handler *InMemoryUserRepository) FindAll() []domain.User {
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+	list := make([]domain.User, 0, len(handler.users))
+	for _, v := range handler.users {
+		list = append(list, v)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Id < list[j].Id })
+	return list
+}
+
+func (handler *InMemoryUserRepository) Delete(id string) error {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+	if _, ok := handler.users[id]; !ok {
+		return fmt.Errorf("not found user %s", id)
+	}
+	delete(handler.users, id)
+	return nil
 }
 
 func (handler *InMemoryUserRepository) Exists(id string) bool {
-	return true
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+	_, ok := handler.users[id]
+	return ok
 }
 
 func (handler *InMemoryUserRepository) Count() int {
-	return 1
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+	return len(handler.users)
 }
 
 type InMemoryTokenRepository struct {
 	keyStore KeyStore
 }
 
-func (handler *InMemoryTokenRepository) Save(token string, role domain.Role, ttlSeconds time.Duration) {
-	handler.keyStore.Put(token, role, ttlSeconds)
+func (handler *InMemoryTokenRepository) Save(token string, session domain.Session, ttlSeconds time.Duration) {
+	handler.keyStore.Put(token, session, ttlSeconds)
 }
 
-func (handler *InMemoryTokenRepository) FindById(token string) (domain.Role, bool) {
+func (handler *InMemoryTokenRepository) FindById(token string) (domain.Session, bool) {
 	return handler.keyStore.Get(token)
 }
 
+func (handler *InMemoryTokenRepository) FindAll() []domain.Session {
+	return handler.keyStore.List()
+}
+
+func (handler *InMemoryTokenRepository) Delete(token string) {
+	handler.keyStore.Remove(digestToken(token))
+}
+
+func (handler *InMemoryTokenRepository) DeleteSession(id string) bool {
+	return handler.keyStore.Remove(id)
+}
+
+func (handler *InMemoryTokenRepository) Extend(token string, expireAt time.Time) bool {
+	return handler.keyStore.Extend(digestToken(token), expireAt)
+}
+
+// InMemoryJunoRepository keeps juno summary in memory guarded by RWMutex.
+// find functions return copy which is safe to use after lock is released
 type InMemoryJunoRepository struct {
+	summary *domain.JunoSummary
+	health  map[string][]domain.HealthResult
+	mutex   sync.RWMutex
+}
+
+func copyHealthHistory(histories map[string][]domain.HealthResult) map[string][]domain.HealthResult {
+	copied := make(map[string][]domain.HealthResult, len(histories))
+	for endpoint, results := range histories {
+		copied[endpoint] = append([]domain.HealthResult(nil), results...)
+	}
+	return copied
+}
+
+func newEmptyJunoSummary() *domain.JunoSummary {
+	summary := &domain.JunoSummary{GroupCount: 0, HostCount: 0, PackageCount: 0}
+	summary.Groups = make([]domain.JunoGroup, 0)
+	return summary
+}
+
+func copyPackage(pack *domain.JunoPackage) *domain.JunoPackage {
+	if pack == nil {
+		return nil
+	}
+	p := pack.Copy()
+	return &p
 }
 
 func (handler *InMemoryJunoRepository) FindAll() *domain.JunoSummary {
-	return new(domain.JunoSummary)
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+
+	return handler.summary.Copy()
 }
 
 func (handler *InMemoryJunoRepository) FindByPoint(point domain.PackagePoint) *domain.JunoPackage {
-	return nil
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+
+	summary := handler.summary
+
+	if summary == nil {
+		return nil
+	}
+
+	if summary.PackageCount == 0 {
+		return nil
+	}
+
+	if len(point.Host) < 1 {
+		if summary.PackageCount == 1 {
+			if len(summary.Groups) == 0 || len(summary.Groups[0].Packages) == 0 {
+				log.Warn("invalid juno summary. JunoPackageCount is 1 but there are no juno data")
+				return nil
+			}
+			return copyPackage(&summary.Groups[0].Packages[0])
+		}
+		return nil
+	}
+
+	return copyPackage(summary.FindByPoint(point))
 }
 
+// FindByAddress returns first package whose endpoint host is ip of address(ip:port)
 func (handler *InMemoryJunoRepository) FindByAddress(address string) *domain.JunoPackage {
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+
+	summary := handler.summary
+
+	if summary == nil {
+		return nil
+	}
+
+	ip := ExtractIpAddress(address)
+	for i := range summary.Groups {
+		for j := range summary.Groups[i].Packages {
+			if endpointHasAddress(summary.Groups[i].Packages[j].Endpoint, ip) {
+				return copyPackage(&summary.Groups[i].Packages[j])
+			}
+		}
+	}
+
 	return nil
 }
 
 func (handler *InMemoryJunoRepository) FindGroup(groupName string) *domain.JunoGroup {
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+
+	summary := handler.summary
+
+	comp := strings.ToLower(groupName)
+	for i := 0; i < len(summary.Groups); i++ {
+		if comp == strings.ToLower(summary.Groups[i].Name) {
+			group := summary.Groups[i].Copy()
+			return &group
+		}
+	}
+
 	return nil
 }
 
 func (handler *InMemoryJunoRepository) FindByEndpoint(endpoint string) *domain.JunoPackage {
-	return nil
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+
+	summary := handler.summary
+
+	if summary == nil || len(endpoint) < 1 {
+		return nil
+	}
+
+	if summary.PackageCount == 0 {
+		return nil
+	}
+
+	return copyPackage(summary.FindByEndpoint(endpoint))
+}
+
+// save appends package to its group. caller holds write lock
+func (handler *InMemoryJunoRepository) save(data domain.JunoRegistration) bool {
+	summary := handler.summary
+	if summary == nil {
+		return false
+	}
+
+	compGroup := strings.ToLower(data.Group)
+	for i := 0; i < len(summary.Groups); i++ {
+		if strings.ToLower(summary.Groups[i].Name) == compGroup {
+			summary.Groups[i].Append(data.AsJunoPackage())
+			summary.PackageCount = summary.PackageCount + 1
+			summary.UpdateHostCount()
+			return true
+		}
+	}
+
+	group := domain.JunoGroup{Name: data.Group}
+	group.Packages = make([]domain.JunoPackage, 1)
+	group.Packages[0] = data.AsJunoPackage()
+	summary.Groups = append(summary.Groups, group)
+	summary.GroupCount = summary.GroupCount + 1
+	summary.PackageCount = summary.PackageCount + 1
+	summary.UpdateHostCount()
+	return true
+}
+
+// delete removes package of endpoint and its group when it becomes empty. caller holds write lock
+func (handler *InMemoryJunoRepository) delete(endpoint string) bool {
+	if handler.summary == nil {
+		return false
+	}
+
+	found := -1
+	for i := 0; i < len(handler.summary.Groups); i++ {
+		for j, p := range handler.summary.Groups[i].Packages {
+			if p.Endpoint == endpoint {
+				found = j
+				break
+			}
+		}
+		if found >= 0 {
+			handler.summary.Groups[i].Delete(found)
+			if len(handler.summary.Groups[i].Packages) == 0 {
+				handler.summary.DeleteGroup(i)
+				handler.summary.GroupCount = len(handler.summary.Groups)
+			}
+			handler.summary.PackageCount = handler.summary.PackageCount - 1
+			handler.summary.UpdateHostCount()
+			return true
+		}
+	}
+	return false
 }
 
 func (handler *InMemoryJunoRepository) Save(data domain.JunoRegistration) {
-	return
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	handler.save(data)
 }
 
 func (handler *InMemoryJunoRepository) Delete(endpoint string) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	handler.delete(endpoint)
+}
+
+func (handler *InMemoryJunoRepository) Update(update func(summary *domain.JunoSummary) bool) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	if handler.summary == nil {
+		return
+	}
+
+	update(handler.summary)
 }
 
-func (handler *InMemoryJunoRepository) SaveAll() {
-	return
+func (handler *InMemoryJunoRepository) FindHealthHistory() map[string][]domain.HealthResult {
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+
+	return copyHealthHistory(handler.health)
+}
+
+func (handler *InMemoryJunoRepository) SaveHealthHistory(histories map[string][]domain.HealthResult) error {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	handler.health = copyHealthHistory(histories)
+	return nil
 }
@@ -0,0 +1,73 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 9:05
+ */
+package infra
+
+import (
+	"github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/infra/repotest"
+	"testing"
+)
+
+// TestMemoryJunoRepositoryHammer should be run with -race
+func TestMemoryJunoRepositoryHammer(t *testing.T) {
+	workers, rounds := 8, 20
+	if err := repotest.Hammer(NewMemoryDeployRepository(), workers, rounds); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMemoryJunoRepositoryCopy(t *testing.T) {
+	endpoint := "http://10.0.0.1:9180/a/"
+	repo := NewMemoryDeployRepository()
+	registration := newTestJunoRegistration(endpoint)
+	registration.Labels = map[string]string{"env": "prod"}
+	repo.Save(registration)
+
+	cases := []struct {
+		name string
+		find func() *domain.JunoPackage
+	}{
+		{"find by endpoint", func() *domain.JunoPackage { return repo.FindByEndpoint(endpoint) }},
+		{"find by point", func() *domain.JunoPackage {
+			return repo.FindByPoint(domain.PackagePoint{Host: "host-1", Name: "default"})
+		}},
+		{"find by point without host", func() *domain.JunoPackage { return repo.FindByPoint(domain.PackagePoint{}) }},
+		{"find by address", func() *domain.JunoPackage { return repo.FindByAddress("10.0.0.1:51234") }},
+		{"find group", func() *domain.JunoPackage { return &repo.FindGroup("PAYMENT").Packages[0] }},
+		{"find all", func() *domain.JunoPackage { return &repo.FindAll().Groups[0].Packages[0] }},
+	}
+
+	for _, c := range cases {
+		pack := c.find()
+		if pack == nil || pack.Endpoint != endpoint {
+			t.Fatalf("%s : found %v", c.name, pack)
+		}
+		pack.Status = domain.JUNO_STATUS_DEAD
+		pack.Labels["env"] = "dev"
+
+		stored := repo.FindByEndpoint(endpoint)
+		if stored.Status != domain.JUNO_STATUS_ALIVE || stored.Labels["env"] != "prod" {
+			t.Fatalf("%s : modification of copy reached repository", c.name)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 19. 오후 11:40
+ */
+package infra
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"strings"
+	"sync"
+	"time"
+)
+
+func NewSqlJunoRepository(database *SqlDatabase) domain.JunoRepository {
+	repo := new(SqlJunoRepository)
+	repo.database = database
+	return repo
+}
+
+// SqlJunoRepository keeps juno packages in juno_package table.
+// writes are serialized with mutex so that Update sees consistent summary
+type SqlJunoRepository struct {
+	database *SqlDatabase
+	mutex    sync.Mutex
+}
+
+type sqlJunoPackage struct {
+	group string
+	pack  domain.JunoPackage
+}
+
+func toUnixValue(value interface{}) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	case int:
+		return int64(v)
+	}
+	return 0
+}
+
+func queryJunoPackages(q sqlQueryer, query string, args ...interface{}) ([]sqlJunoPackage, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]sqlJunoPackage, 0)
+	for rows.Next() {
+		var row sqlJunoPackage
+		var registDate, lastChecked int64
+		var labels string
+		err = rows.Scan(&row.group, &row.pack.Endpoint, &row.pack.Host, &row.pack.Name, &registDate,
+			&row.pack.Status, &row.pack.Platform.Architecture, &row.pack.Platform.Os, &row.pack.KeyId,
+			&lastChecked, &row.pack.LeaseSeconds, &labels)
+		if err != nil {
+			return nil, err
+		}
+		if err = unmarshalSqlColumn(labels, &row.pack.Labels); err != nil {
+			return nil, fmt.Errorf("invalid labels of %s : %s", row.pack.Endpoint, err.Error())
+		}
+		row.pack.RegistDate = registDate
+		if lastChecked > 0 {
+			row.pack.LastChecked = lastChecked
+		}
+		list = append(list, row)
+	}
+	return list, rows.Err()
+}
+
+func insertJunoPackage(q sqlQueryer, query string, group string, pack domain.JunoPackage) error {
+	labels, err := marshalSqlColumn(pack.Labels, len(pack.Labels) == 0)
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec(query, group, pack.Endpoint, pack.Host, pack.Name, toUnixValue(pack.RegistDate),
+		pack.Status, pack.Platform.Architecture, pack.Platform.Os, pack.KeyId,
+		toUnixValue(pack.LastChecked), pack.LeaseSeconds, labels)
+	return err
+}
+
+// buildJunoSummary groups rows in order. group name is case-insensitive and first row gives its name
+func buildJunoSummary(list []sqlJunoPackage) *domain.JunoSummary {
+	summary := &domain.JunoSummary{}
+	summary.Groups = make([]domain.JunoGroup, 0)
+	groupIndex := make(map[string]int)
+	for _, row := range list {
+		comp := strings.ToLower(row.group)
+		idx, ok := groupIndex[comp]
+		if !ok {
+			idx = len(summary.Groups)
+			groupIndex[comp] = idx
+			summary.Groups = append(summary.Groups, domain.JunoGroup{Name: row.group, Packages: make([]domain.JunoPackage, 0)})
+		}
+		summary.Groups[idx].Append(row.pack)
+	}
+	summary.GroupCount = len(summary.Groups)
+	summary.PackageCount = len(list)
+	summary.UpdateHostCount()
+	return summary
+}
+
+func (handler *SqlJunoRepository) findOne(query string, args ...interface{}) *domain.JunoPackage {
+	list, err := queryJunoPackages(handler.database.db, query, args...)
+	if err != nil {
+		log.Warn("fail to find juno package : %s", err.Error())
+		return nil
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	return &list[0].pack
+}
+
+// findSingle returns package when there is only one package
+func (handler *SqlJunoRepository) findSingle() *domain.JunoPackage {
+	list, err := queryJunoPackages(handler.database.db, handler.database.provider.GetSqlFindSingleDep())
+	if err != nil {
+		log.Warn("fail to find juno package : %s", err.Error())
+		return nil
+	}
+	if len(list) != 1 {
+		return nil
+	}
+	return &list[0].pack
+}
+
+func (handler *SqlJunoRepository) FindAll() *domain.JunoSummary {
+	list, err := queryJunoPackages(handler.database.db, handler.database.provider.GetSqlFindAllDep())
+	if err != nil {
+		log.Warn("fail to find juno packages : %s", err.Error())
+		return buildJunoSummary(nil)
+	}
+	return buildJunoSummary(list)
+}
+
+func (handler *SqlJunoRepository) FindGroup(groupName string) *domain.JunoGroup {
+	list, err := queryJunoPackages(handler.database.db, handler.database.provider.GetSqlFindDep(), groupName)
+	if err != nil {
+		log.Warn("fail to find juno group : %s", err.Error())
+		return nil
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	return &buildJunoSummary(list).Groups[0]
+}
+
+func (handler *SqlJunoRepository) FindByPoint(point domain.PackagePoint) *domain.JunoPackage {
+	if len(point.Host) < 1 {
+		return handler.findSingle()
+	}
+	return handler.findOne(handler.database.provider.GetSqlFindDepByPoint(), point.Host, point.Name)
+}
+
+// FindByAddress returns first package whose endpoint host is ip of address(ip:port).
+// rows containing ip are filtered with same rule of memory repository
+func (handler *SqlJunoRepository) FindByAddress(address string) *domain.JunoPackage {
+	ip := strings.Trim(ExtractIpAddress(address), "[]")
+	if len(ip) == 0 {
+		return nil
+	}
+
+	list, err := queryJunoPackages(handler.database.db, handler.database.provider.GetSqlFindDepByAddress(ip), ip)
+	if err != nil {
+		log.Warn("fail to find juno package : %s", err.Error())
+		return nil
+	}
+	for i := range list {
+		if endpointHasAddress(list[i].pack.Endpoint, ip) {
+			return &list[i].pack
+		}
+	}
+	return nil
+}
+
+func (handler *SqlJunoRepository) FindByEndpoint(endpoint string) *domain.JunoPackage {
+	if len(endpoint) < 1 {
+		return nil
+	}
+	return handler.findOne(handler.database.provider.GetSqlFindDepByEndpoint(), endpoint)
+}
+
+func (handler *SqlJunoRepository) Save(data domain.JunoRegistration) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	provider := handler.database.provider
+	err := handler.database.inTx(func(tx *sql.Tx) error {
+		group := data.Group
+		list, err := queryJunoPackages(tx, provider.GetSqlFindDep(), group)
+		if err != nil {
+			return err
+		}
+		if len(list) > 0 {
+			group = list[0].group
+		}
+		return insertJunoPackage(tx, provider.GetSqlInsertDep(), group, data.AsJunoPackage())
+	})
+	if err != nil {
+		log.Error("fail to save juno package %s : %s", data.Endpoint, err.Error())
+	}
+}
+
+func (handler *SqlJunoRepository) Delete(endpoint string) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	_, err := handler.database.db.Exec(handler.database.provider.GetSqlDeleteDepByEndpoint(), endpoint)
+	if err != nil {
+		log.Error("fail to delete juno package %s : %s", endpoint, err.Error())
+	}
+}
+
+// Update loads summary and writes whole packages back in summary order when update returns true
+func (handler *SqlJunoRepository) Update(update func(summary *domain.JunoSummary) bool) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+
+	provider := handler.database.provider
+	err := handler.database.inTx(func(tx *sql.Tx) error {
+		list, err := queryJunoPackages(tx, provider.GetSqlFindAllDep())
+		if err != nil {
+			return err
+		}
+
+		summary := buildJunoSummary(list)
+		if !update(summary) {
+			return nil
+		}
+
+		_, err = tx.Exec(provider.GetSqlDeleteAllDep())
+		if err != nil {
+			return err
+		}
+		for _, g := range summary.Groups {
+			for _, p := range g.Packages {
+				err = insertJunoPackage(tx, provider.GetSqlInsertDep(), g.Name, p)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error("fail to update juno packages : %s", err.Error())
+	}
+}
+
+func (handler *SqlJunoRepository) FindHealthHistory() map[string][]domain.HealthResult {
+	histories := make(map[string][]domain.HealthResult)
+
+	rows, err := handler.database.db.Query(handler.database.provider.GetSqlFindAllHealth())
+	if err != nil {
+		log.Warn("fail to find health history : %s", err.Error())
+		return histories
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var endpoint string
+		var seq, checkedAt, latency int64
+		var result domain.HealthResult
+		err = rows.Scan(&endpoint, &seq, &checkedAt, &latency, &result.Error)
+		if err != nil {
+			log.Warn("fail to read health history : %s", err.Error())
+			return make(map[string][]domain.HealthResult)
+		}
+		result.Time = time.UnixMilli(checkedAt)
+		result.Latency = time.Duration(latency)
+		histories[endpoint] = append(histories[endpoint], result)
+	}
+	return histories
+}
+
+// SaveHealthHistory replaces every health result in one transaction
+func (handler *SqlJunoRepository) SaveHealthHistory(histories map[string][]domain.HealthResult) error {
+	provider := handler.database.provider
+	return handler.database.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(provider.GetSqlDeleteAllHealth()); err != nil {
+			return err
+		}
+		for endpoint, results := range histories {
+			for i, r := range results {
+				_, err := tx.Exec(provider.GetSqlInsertHealth(), endpoint, i, r.Time.UnixMilli(), int64(r.Latency), r.Error)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
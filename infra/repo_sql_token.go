@@ -0,0 +1,62 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오전 12:20
+ */
+package infra
+
+import (
+	"github.com/fatima-go/jupiter/domain"
+	"time"
+)
+
+func NewSqlTokenRepository(database *SqlDatabase) domain.TokenRepository {
+	repo := new(SqlTokenRepository)
+	repo.keyStore = newSqlKeyStore(database)
+	return repo
+}
+
+type SqlTokenRepository struct {
+	keyStore KeyStore
+}
+
+func (handler *SqlTokenRepository) Save(token string, session domain.Session, ttlSeconds time.Duration) {
+	handler.keyStore.Put(token, session, ttlSeconds)
+}
+
+func (handler *SqlTokenRepository) FindById(token string) (domain.Session, bool) {
+	return handler.keyStore.Get(token)
+}
+
+func (handler *SqlTokenRepository) FindAll() []domain.Session {
+	return handler.keyStore.List()
+}
+
+func (handler *SqlTokenRepository) Delete(token string) {
+	handler.keyStore.Remove(digestToken(token))
+}
+
+func (handler *SqlTokenRepository) DeleteSession(id string) bool {
+	return handler.keyStore.Remove(id)
+}
+
+func (handler *SqlTokenRepository) Extend(token string, expireAt time.Time) bool {
+	return handler.keyStore.Extend(digestToken(token), expireAt)
+}
@@ -0,0 +1,167 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 19. 오후 11:55
+ */
+package infra
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+)
+
+func NewSqlUserRepository(database *SqlDatabase) domain.UserRepository {
+	repo := new(SqlUserRepository)
+	repo.database = database
+	return repo
+}
+
+// SqlUserRepository keeps users in jupiter_user table. bindings and totp are stored as json
+type SqlUserRepository struct {
+	database *SqlDatabase
+}
+
+func marshalSqlColumn(value interface{}, empty bool) (string, error) {
+	if empty {
+		return "", nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func unmarshalSqlColumn(column string, value interface{}) error {
+	if len(column) == 0 {
+		return nil
+	}
+	return json.Unmarshal([]byte(column), value)
+}
+
+func queryUsers(q sqlQueryer, query string, args ...interface{}) ([]domain.User, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]domain.User, 0)
+	for rows.Next() {
+		var u GatewayUser
+		var bindings, totp string
+		err = rows.Scan(&u.Id, &u.Password, &u.Role, &bindings, &totp)
+		if err != nil {
+			return nil, err
+		}
+		if err = unmarshalSqlColumn(bindings, &u.Bindings); err != nil {
+			return nil, fmt.Errorf("invalid bindings of user %s : %s", u.Id, err.Error())
+		}
+		if err = unmarshalSqlColumn(totp, &u.Totp); err != nil {
+			return nil, fmt.Errorf("invalid totp of user %s : %s", u.Id, err.Error())
+		}
+		list = append(list, toDomainUser(u))
+	}
+	return list, rows.Err()
+}
+
+func (handler *SqlUserRepository) count(query string, args ...interface{}) int {
+	var count int
+	err := handler.database.db.QueryRow(query, args...).Scan(&count)
+	if err != nil {
+		log.Warn("fail to count users : %s", err.Error())
+		return 0
+	}
+	return count
+}
+
+func (handler *SqlUserRepository) Save(user domain.User) error {
+	u := toGatewayUser(user)
+	bindings, err := marshalSqlColumn(u.Bindings, len(u.Bindings) == 0)
+	if err != nil {
+		return fmt.Errorf("fail to build bindings of user %s : %s", u.Id, err.Error())
+	}
+	totp, err := marshalSqlColumn(u.Totp, u.Totp == nil)
+	if err != nil {
+		return fmt.Errorf("fail to build totp of user %s : %s", u.Id, err.Error())
+	}
+
+	provider := handler.database.provider
+	err = handler.database.inTx(func(tx *sql.Tx) error {
+		var count int
+		err := tx.QueryRow(provider.GetSqlCountUserById(), u.Id).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			_, err = tx.Exec(provider.GetSqlUpdateUser(), u.Password, u.Role, bindings, totp, u.Id)
+		} else {
+			_, err = tx.Exec(provider.GetSqlInsertUser(), u.Id, u.Password, u.Role, bindings, totp)
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("fail to save user %s : %s", u.Id, err.Error())
+	}
+	return nil
+}
+
+func (handler *SqlUserRepository) FindById(id string) *domain.User {
+	list, err := queryUsers(handler.database.db, handler.database.provider.GetSqlFindUserById(), id)
+	if err != nil {
+		log.Warn("fail to find user %s : %s", id, err.Error())
+		return nil
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	return &list[0]
+}
+
+func (handler *SqlUserRepository) FindAll() []domain.User {
+	list, err := queryUsers(handler.database.db, handler.database.provider.GetSqlFindAllUsers())
+	if err != nil {
+		log.Warn("fail to find users : %s", err.Error())
+		return make([]domain.User, 0)
+	}
+	return list
+}
+
+func (handler *SqlUserRepository) Delete(id string) error {
+	result, err := handler.database.db.Exec(handler.database.provider.GetSqlDeleteUserById(), id)
+	if err != nil {
+		return fmt.Errorf("fail to delete user %s : %s", id, err.Error())
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("not found user %s", id)
+	}
+	return nil
+}
+
+func (handler *SqlUserRepository) Exists(id string) bool {
+	return handler.count(handler.database.provider.GetSqlCountUserById(), id) > 0
+}
+
+func (handler *SqlUserRepository) Count() int {
+	return handler.count(handler.database.provider.GetSqlCountAllUsers())
+}
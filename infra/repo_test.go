@@ -0,0 +1,144 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오전 11:52
+ */
+
+package infra
+
+import (
+	"github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/infra/repotest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func openTestSqlDatabase(t *testing.T) *SqlDatabase {
+	database, err := OpenSqlDatabase(filepath.Join(t.TempDir(), SQL_DATA_FILE))
+	if err != nil {
+		t.Fatalf("fail to open database : %s", err.Error())
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+func TestUserRepository(t *testing.T) {
+	backends := []struct {
+		name    string
+		newRepo func(t *testing.T) domain.UserRepository
+	}{
+		{"file", func(t *testing.T) domain.UserRepository {
+			return NewFileUserRepositoryWithPath(filepath.Join(t.TempDir(), USER_DATA_FILE))
+		}},
+		{"memory", func(t *testing.T) domain.UserRepository { return NewMemoryUserRepository() }},
+		{"sql", func(t *testing.T) domain.UserRepository { return NewSqlUserRepository(openTestSqlDatabase(t)) }},
+	}
+
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			if err := repotest.CheckUserRepository(b.newRepo(t)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestTokenRepository(t *testing.T) {
+	backends := []struct {
+		name    string
+		newRepo func(t *testing.T) domain.TokenRepository
+	}{
+		{"file", func(t *testing.T) domain.TokenRepository {
+			return NewFileTokenRepositoryWithPath(filepath.Join(t.TempDir(), TOKEN_DATA_FILE))
+		}},
+		{"memory", func(t *testing.T) domain.TokenRepository { return NewMemoryTokenRepository() }},
+		{"sql", func(t *testing.T) domain.TokenRepository { return NewSqlTokenRepository(openTestSqlDatabase(t)) }},
+	}
+
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			if err := repotest.CheckTokenRepository(b.newRepo(t)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestTokenRepositoryRestart(t *testing.T) {
+	backends := []struct {
+		name     string
+		fileName string
+		open     func(t *testing.T, path string) (domain.TokenRepository, func())
+	}{
+		{"file", TOKEN_DATA_FILE, func(t *testing.T, path string) (domain.TokenRepository, func()) {
+			return NewFileTokenRepositoryWithPath(path), func() {}
+		}},
+		{"sql", SQL_DATA_FILE, func(t *testing.T, path string) (domain.TokenRepository, func()) {
+			database, err := OpenSqlDatabase(path)
+			if err != nil {
+				t.Fatalf("fail to open database : %s", err.Error())
+			}
+			return NewSqlTokenRepository(database), func() { database.Close() }
+		}},
+	}
+
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), b.fileName)
+			repo, closeRepo := b.open(t, path)
+			repo.Save("live-token", domain.Session{UserId: "alice", Role: domain.ROLE_OPERATOR}, time.Hour)
+			repo.Save("expired-token", domain.Session{UserId: "alice", Role: domain.ROLE_OPERATOR}, -time.Second)
+			repo.Save("deleted-token", domain.Session{UserId: "alice", Role: domain.ROLE_OPERATOR}, time.Hour)
+			repo.Delete("deleted-token")
+			closeRepo()
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("fail to read token store : %s", err.Error())
+			}
+			if strings.Contains(string(content), "live-token") {
+				t.Fatalf("token itself is written to %s", b.fileName)
+			}
+
+			repo, closeRepo = b.open(t, path)
+			defer closeRepo()
+			cases := []struct {
+				token string
+				found bool
+			}{
+				{"live-token", true},
+				{"expired-token", false},
+				{"deleted-token", false},
+			}
+			for _, c := range cases {
+				session, ok := repo.FindById(c.token)
+				if ok != c.found {
+					t.Fatalf("%s : found %t after restart", c.token, ok)
+				}
+				if ok && (session.UserId != "alice" || session.Role != domain.ROLE_OPERATOR) {
+					t.Fatalf("%s : restored as %v", c.token, session)
+				}
+			}
+		})
+	}
+}
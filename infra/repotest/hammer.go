@@ -0,0 +1,135 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 19. 오후 10:20
+ */
+// Package repotest provides helpers to verify juno, user and token repository implementations from go test.
+// every backend(file, memory, sql) should pass the same checks
+// run them with -race to find data race of repository
+package repotest
+
+import (
+	"fmt"
+	"github.com/fatima-go/jupiter/domain"
+	"sync"
+	"time"
+)
+
+// Hammer registers, unregisters, reads and removes junos from workers goroutines concurrently.
+// each worker registers rounds packages on its own group, marks them dead, reads summary while others write
+// and removes every other package. returns error when final state of repository is inconsistent
+func Hammer(repo domain.JunoRepository, workers int, rounds int) error {
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			if err := hammerWorker(repo, worker, rounds); err != nil {
+				errs <- err
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		return err
+	}
+
+	expected := workers * (rounds / 2)
+	return CheckCounts(repo.FindAll(), expected)
+}
+
+func hammerEndpoint(worker int, round int) string {
+	return fmt.Sprintf("http://10.0.%d.%d:9180/hammer/", worker, round)
+}
+
+func hammerWorker(repo domain.JunoRepository, worker int, rounds int) error {
+	group := fmt.Sprintf("hammer-%d", worker)
+	for r := 0; r < rounds; r++ {
+		registration := domain.JunoRegistration{Group: group}
+		registration.Endpoint = hammerEndpoint(worker, r)
+		registration.Host = fmt.Sprintf("host-%d-%d", worker, r)
+		registration.Name = "default"
+		registration.Status = domain.JUNO_STATUS_ALIVE
+		registration.RegistDate = time.Now().Unix()
+		repo.Save(registration)
+
+		// unregist
+		endpoint := registration.Endpoint
+		repo.Update(func(summary *domain.JunoSummary) bool {
+			pack := summary.FindByEndpoint(endpoint)
+			if pack == nil {
+				return false
+			}
+			pack.Status = domain.JUNO_STATUS_DEAD
+			return true
+		})
+
+		// summary. returned copy is modified on purpose, it must not reach repository
+		summary := repo.FindAll()
+		for i := range summary.Groups {
+			for j := range summary.Groups[i].Packages {
+				summary.Groups[i].Packages[j].Status = domain.JUNO_STATUS_ALIVE
+			}
+		}
+
+		pack := repo.FindByEndpoint(endpoint)
+		if pack == nil {
+			return fmt.Errorf("registered endpoint %s is not found", endpoint)
+		}
+		if pack.Status != domain.JUNO_STATUS_DEAD {
+			return fmt.Errorf("modification of copy reached repository on %s", endpoint)
+		}
+	}
+
+	for r := 0; r < rounds; r++ {
+		if r%2 == 0 {
+			repo.Delete(hammerEndpoint(worker, r))
+		}
+	}
+	return nil
+}
+
+// CheckCounts verifies package, group and host counts of summary with its groups
+func CheckCounts(summary *domain.JunoSummary, expectedPackages int) error {
+	packages := 0
+	for _, g := range summary.Groups {
+		if len(g.Packages) == 0 {
+			return fmt.Errorf("group %s has no package", g.Name)
+		}
+		packages = packages + len(g.Packages)
+	}
+
+	if packages != expectedPackages {
+		return fmt.Errorf("expected %d packages but %d packages in groups", expectedPackages, packages)
+	}
+	if summary.PackageCount != packages {
+		return fmt.Errorf("package count %d but %d packages in groups", summary.PackageCount, packages)
+	}
+	if summary.GroupCount != len(summary.Groups) {
+		return fmt.Errorf("group count %d but %d groups", summary.GroupCount, len(summary.Groups))
+	}
+	if summary.HostCount != packages {
+		return fmt.Errorf("host count %d but %d packages on distinct hosts", summary.HostCount, packages)
+	}
+	return nil
+}
@@ -0,0 +1,385 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오전 1:10
+ */
+package repotest
+
+import (
+	"fmt"
+	"github.com/fatima-go/jupiter/domain"
+	"testing"
+	"time"
+)
+
+// JunoCase is a conformance case of domain.JunoRepository. check runs on empty repository
+type JunoCase struct {
+	Name  string
+	Check func(repo domain.JunoRepository) error
+}
+
+// JunoCases are behaviors every domain.JunoRepository implementation has to keep
+var JunoCases = []JunoCase{
+	{"empty repository", checkJunoEmpty},
+	{"save and find", checkJunoSaveAndFind},
+	{"group name is case-insensitive", checkJunoGroupCase},
+	{"host and package name are case-insensitive", checkJunoPointCase},
+	{"point without host finds only package", checkJunoPointWithoutHost},
+	{"address lookup matches endpoint host", checkJunoAddress},
+	{"delete last package of group removes group", checkJunoDeleteLastOfGroup},
+	{"delete keeps other packages and groups", checkJunoDeleteMiddle},
+	{"counts follow save and delete", checkJunoCounts},
+	{"update is stored and copies are not", checkJunoUpdate},
+	{"labels are kept", checkJunoLabels},
+	{"health history is replaced", checkJunoHealthHistory},
+}
+
+// RunJunoConformance runs every JunoCases on repository from newRepo as subtest
+func RunJunoConformance(t *testing.T, newRepo func() domain.JunoRepository) {
+	for _, c := range JunoCases {
+		t.Run(c.Name, func(t *testing.T) {
+			if err := c.Check(newRepo()); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// CheckJunoRepository runs every JunoCases on repository from newRepo and returns first failure
+func CheckJunoRepository(newRepo func() domain.JunoRepository) error {
+	for _, c := range JunoCases {
+		if err := c.Check(newRepo()); err != nil {
+			return fmt.Errorf("%s : %s", c.Name, err.Error())
+		}
+	}
+	return nil
+}
+
+func newRegistration(group, host, endpoint string) domain.JunoRegistration {
+	registration := domain.JunoRegistration{Group: group}
+	registration.Endpoint = endpoint
+	registration.Host = host
+	registration.Name = "default"
+	registration.Status = domain.JUNO_STATUS_ALIVE
+	registration.RegistDate = time.Now().Unix()
+	registration.Platform = domain.PlatformInfo{Architecture: "amd64", Os: "linux"}
+	registration.KeyId = "key-" + host
+	return registration
+}
+
+// saveSample saves payment(host-1, host-2), order(host-3) and batch(host-4)
+func saveSample(repo domain.JunoRepository) {
+	repo.Save(newRegistration("payment", "host-1", "http://10.0.0.1:9180/a/"))
+	repo.Save(newRegistration("payment", "host-2", "http://10.0.0.2:9180/b/"))
+	repo.Save(newRegistration("order", "host-3", "http://10.0.0.3:9180/c/"))
+	repo.Save(newRegistration("batch", "host-4", "http://10.0.0.4:9180/d/"))
+}
+
+func expectHost(pack *domain.JunoPackage, host string, what string) error {
+	if len(host) == 0 {
+		if pack != nil {
+			return fmt.Errorf("%s : expected nothing but %s", what, pack.Endpoint)
+		}
+		return nil
+	}
+	if pack == nil {
+		return fmt.Errorf("%s : expected %s but not found", what, host)
+	}
+	if pack.Host != host {
+		return fmt.Errorf("%s : expected %s but %s", what, host, pack.Host)
+	}
+	return nil
+}
+
+func checkJunoEmpty(repo domain.JunoRepository) error {
+	if err := CheckCounts(repo.FindAll(), 0); err != nil {
+		return err
+	}
+	if repo.FindGroup("payment") != nil {
+		return fmt.Errorf("group is found on empty repository")
+	}
+	if err := expectHost(repo.FindByEndpoint("http://10.0.0.1:9180/a/"), "", "endpoint"); err != nil {
+		return err
+	}
+	if err := expectHost(repo.FindByPoint(domain.PackagePoint{}), "", "empty point"); err != nil {
+		return err
+	}
+	if err := expectHost(repo.FindByAddress("10.0.0.1:52100"), "", "address"); err != nil {
+		return err
+	}
+	repo.Delete("http://10.0.0.1:9180/a/")
+	return CheckCounts(repo.FindAll(), 0)
+}
+
+func checkJunoSaveAndFind(repo domain.JunoRepository) error {
+	saved := newRegistration("payment", "host-1", "http://10.0.0.1:9180/a/")
+	repo.Save(saved)
+
+	pack := repo.FindByEndpoint(saved.Endpoint)
+	if pack == nil {
+		return fmt.Errorf("saved endpoint %s is not found", saved.Endpoint)
+	}
+	if pack.Host != saved.Host || pack.Name != saved.Name || pack.Status != saved.Status ||
+		pack.Platform != saved.Platform || pack.KeyId != saved.KeyId {
+		return fmt.Errorf("package is stored as %v", pack)
+	}
+	if repo.FindByEndpoint("http://10.0.0.1:9180/") != nil {
+		return fmt.Errorf("endpoint should match exactly")
+	}
+
+	group := repo.FindGroup("payment")
+	if group == nil || group.Name != "payment" || len(group.Packages) != 1 {
+		return fmt.Errorf("group payment is not found")
+	}
+	return nil
+}
+
+func checkJunoGroupCase(repo domain.JunoRepository) error {
+	repo.Save(newRegistration("Payment", "host-1", "http://10.0.0.1:9180/a/"))
+	repo.Save(newRegistration("PAYMENT", "host-2", "http://10.0.0.2:9180/b/"))
+
+	summary := repo.FindAll()
+	if summary.GroupCount != 1 || len(summary.Groups) != 1 {
+		return fmt.Errorf("expected 1 group but %d", len(summary.Groups))
+	}
+	if summary.Groups[0].Name != "Payment" {
+		return fmt.Errorf("group should keep name of first registration but %s", summary.Groups[0].Name)
+	}
+	for _, name := range []string{"payment", "PAYMENT", "pAyMeNt"} {
+		group := repo.FindGroup(name)
+		if group == nil || len(group.Packages) != 2 {
+			return fmt.Errorf("group %s is not found with 2 packages", name)
+		}
+	}
+	return nil
+}
+
+func checkJunoPointCase(repo domain.JunoRepository) error {
+	repo.Save(newRegistration("payment", "Host-1", "http://10.0.0.1:9180/a/"))
+	repo.Save(newRegistration("payment", "host-2", "http://10.0.0.2:9180/b/"))
+
+	points := map[string]domain.PackagePoint{
+		"Host-1": {Host: "host-1", Name: "DEFAULT"},
+		"host-2": {Host: "HOST-2", Name: "Default"},
+		"":       {Host: "host-3", Name: "default"},
+	}
+	for host, point := range points {
+		if err := expectHost(repo.FindByPoint(point), host, point.Host+":"+point.Name); err != nil {
+			return err
+		}
+	}
+	return expectHost(repo.FindByPoint(domain.PackagePoint{Host: "host-1", Name: "other"}), "", "other package")
+}
+
+func checkJunoPointWithoutHost(repo domain.JunoRepository) error {
+	repo.Save(newRegistration("payment", "host-1", "http://10.0.0.1:9180/a/"))
+	if err := expectHost(repo.FindByPoint(domain.PackagePoint{}), "host-1", "empty point of single package"); err != nil {
+		return err
+	}
+
+	repo.Save(newRegistration("order", "host-2", "http://10.0.0.2:9180/b/"))
+	return expectHost(repo.FindByPoint(domain.PackagePoint{}), "", "empty point of 2 packages")
+}
+
+func checkJunoAddress(repo domain.JunoRepository) error {
+	repo.Save(newRegistration("payment", "host-1", "http://10.0.0.1:9180/a/"))
+	if err := expectHost(repo.FindByAddress("10.0.0.9:52100"), "", "other address of single package"); err != nil {
+		return err
+	}
+
+	repo.Save(newRegistration("payment", "host-11", "http://10.0.0.11:9180/b/"))
+	repo.Save(newRegistration("order", "host-5", "10.0.0.5:9180/c/"))
+	repo.Save(newRegistration("order", "host-6", "http://[fd00::6]:9180/d/"))
+
+	addresses := map[string]string{
+		"10.0.0.1:52100":     "host-1",
+		"10.0.0.11:52100":    "host-11",
+		"10.0.0.5:52100":     "host-5",
+		"[fd00::6]:52100":    "host-6",
+		"10.0.0.111:52100":   "",
+		"10.0.0.0:52100":     "",
+		"[fd00::60]:52100":   "",
+		"9180:52100":         "",
+		"host-1:52100":       "",
+		"http://10.0.0.1:80": "",
+	}
+	for address, host := range addresses {
+		if err := expectHost(repo.FindByAddress(address), host, address); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkJunoDeleteLastOfGroup(repo domain.JunoRepository) error {
+	saveSample(repo)
+	repo.Delete("http://10.0.0.3:9180/c/")
+
+	if repo.FindGroup("order") != nil {
+		return fmt.Errorf("group without package remains")
+	}
+	summary := repo.FindAll()
+	if err := CheckCounts(summary, 3); err != nil {
+		return err
+	}
+	if summary.Groups[0].Name != "payment" || summary.Groups[1].Name != "batch" {
+		return fmt.Errorf("groups after deletion are %s, %s", summary.Groups[0].Name, summary.Groups[1].Name)
+	}
+
+	repo.Save(newRegistration("order", "host-3", "http://10.0.0.3:9180/c/"))
+	if group := repo.FindGroup("order"); group == nil || len(group.Packages) != 1 {
+		return fmt.Errorf("group is not created again")
+	}
+	return CheckCounts(repo.FindAll(), 4)
+}
+
+func checkJunoDeleteMiddle(repo domain.JunoRepository) error {
+	saveSample(repo)
+	repo.Save(newRegistration("batch", "host-5", "http://10.0.0.5:9180/e/"))
+	repo.Save(newRegistration("batch", "host-6", "http://10.0.0.6:9180/f/"))
+
+	// second package of last group. group and package index differ
+	repo.Delete("http://10.0.0.5:9180/e/")
+	if repo.FindByEndpoint("http://10.0.0.5:9180/e/") != nil {
+		return fmt.Errorf("deleted package is found")
+	}
+	for _, endpoint := range []string{"http://10.0.0.1:9180/a/", "http://10.0.0.2:9180/b/",
+		"http://10.0.0.3:9180/c/", "http://10.0.0.4:9180/d/", "http://10.0.0.6:9180/f/"} {
+		if repo.FindByEndpoint(endpoint) == nil {
+			return fmt.Errorf("%s is deleted instead", endpoint)
+		}
+	}
+	if group := repo.FindGroup("batch"); group == nil || len(group.Packages) != 2 {
+		return fmt.Errorf("group batch should have 2 packages")
+	}
+
+	repo.Delete("http://10.0.0.9:9180/unknown/")
+	return CheckCounts(repo.FindAll(), 5)
+}
+
+func checkJunoCounts(repo domain.JunoRepository) error {
+	saveSample(repo)
+	summary := repo.FindAll()
+	if err := CheckCounts(summary, 4); err != nil {
+		return err
+	}
+	if summary.GroupCount != 3 {
+		return fmt.Errorf("expected 3 groups but %d", summary.GroupCount)
+	}
+
+	endpoints := []string{"http://10.0.0.1:9180/a/", "http://10.0.0.3:9180/c/", "http://10.0.0.2:9180/b/", "http://10.0.0.4:9180/d/"}
+	for i, endpoint := range endpoints {
+		repo.Delete(endpoint)
+		if err := CheckCounts(repo.FindAll(), len(endpoints)-i-1); err != nil {
+			return fmt.Errorf("after deleting %s : %s", endpoint, err.Error())
+		}
+	}
+	return nil
+}
+
+func checkJunoUpdate(repo domain.JunoRepository) error {
+	saveSample(repo)
+	endpoint := "http://10.0.0.2:9180/b/"
+
+	repo.Update(func(summary *domain.JunoSummary) bool {
+		pack := summary.FindByEndpoint(endpoint)
+		if pack == nil {
+			return false
+		}
+		pack.Status = domain.JUNO_STATUS_DEAD
+		pack.LeaseSeconds = 30
+		return true
+	})
+	pack := repo.FindByEndpoint(endpoint)
+	if pack == nil || pack.Status != domain.JUNO_STATUS_DEAD || pack.LeaseSeconds != 30 {
+		return fmt.Errorf("update is not stored")
+	}
+
+	pack.Status = domain.JUNO_STATUS_ALIVE
+	repo.FindAll().Groups[0].Packages[1].Status = domain.JUNO_STATUS_ALIVE
+	repo.FindGroup("payment").Packages[1].Status = domain.JUNO_STATUS_ALIVE
+	if repo.FindByEndpoint(endpoint).Status != domain.JUNO_STATUS_DEAD {
+		return fmt.Errorf("modification of copy reached repository")
+	}
+	return CheckCounts(repo.FindAll(), 4)
+}
+
+func checkJunoLabels(repo domain.JunoRepository) error {
+	registration := newRegistration("payment", "host-1", "http://10.0.0.1:9180/a/")
+	registration.Labels = map[string]string{"env": "prod", "region": "kr1"}
+	repo.Save(registration)
+	registration.Labels["env"] = "dev"
+
+	pack := repo.FindByEndpoint(registration.Endpoint)
+	if pack == nil || len(pack.Labels) != 2 || pack.Labels["env"] != "prod" || pack.Labels["region"] != "kr1" {
+		return fmt.Errorf("labels are not kept : %v", pack)
+	}
+	pack.Labels["env"] = "dev"
+	repo.FindAll().Groups[0].Packages[0].Labels["env"] = "dev"
+	if repo.FindByEndpoint(registration.Endpoint).Labels["env"] != "prod" {
+		return fmt.Errorf("labels of copy reached repository")
+	}
+
+	repo.Update(func(summary *domain.JunoSummary) bool {
+		summary.FindByEndpoint(registration.Endpoint).Labels = map[string]string{"tier": "web"}
+		return true
+	})
+	labels := repo.FindByEndpoint(registration.Endpoint).Labels
+	if len(labels) != 1 || labels["tier"] != "web" {
+		return fmt.Errorf("updated labels are %v", labels)
+	}
+	return nil
+}
+
+func checkJunoHealthHistory(repo domain.JunoRepository) error {
+	if len(repo.FindHealthHistory()) != 0 {
+		return fmt.Errorf("health history of empty repository is not empty")
+	}
+
+	now := time.Unix(time.Now().Unix(), 0)
+	first := map[string][]domain.HealthResult{
+		"http://10.0.0.1:9180/a/": {{Time: now, Latency: time.Millisecond}, {Time: now.Add(time.Second), Error: "timeout"}},
+		"http://10.0.0.2:9180/b/": {{Time: now}},
+	}
+	if err := repo.SaveHealthHistory(first); err != nil {
+		return err
+	}
+	first["http://10.0.0.2:9180/b/"][0].Error = "modified"
+
+	found := repo.FindHealthHistory()
+	results := found["http://10.0.0.1:9180/a/"]
+	if len(found) != 2 || len(results) != 2 {
+		return fmt.Errorf("saved health history is %v", found)
+	}
+	if !results[0].Time.Equal(now) || results[0].Latency != time.Millisecond || results[1].Error != "timeout" {
+		return fmt.Errorf("health results are not kept in order : %v", results)
+	}
+	if found["http://10.0.0.2:9180/b/"][0].Error != "" {
+		return fmt.Errorf("modification of saved map reached repository")
+	}
+
+	if err := repo.SaveHealthHistory(map[string][]domain.HealthResult{"http://10.0.0.2:9180/b/": {{Time: now}}}); err != nil {
+		return err
+	}
+	if found = repo.FindHealthHistory(); len(found) != 1 || len(found["http://10.0.0.2:9180/b/"]) != 1 {
+		return fmt.Errorf("health history is not replaced : %v", found)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오전 12:55
+ */
+package repotest
+
+import (
+	"fmt"
+	"github.com/fatima-go/jupiter/domain"
+	"time"
+)
+
+// CheckTokenRepository runs save, find, extend and delete on empty repo
+func CheckTokenRepository(repo domain.TokenRepository) error {
+	session := domain.Session{UserId: "alice", Role: domain.ROLE_MONITOR, ClientAddress: "10.0.0.1", UserAgent: "cli", Kind: "access"}
+	session.Bindings = []domain.RoleBinding{domain.NewRoleBinding("payment", "OPERATOR")}
+	session.AuthenticatedAt = time.Now().Add(-time.Hour).Truncate(time.Second)
+	repo.Save("token-1", session, time.Minute)
+
+	found, ok := repo.FindById("token-1")
+	if !ok {
+		return fmt.Errorf("saved token is not found")
+	}
+	if found.Id == "token-1" || len(found.Id) == 0 {
+		return fmt.Errorf("session id should be digest of token but %s", found.Id)
+	}
+	if found.UserId != session.UserId || found.Role != session.Role || found.ClientAddress != session.ClientAddress ||
+		found.UserAgent != session.UserAgent || found.Kind != session.Kind {
+		return fmt.Errorf("token is stored as %v", found)
+	}
+	if !found.AuthenticatedAt.Equal(session.AuthenticatedAt) {
+		return fmt.Errorf("token login time %s but %s", session.AuthenticatedAt, found.AuthenticatedAt)
+	}
+	if len(found.Bindings) != 1 || found.Bindings[0] != session.Bindings[0] {
+		return fmt.Errorf("token bindings %v but %v", session.Bindings, found.Bindings)
+	}
+	if _, ok = repo.FindById("token-2"); ok {
+		return fmt.Errorf("unknown token is found")
+	}
+
+	expireAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	if !repo.Extend("token-1", expireAt) {
+		return fmt.Errorf("fail to extend token")
+	}
+	if repo.Extend("token-2", expireAt) {
+		return fmt.Errorf("unknown token is extended")
+	}
+	found, _ = repo.FindById("token-1")
+	if !found.ExpireAt.Equal(expireAt) {
+		return fmt.Errorf("token expire %s but %s", expireAt, found.ExpireAt)
+	}
+
+	repo.Save("token-2", domain.Session{UserId: "bob", Role: domain.ROLE_OPERATOR}, time.Minute)
+	repo.Save("token-3", domain.Session{UserId: "bob", Role: domain.ROLE_OPERATOR}, -time.Minute)
+	if _, ok = repo.FindById("token-3"); ok {
+		return fmt.Errorf("expired token is found")
+	}
+	if list := repo.FindAll(); len(list) != 2 {
+		return fmt.Errorf("expected 2 live sessions but %d", len(list))
+	}
+
+	if !repo.DeleteSession(found.Id) {
+		return fmt.Errorf("fail to delete session")
+	}
+	if repo.DeleteSession(found.Id) {
+		return fmt.Errorf("deleted session is deleted again")
+	}
+	repo.Delete("token-2")
+	if _, ok = repo.FindById("token-1"); ok {
+		return fmt.Errorf("deleted session is found")
+	}
+	if _, ok = repo.FindById("token-2"); ok {
+		return fmt.Errorf("deleted token is found")
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오전 12:40
+ */
+package repotest
+
+import (
+	"fmt"
+	"github.com/fatima-go/jupiter/domain"
+	"reflect"
+)
+
+// CheckUserRepository runs save, find, update and delete on repo which has only default admin user
+func CheckUserRepository(repo domain.UserRepository) error {
+	if repo.Count() != 1 || !repo.Exists("admin") {
+		return fmt.Errorf("expected only default admin user but %d users", repo.Count())
+	}
+	admin := repo.FindById("admin")
+	if admin == nil || admin.Role != domain.ROLE_OPERATOR {
+		return fmt.Errorf("default admin user is not operator")
+	}
+
+	alice := domain.User{Id: "alice", Password: "secret", Role: domain.ROLE_MONITOR}
+	alice.Bindings = []domain.RoleBinding{domain.NewRoleBinding("payment", "OPERATOR")}
+	alice.Totp = domain.Totp{Secret: "JBSWY3DPEHPK3PXP", Confirmed: true, LastStep: 100, RecoveryCodes: []string{"r1", "r2"}}
+	if err := repo.Save(alice); err != nil {
+		return fmt.Errorf("fail to save user : %s", err.Error())
+	}
+	if err := checkUser(repo, alice); err != nil {
+		return err
+	}
+
+	alice.Role = domain.ROLE_OPERATOR
+	alice.Bindings = nil
+	alice.Totp = domain.Totp{}
+	if err := repo.Save(alice); err != nil {
+		return fmt.Errorf("fail to update user : %s", err.Error())
+	}
+	if err := checkUser(repo, alice); err != nil {
+		return err
+	}
+
+	if repo.Count() != 2 {
+		return fmt.Errorf("expected 2 users but %d", repo.Count())
+	}
+	if list := repo.FindAll(); len(list) != 2 {
+		return fmt.Errorf("expected 2 users on list but %d", len(list))
+	}
+
+	if err := repo.Delete("alice"); err != nil {
+		return fmt.Errorf("fail to delete user : %s", err.Error())
+	}
+	if repo.Delete("alice") == nil {
+		return fmt.Errorf("deleting unknown user should fail")
+	}
+	if repo.Exists("alice") || repo.FindById("alice") != nil || repo.Count() != 1 {
+		return fmt.Errorf("deleted user is still found")
+	}
+	return nil
+}
+
+func checkUser(repo domain.UserRepository, expected domain.User) error {
+	if !repo.Exists(expected.Id) {
+		return fmt.Errorf("user %s does not exist", expected.Id)
+	}
+	found := repo.FindById(expected.Id)
+	if found == nil {
+		return fmt.Errorf("user %s is not found", expected.Id)
+	}
+	if found.Password != expected.Password || found.Role != expected.Role {
+		return fmt.Errorf("user %s is stored as %s/%s", expected.Id, found.Password, found.Role)
+	}
+	if len(found.Bindings) != len(expected.Bindings) ||
+		(len(expected.Bindings) > 0 && !reflect.DeepEqual(found.Bindings, expected.Bindings)) {
+		return fmt.Errorf("user %s bindings %v but %v", expected.Id, expected.Bindings, found.Bindings)
+	}
+	if found.Totp.Secret != expected.Totp.Secret || found.Totp.Confirmed != expected.Totp.Confirmed ||
+		found.Totp.LastStep != expected.Totp.LastStep || len(found.Totp.RecoveryCodes) != len(expected.Totp.RecoveryCodes) {
+		return fmt.Errorf("user %s totp %v but %v", expected.Id, expected.Totp, found.Totp)
+	}
+	return nil
+}
@@ -16,12 +16,92 @@
 package infra
 
 import (
+	"crypto/rand"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
 	"strings"
 )
 
+const secretLetters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
 func ExtractIpAddress(addr string) string {
 	if idx := strings.LastIndex(addr, ":"); idx >= 0 {
 		return addr[:idx]
 	}
 	return addr
 }
+
+// endpointHasAddress reports whether host of endpoint(http://ip:port/..., ip:port/... or ip) is ip.
+// brackets of ipv6 address are ignored
+func endpointHasAddress(endpoint string, ip string) bool {
+	host := endpoint
+	if idx := strings.Index(host, "://"); idx >= 0 {
+		host = host[idx+3:]
+	}
+	if idx := strings.Index(host, "/"); idx >= 0 {
+		host = host[:idx]
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return len(ip) > 0 && strings.Trim(host, "[]") == strings.Trim(ip, "[]")
+}
+
+// GenerateSecret returns alphanumeric string from crypto/rand.
+// use it instead of lib.RandomAlphanumeric for credentials
+func GenerateSecret(n int) string {
+	b := make([]byte, n)
+	max := big.NewInt(int64(len(secretLetters)))
+	for i := range b {
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+		b[i] = secretLetters[idx.Int64()]
+	}
+	return string(b)
+}
+
+// writeFileAtomic writes data to temporary file in same folder and renames it to path.
+// reader never sees partially written file. folder is synced so rename survives crash
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return syncDir(filepath.Dir(path))
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if closeErr := d.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,157 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 19. 오후 11:20
+ */
+package infra
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/fatima-go/fatima-core"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"path/filepath"
+	"sync"
+
+	_ "modernc.org/sqlite"
+)
+
+const (
+	SQL_DATA_FILE     = "jupiter.db"
+	sqlDriverName     = "sqlite"
+	sqlBusyTimeoutMs  = 5000
+	sqlVersionTable   = "schema_version"
+	sqlVersionCreate  = "CREATE TABLE IF NOT EXISTS " + sqlVersionTable + " (version INTEGER NOT NULL)"
+	sqlVersionCurrent = "SELECT COALESCE(MAX(version), 0) FROM " + sqlVersionTable
+	sqlVersionInsert  = "INSERT INTO " + sqlVersionTable + " (version) VALUES (?)"
+)
+
+var (
+	sharedDatabase      *SqlDatabase
+	sharedDatabaseMutex sync.Mutex
+)
+
+// SqlDatabase is embedded sqlite database with its sql provider.
+// connection is single, so statements are serialized by database/sql
+type SqlDatabase struct {
+	db       *sql.DB
+	provider domain.SqlProvider
+}
+
+// sqlQueryer is *sql.DB or *sql.Tx
+type sqlQueryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// NewSqlDatabase returns database on data folder. repositories of one process share it
+func NewSqlDatabase(fatimaRuntime fatima.FatimaRuntime) (*SqlDatabase, error) {
+	sharedDatabaseMutex.Lock()
+	defer sharedDatabaseMutex.Unlock()
+
+	if sharedDatabase != nil {
+		return sharedDatabase, nil
+	}
+
+	database, err := OpenSqlDatabase(filepath.Join(
+		fatimaRuntime.GetEnv().GetFolderGuide().GetDataFolder(),
+		SQL_DATA_FILE))
+	if err != nil {
+		return nil, err
+	}
+	sharedDatabase = database
+	return sharedDatabase, nil
+}
+
+// OpenSqlDatabase opens sqlite database file on dbFilePath and migrates schema to latest version
+func OpenSqlDatabase(dbFilePath string) (*SqlDatabase, error) {
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(%d)&_pragma=journal_mode(WAL)", dbFilePath, sqlBusyTimeoutMs)
+	db, err := sql.Open(sqlDriverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("fail to open database %s : %s", dbFilePath, err.Error())
+	}
+	db.SetMaxOpenConns(1)
+
+	database := &SqlDatabase{db: db, provider: NewSqliteProvider()}
+	err = database.migrate()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return database, nil
+}
+
+func (d *SqlDatabase) Close() error {
+	return d.db.Close()
+}
+
+// migrate applies migrations newer than stored schema version. each migration runs in own transaction
+func (d *SqlDatabase) migrate() error {
+	_, err := d.db.Exec(sqlVersionCreate)
+	if err != nil {
+		return fmt.Errorf("fail to create schema version table : %s", err.Error())
+	}
+
+	var version int
+	err = d.db.QueryRow(sqlVersionCurrent).Scan(&version)
+	if err != nil {
+		return fmt.Errorf("fail to read schema version : %s", err.Error())
+	}
+
+	migrations := d.provider.GetSqlMigrations()
+	if version > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than %d", version, len(migrations))
+	}
+
+	for i := version; i < len(migrations); i++ {
+		err = d.inTx(func(tx *sql.Tx) error {
+			if _, err := tx.Exec(migrations[i]); err != nil {
+				return err
+			}
+			_, err := tx.Exec(sqlVersionInsert, i+1)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("fail to migrate schema to version %d : %s", i+1, err.Error())
+		}
+	}
+
+	if version < len(migrations) {
+		log.Info("database schema migrated from version %d to %d", version, len(migrations))
+	}
+	return nil
+}
+
+// inTx runs work in transaction. it is committed when work returns nil
+func (d *SqlDatabase) inTx(work func(tx *sql.Tx) error) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = work(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,203 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 19. 오후 11:00
+ */
+package infra
+
+import (
+	"github.com/fatima-go/jupiter/domain"
+)
+
+const (
+	sqlJunoPackageColumns = "group_name, endpoint, host, name, regist_date, status, platform_arch, platform_os, key_id, last_checked, lease_seconds, labels"
+	sqlTokenColumns       = "id, user_id, role, issued_at, expire_at, client_address, user_agent, kind, bindings, auth_at"
+	sqlUserColumns        = "id, passwd, role, bindings, totp"
+	sqlHealthColumns      = "endpoint, seq, checked_at, latency, error"
+)
+
+var sqliteMigrations = []string{
+	`CREATE TABLE juno_package (
+		seq INTEGER PRIMARY KEY,
+		group_name TEXT NOT NULL,
+		endpoint TEXT NOT NULL UNIQUE,
+		host TEXT NOT NULL,
+		name TEXT NOT NULL,
+		regist_date INTEGER NOT NULL DEFAULT 0,
+		status TEXT NOT NULL DEFAULT '',
+		platform_arch TEXT NOT NULL DEFAULT '',
+		platform_os TEXT NOT NULL DEFAULT '',
+		key_id TEXT NOT NULL DEFAULT '',
+		last_checked INTEGER NOT NULL DEFAULT 0,
+		lease_seconds INTEGER NOT NULL DEFAULT 0)`,
+	`CREATE INDEX juno_package_point ON juno_package (lower(host), lower(name))`,
+	`CREATE TABLE jupiter_token (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL DEFAULT '',
+		role TEXT NOT NULL,
+		issued_at INTEGER NOT NULL DEFAULT 0,
+		expire_at INTEGER NOT NULL,
+		client_address TEXT NOT NULL DEFAULT '',
+		user_agent TEXT NOT NULL DEFAULT '',
+		kind TEXT NOT NULL DEFAULT '',
+		bindings TEXT NOT NULL DEFAULT '')`,
+	`CREATE INDEX jupiter_token_expire ON jupiter_token (expire_at)`,
+	`CREATE TABLE jupiter_user (
+		seq INTEGER PRIMARY KEY,
+		id TEXT NOT NULL UNIQUE,
+		passwd TEXT NOT NULL,
+		role TEXT NOT NULL,
+		bindings TEXT NOT NULL DEFAULT '',
+		totp TEXT NOT NULL DEFAULT '')`,
+	`INSERT INTO jupiter_user (id, passwd, role) VALUES ('admin', 'admin', 'OPERATOR')`,
+	`ALTER TABLE juno_package ADD COLUMN labels TEXT NOT NULL DEFAULT ''`,
+	`CREATE TABLE juno_health (
+		endpoint TEXT NOT NULL,
+		seq INTEGER NOT NULL,
+		checked_at INTEGER NOT NULL,
+		latency INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (endpoint, seq))`,
+	`ALTER TABLE jupiter_token ADD COLUMN auth_at INTEGER NOT NULL DEFAULT 0`,
+}
+
+// NewSqliteProvider returns sql statements for sqlite.
+// deployment(juno package) rows keep registration order with seq
+func NewSqliteProvider() domain.SqlProvider {
+	return new(SqliteProvider)
+}
+
+type SqliteProvider struct {
+}
+
+func (p *SqliteProvider) GetSqlMigrations() []string {
+	return sqliteMigrations
+}
+
+func (p *SqliteProvider) GetSqlFindAllDep() string {
+	return "SELECT " + sqlJunoPackageColumns + " FROM juno_package ORDER BY seq"
+}
+
+// GetSqlFindDepByPoint binds host, name
+func (p *SqliteProvider) GetSqlFindDepByPoint() string {
+	return "SELECT " + sqlJunoPackageColumns + " FROM juno_package WHERE lower(host) = lower(?) AND lower(name) = lower(?) ORDER BY seq LIMIT 1"
+}
+
+// GetSqlFindDepByAddress binds ip address and finds candidate rows whose endpoint contains it.
+// caller compares host of endpoint, so statement is same for ipv4 and ipv6
+func (p *SqliteProvider) GetSqlFindDepByAddress(ipAddress string) string {
+	return "SELECT " + sqlJunoPackageColumns + " FROM juno_package WHERE instr(endpoint, ?) > 0 ORDER BY seq"
+}
+
+// GetSqlFindSingleDep returns up to 2 rows. caller uses the row only when there is exactly one package
+func (p *SqliteProvider) GetSqlFindSingleDep() string {
+	return "SELECT " + sqlJunoPackageColumns + " FROM juno_package ORDER BY seq LIMIT 2"
+}
+
+// GetSqlFindDep binds group name. group name is case-insensitive
+func (p *SqliteProvider) GetSqlFindDep() string {
+	return "SELECT " + sqlJunoPackageColumns + " FROM juno_package WHERE lower(group_name) = lower(?) ORDER BY seq"
+}
+
+func (p *SqliteProvider) GetSqlFindDepByEndpoint() string {
+	return "SELECT " + sqlJunoPackageColumns + " FROM juno_package WHERE endpoint = ?"
+}
+
+func (p *SqliteProvider) GetSqlDeleteDepByEndpoint() string {
+	return "DELETE FROM juno_package WHERE endpoint = ?"
+}
+
+func (p *SqliteProvider) GetSqlDeleteAllDep() string {
+	return "DELETE FROM juno_package"
+}
+
+func (p *SqliteProvider) GetSqlInsertDep() string {
+	return "INSERT INTO juno_package (" + sqlJunoPackageColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+}
+
+// GetSqlDeleteOldToken binds current unix time
+func (p *SqliteProvider) GetSqlDeleteOldToken() string {
+	return "DELETE FROM jupiter_token WHERE expire_at < ?"
+}
+
+func (p *SqliteProvider) GetSqlInsertToken() string {
+	return "INSERT OR REPLACE INTO jupiter_token (" + sqlTokenColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+}
+
+// GetSqlFindRoleFromToken binds token digest and current unix time
+func (p *SqliteProvider) GetSqlFindRoleFromToken() string {
+	return "SELECT " + sqlTokenColumns + " FROM jupiter_token WHERE id = ? AND expire_at >= ?"
+}
+
+// GetSqlFindAllTokens binds current unix time
+func (p *SqliteProvider) GetSqlFindAllTokens() string {
+	return "SELECT " + sqlTokenColumns + " FROM jupiter_token WHERE expire_at >= ?"
+}
+
+func (p *SqliteProvider) GetSqlDeleteToken() string {
+	return "DELETE FROM jupiter_token WHERE id = ?"
+}
+
+// GetSqlExtendToken binds expire unix time and token digest
+func (p *SqliteProvider) GetSqlExtendToken() string {
+	return "UPDATE jupiter_token SET expire_at = ? WHERE id = ?"
+}
+
+func (p *SqliteProvider) GetSqlCountUserById() string {
+	return "SELECT COUNT(*) FROM jupiter_user WHERE id = ?"
+}
+
+func (p *SqliteProvider) GetSqlInsertUser() string {
+	return "INSERT INTO jupiter_user (" + sqlUserColumns + ") VALUES (?, ?, ?, ?, ?)"
+}
+
+// GetSqlUpdateUser binds passwd, role, bindings, totp and id
+func (p *SqliteProvider) GetSqlUpdateUser() string {
+	return "UPDATE jupiter_user SET passwd = ?, role = ?, bindings = ?, totp = ? WHERE id = ?"
+}
+
+func (p *SqliteProvider) GetSqlFindUserById() string {
+	return "SELECT " + sqlUserColumns + " FROM jupiter_user WHERE id = ?"
+}
+
+func (p *SqliteProvider) GetSqlDeleteUserById() string {
+	return "DELETE FROM jupiter_user WHERE id = ?"
+}
+
+func (p *SqliteProvider) GetSqlCountAllUsers() string {
+	return "SELECT COUNT(*) FROM jupiter_user"
+}
+
+func (p *SqliteProvider) GetSqlFindAllUsers() string {
+	return "SELECT " + sqlUserColumns + " FROM jupiter_user ORDER BY seq"
+}
+
+func (p *SqliteProvider) GetSqlFindAllHealth() string {
+	return "SELECT " + sqlHealthColumns + " FROM juno_health ORDER BY endpoint, seq"
+}
+
+func (p *SqliteProvider) GetSqlDeleteAllHealth() string {
+	return "DELETE FROM juno_health"
+}
+
+// GetSqlInsertHealth binds endpoint, seq, checked_at(unix millis), latency(nanoseconds) and error
+func (p *SqliteProvider) GetSqlInsertHealth() string {
+	return "INSERT INTO juno_health (" + sqlHealthColumns + ") VALUES (?, ?, ?, ?, ?)"
+}
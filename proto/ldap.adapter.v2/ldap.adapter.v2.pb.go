@@ -0,0 +1,1097 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v3.20.1
+// source: ldap.adapter.v2.proto
+
+package ldap_adapter_v2
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ResponseError_GrpcResponse int32
+
+const (
+	ResponseError_UNIVERSAL           ResponseError_GrpcResponse = 0
+	ResponseError_BAD_PARAMETER       ResponseError_GrpcResponse = 400
+	ResponseError_UNAUTORIZED         ResponseError_GrpcResponse = 401
+	ResponseError_FORBIDDEN           ResponseError_GrpcResponse = 403
+	ResponseError_NOT_FOUND           ResponseError_GrpcResponse = 404
+	ResponseError_NOT_ACCEPTABLE      ResponseError_GrpcResponse = 406
+	ResponseError_SERVER_ERROR        ResponseError_GrpcResponse = 500
+	ResponseError_SERVICE_UNAVAILABLE ResponseError_GrpcResponse = 503
+)
+
+// Enum value maps for ResponseError_GrpcResponse.
+var (
+	ResponseError_GrpcResponse_name = map[int32]string{
+		0:   "UNIVERSAL",
+		400: "BAD_PARAMETER",
+		401: "UNAUTORIZED",
+		403: "FORBIDDEN",
+		404: "NOT_FOUND",
+		406: "NOT_ACCEPTABLE",
+		500: "SERVER_ERROR",
+		503: "SERVICE_UNAVAILABLE",
+	}
+	ResponseError_GrpcResponse_value = map[string]int32{
+		"UNIVERSAL":           0,
+		"BAD_PARAMETER":       400,
+		"UNAUTORIZED":         401,
+		"FORBIDDEN":           403,
+		"NOT_FOUND":           404,
+		"NOT_ACCEPTABLE":      406,
+		"SERVER_ERROR":        500,
+		"SERVICE_UNAVAILABLE": 503,
+	}
+)
+
+func (x ResponseError_GrpcResponse) Enum() *ResponseError_GrpcResponse {
+	p := new(ResponseError_GrpcResponse)
+	*p = x
+	return p
+}
+
+func (x ResponseError_GrpcResponse) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ResponseError_GrpcResponse) Descriptor() protoreflect.EnumDescriptor {
+	return file_ldap_adapter_v2_proto_enumTypes[0].Descriptor()
+}
+
+func (ResponseError_GrpcResponse) Type() protoreflect.EnumType {
+	return &file_ldap_adapter_v2_proto_enumTypes[0]
+}
+
+func (x ResponseError_GrpcResponse) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ResponseError_GrpcResponse.Descriptor instead.
+func (ResponseError_GrpcResponse) EnumDescriptor() ([]byte, []int) {
+	return file_ldap_adapter_v2_proto_rawDescGZIP(), []int{11, 0}
+}
+
+type ResponseError_ErrorCode int32
+
+const (
+	ResponseError_SUCCESS        ResponseError_ErrorCode = 0
+	ResponseError_NO_RECORD      ResponseError_ErrorCode = 100
+	ResponseError_ERROR_RESPONSE ResponseError_ErrorCode = 101
+	ResponseError_CONNECT_FAIL   ResponseError_ErrorCode = 102
+	ResponseError_ERROR_ETC      ResponseError_ErrorCode = 103
+	ResponseError_DISABLED       ResponseError_ErrorCode = 104
+)
+
+// Enum value maps for ResponseError_ErrorCode.
+var (
+	ResponseError_ErrorCode_name = map[int32]string{
+		0:   "SUCCESS",
+		100: "NO_RECORD",
+		101: "ERROR_RESPONSE",
+		102: "CONNECT_FAIL",
+		103: "ERROR_ETC",
+		104: "DISABLED",
+	}
+	ResponseError_ErrorCode_value = map[string]int32{
+		"SUCCESS":        0,
+		"NO_RECORD":      100,
+		"ERROR_RESPONSE": 101,
+		"CONNECT_FAIL":   102,
+		"ERROR_ETC":      103,
+		"DISABLED":       104,
+	}
+)
+
+func (x ResponseError_ErrorCode) Enum() *ResponseError_ErrorCode {
+	p := new(ResponseError_ErrorCode)
+	*p = x
+	return p
+}
+
+func (x ResponseError_ErrorCode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ResponseError_ErrorCode) Descriptor() protoreflect.EnumDescriptor {
+	return file_ldap_adapter_v2_proto_enumTypes[1].Descriptor()
+}
+
+func (ResponseError_ErrorCode) Type() protoreflect.EnumType {
+	return &file_ldap_adapter_v2_proto_enumTypes[1]
+}
+
+func (x ResponseError_ErrorCode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ResponseError_ErrorCode.Descriptor instead.
+func (ResponseError_ErrorCode) EnumDescriptor() ([]byte, []int) {
+	return file_ldap_adapter_v2_proto_rawDescGZIP(), []int{11, 1}
+}
+
+type AuthenticateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuthenticateRequest) Reset() {
+	*x = AuthenticateRequest{}
+	mi := &file_ldap_adapter_v2_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuthenticateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthenticateRequest) ProtoMessage() {}
+
+func (x *AuthenticateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ldap_adapter_v2_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthenticateRequest.ProtoReflect.Descriptor instead.
+func (*AuthenticateRequest) Descriptor() ([]byte, []int) {
+	return file_ldap_adapter_v2_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AuthenticateRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *AuthenticateRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+type AuthenticateResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Response:
+	//
+	//	*AuthenticateResponse_Success
+	//	*AuthenticateResponse_Error
+	Response      isAuthenticateResponse_Response `protobuf_oneof:"response"`
+	Role          string                          `protobuf:"bytes,3,opt,name=role,proto3" json:"role,omitempty"`
+	User          *UserInfo                       `protobuf:"bytes,4,opt,name=user,proto3" json:"user,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuthenticateResponse) Reset() {
+	*x = AuthenticateResponse{}
+	mi := &file_ldap_adapter_v2_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuthenticateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthenticateResponse) ProtoMessage() {}
+
+func (x *AuthenticateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ldap_adapter_v2_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthenticateResponse.ProtoReflect.Descriptor instead.
+func (*AuthenticateResponse) Descriptor() ([]byte, []int) {
+	return file_ldap_adapter_v2_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AuthenticateResponse) GetResponse() isAuthenticateResponse_Response {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+func (x *AuthenticateResponse) GetSuccess() *ResponseSuccess {
+	if x != nil {
+		if x, ok := x.Response.(*AuthenticateResponse_Success); ok {
+			return x.Success
+		}
+	}
+	return nil
+}
+
+func (x *AuthenticateResponse) GetError() *ResponseError {
+	if x != nil {
+		if x, ok := x.Response.(*AuthenticateResponse_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+func (x *AuthenticateResponse) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *AuthenticateResponse) GetUser() *UserInfo {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+type isAuthenticateResponse_Response interface {
+	isAuthenticateResponse_Response()
+}
+
+type AuthenticateResponse_Success struct {
+	Success *ResponseSuccess `protobuf:"bytes,1,opt,name=success,proto3,oneof"`
+}
+
+type AuthenticateResponse_Error struct {
+	Error *ResponseError `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+}
+
+func (*AuthenticateResponse_Success) isAuthenticateResponse_Response() {}
+
+func (*AuthenticateResponse_Error) isAuthenticateResponse_Response() {}
+
+type LookupUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LookupUserRequest) Reset() {
+	*x = LookupUserRequest{}
+	mi := &file_ldap_adapter_v2_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LookupUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupUserRequest) ProtoMessage() {}
+
+func (x *LookupUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ldap_adapter_v2_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupUserRequest.ProtoReflect.Descriptor instead.
+func (*LookupUserRequest) Descriptor() ([]byte, []int) {
+	return file_ldap_adapter_v2_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *LookupUserRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type LookupUserResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Response:
+	//
+	//	*LookupUserResponse_Success
+	//	*LookupUserResponse_Error
+	Response      isLookupUserResponse_Response `protobuf_oneof:"response"`
+	User          *UserInfo                     `protobuf:"bytes,3,opt,name=user,proto3" json:"user,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LookupUserResponse) Reset() {
+	*x = LookupUserResponse{}
+	mi := &file_ldap_adapter_v2_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LookupUserResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupUserResponse) ProtoMessage() {}
+
+func (x *LookupUserResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ldap_adapter_v2_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupUserResponse.ProtoReflect.Descriptor instead.
+func (*LookupUserResponse) Descriptor() ([]byte, []int) {
+	return file_ldap_adapter_v2_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *LookupUserResponse) GetResponse() isLookupUserResponse_Response {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+func (x *LookupUserResponse) GetSuccess() *ResponseSuccess {
+	if x != nil {
+		if x, ok := x.Response.(*LookupUserResponse_Success); ok {
+			return x.Success
+		}
+	}
+	return nil
+}
+
+func (x *LookupUserResponse) GetError() *ResponseError {
+	if x != nil {
+		if x, ok := x.Response.(*LookupUserResponse_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+func (x *LookupUserResponse) GetUser() *UserInfo {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+type isLookupUserResponse_Response interface {
+	isLookupUserResponse_Response()
+}
+
+type LookupUserResponse_Success struct {
+	Success *ResponseSuccess `protobuf:"bytes,1,opt,name=success,proto3,oneof"`
+}
+
+type LookupUserResponse_Error struct {
+	Error *ResponseError `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+}
+
+func (*LookupUserResponse_Success) isLookupUserResponse_Response() {}
+
+func (*LookupUserResponse_Error) isLookupUserResponse_Response() {}
+
+type ListGroupsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prefix        string                 `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListGroupsRequest) Reset() {
+	*x = ListGroupsRequest{}
+	mi := &file_ldap_adapter_v2_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListGroupsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListGroupsRequest) ProtoMessage() {}
+
+func (x *ListGroupsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ldap_adapter_v2_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListGroupsRequest.ProtoReflect.Descriptor instead.
+func (*ListGroupsRequest) Descriptor() ([]byte, []int) {
+	return file_ldap_adapter_v2_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListGroupsRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+type ListGroupsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Response:
+	//
+	//	*ListGroupsResponse_Success
+	//	*ListGroupsResponse_Error
+	Response      isListGroupsResponse_Response `protobuf_oneof:"response"`
+	Groups        []*GroupInfo                  `protobuf:"bytes,3,rep,name=groups,proto3" json:"groups,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListGroupsResponse) Reset() {
+	*x = ListGroupsResponse{}
+	mi := &file_ldap_adapter_v2_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListGroupsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListGroupsResponse) ProtoMessage() {}
+
+func (x *ListGroupsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ldap_adapter_v2_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListGroupsResponse.ProtoReflect.Descriptor instead.
+func (*ListGroupsResponse) Descriptor() ([]byte, []int) {
+	return file_ldap_adapter_v2_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListGroupsResponse) GetResponse() isListGroupsResponse_Response {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+func (x *ListGroupsResponse) GetSuccess() *ResponseSuccess {
+	if x != nil {
+		if x, ok := x.Response.(*ListGroupsResponse_Success); ok {
+			return x.Success
+		}
+	}
+	return nil
+}
+
+func (x *ListGroupsResponse) GetError() *ResponseError {
+	if x != nil {
+		if x, ok := x.Response.(*ListGroupsResponse_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+func (x *ListGroupsResponse) GetGroups() []*GroupInfo {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+type isListGroupsResponse_Response interface {
+	isListGroupsResponse_Response()
+}
+
+type ListGroupsResponse_Success struct {
+	Success *ResponseSuccess `protobuf:"bytes,1,opt,name=success,proto3,oneof"`
+}
+
+type ListGroupsResponse_Error struct {
+	Error *ResponseError `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+}
+
+func (*ListGroupsResponse_Success) isListGroupsResponse_Response() {}
+
+func (*ListGroupsResponse_Error) isListGroupsResponse_Response() {}
+
+type ValidateUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidateUserRequest) Reset() {
+	*x = ValidateUserRequest{}
+	mi := &file_ldap_adapter_v2_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateUserRequest) ProtoMessage() {}
+
+func (x *ValidateUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ldap_adapter_v2_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateUserRequest.ProtoReflect.Descriptor instead.
+func (*ValidateUserRequest) Descriptor() ([]byte, []int) {
+	return file_ldap_adapter_v2_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ValidateUserRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ValidateUserResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Response:
+	//
+	//	*ValidateUserResponse_Success
+	//	*ValidateUserResponse_Error
+	Response      isValidateUserResponse_Response `protobuf_oneof:"response"`
+	Role          string                          `protobuf:"bytes,3,opt,name=role,proto3" json:"role,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidateUserResponse) Reset() {
+	*x = ValidateUserResponse{}
+	mi := &file_ldap_adapter_v2_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateUserResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateUserResponse) ProtoMessage() {}
+
+func (x *ValidateUserResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ldap_adapter_v2_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateUserResponse.ProtoReflect.Descriptor instead.
+func (*ValidateUserResponse) Descriptor() ([]byte, []int) {
+	return file_ldap_adapter_v2_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ValidateUserResponse) GetResponse() isValidateUserResponse_Response {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+func (x *ValidateUserResponse) GetSuccess() *ResponseSuccess {
+	if x != nil {
+		if x, ok := x.Response.(*ValidateUserResponse_Success); ok {
+			return x.Success
+		}
+	}
+	return nil
+}
+
+func (x *ValidateUserResponse) GetError() *ResponseError {
+	if x != nil {
+		if x, ok := x.Response.(*ValidateUserResponse_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+func (x *ValidateUserResponse) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+type isValidateUserResponse_Response interface {
+	isValidateUserResponse_Response()
+}
+
+type ValidateUserResponse_Success struct {
+	Success *ResponseSuccess `protobuf:"bytes,1,opt,name=success,proto3,oneof"`
+}
+
+type ValidateUserResponse_Error struct {
+	Error *ResponseError `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+}
+
+func (*ValidateUserResponse_Success) isValidateUserResponse_Response() {}
+
+func (*ValidateUserResponse_Error) isValidateUserResponse_Response() {}
+
+type UserInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	DisplayName   string                 `protobuf:"bytes,2,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	Email         string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	Groups        []string               `protobuf:"bytes,4,rep,name=groups,proto3" json:"groups,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserInfo) Reset() {
+	*x = UserInfo{}
+	mi := &file_ldap_adapter_v2_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserInfo) ProtoMessage() {}
+
+func (x *UserInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_ldap_adapter_v2_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserInfo.ProtoReflect.Descriptor instead.
+func (*UserInfo) Descriptor() ([]byte, []int) {
+	return file_ldap_adapter_v2_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UserInfo) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UserInfo) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+func (x *UserInfo) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *UserInfo) GetGroups() []string {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+type GroupInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GroupInfo) Reset() {
+	*x = GroupInfo{}
+	mi := &file_ldap_adapter_v2_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GroupInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GroupInfo) ProtoMessage() {}
+
+func (x *GroupInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_ldap_adapter_v2_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GroupInfo.ProtoReflect.Descriptor instead.
+func (*GroupInfo) Descriptor() ([]byte, []int) {
+	return file_ldap_adapter_v2_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GroupInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *GroupInfo) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type ResponseSuccess struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResponseSuccess) Reset() {
+	*x = ResponseSuccess{}
+	mi := &file_ldap_adapter_v2_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResponseSuccess) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResponseSuccess) ProtoMessage() {}
+
+func (x *ResponseSuccess) ProtoReflect() protoreflect.Message {
+	mi := &file_ldap_adapter_v2_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResponseSuccess.ProtoReflect.Descriptor instead.
+func (*ResponseSuccess) Descriptor() ([]byte, []int) {
+	return file_ldap_adapter_v2_proto_rawDescGZIP(), []int{10}
+}
+
+type ResponseError struct {
+	state         protoimpl.MessageState     `protogen:"open.v1"`
+	GrpcResponse  ResponseError_GrpcResponse `protobuf:"varint,1,opt,name=grpcResponse,proto3,enum=ldap.adapter.v2.ResponseError_GrpcResponse" json:"grpcResponse,omitempty"`
+	Code          ResponseError_ErrorCode    `protobuf:"varint,2,opt,name=code,proto3,enum=ldap.adapter.v2.ResponseError_ErrorCode" json:"code,omitempty"`
+	Value         string                     `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	Desc          string                     `protobuf:"bytes,4,opt,name=desc,proto3" json:"desc,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResponseError) Reset() {
+	*x = ResponseError{}
+	mi := &file_ldap_adapter_v2_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResponseError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResponseError) ProtoMessage() {}
+
+func (x *ResponseError) ProtoReflect() protoreflect.Message {
+	mi := &file_ldap_adapter_v2_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResponseError.ProtoReflect.Descriptor instead.
+func (*ResponseError) Descriptor() ([]byte, []int) {
+	return file_ldap_adapter_v2_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ResponseError) GetGrpcResponse() ResponseError_GrpcResponse {
+	if x != nil {
+		return x.GrpcResponse
+	}
+	return ResponseError_UNIVERSAL
+}
+
+func (x *ResponseError) GetCode() ResponseError_ErrorCode {
+	if x != nil {
+		return x.Code
+	}
+	return ResponseError_SUCCESS
+}
+
+func (x *ResponseError) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *ResponseError) GetDesc() string {
+	if x != nil {
+		return x.Desc
+	}
+	return ""
+}
+
+var File_ldap_adapter_v2_proto protoreflect.FileDescriptor
+
+const file_ldap_adapter_v2_proto_rawDesc = "" +
+	"\n" +
+	"\x15ldap.adapter.v2.proto\x12\x0fldap.adapter.v2\"A\n" +
+	"\x13AuthenticateRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\"\xdb\x01\n" +
+	"\x14AuthenticateResponse\x12<\n" +
+	"\asuccess\x18\x01 \x01(\v2 .ldap.adapter.v2.ResponseSuccessH\x00R\asuccess\x126\n" +
+	"\x05error\x18\x02 \x01(\v2\x1e.ldap.adapter.v2.ResponseErrorH\x00R\x05error\x12\x12\n" +
+	"\x04role\x18\x03 \x01(\tR\x04role\x12-\n" +
+	"\x04user\x18\x04 \x01(\v2\x19.ldap.adapter.v2.UserInfoR\x04userB\n" +
+	"\n" +
+	"\bresponse\"#\n" +
+	"\x11LookupUserRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xc5\x01\n" +
+	"\x12LookupUserResponse\x12<\n" +
+	"\asuccess\x18\x01 \x01(\v2 .ldap.adapter.v2.ResponseSuccessH\x00R\asuccess\x126\n" +
+	"\x05error\x18\x02 \x01(\v2\x1e.ldap.adapter.v2.ResponseErrorH\x00R\x05error\x12-\n" +
+	"\x04user\x18\x03 \x01(\v2\x19.ldap.adapter.v2.UserInfoR\x04userB\n" +
+	"\n" +
+	"\bresponse\"+\n" +
+	"\x11ListGroupsRequest\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\"\xca\x01\n" +
+	"\x12ListGroupsResponse\x12<\n" +
+	"\asuccess\x18\x01 \x01(\v2 .ldap.adapter.v2.ResponseSuccessH\x00R\asuccess\x126\n" +
+	"\x05error\x18\x02 \x01(\v2\x1e.ldap.adapter.v2.ResponseErrorH\x00R\x05error\x122\n" +
+	"\x06groups\x18\x03 \x03(\v2\x1a.ldap.adapter.v2.GroupInfoR\x06groupsB\n" +
+	"\n" +
+	"\bresponse\"%\n" +
+	"\x13ValidateUserRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xac\x01\n" +
+	"\x14ValidateUserResponse\x12<\n" +
+	"\asuccess\x18\x01 \x01(\v2 .ldap.adapter.v2.ResponseSuccessH\x00R\asuccess\x126\n" +
+	"\x05error\x18\x02 \x01(\v2\x1e.ldap.adapter.v2.ResponseErrorH\x00R\x05error\x12\x12\n" +
+	"\x04role\x18\x03 \x01(\tR\x04roleB\n" +
+	"\n" +
+	"\bresponse\"k\n" +
+	"\bUserInfo\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12!\n" +
+	"\fdisplay_name\x18\x02 \x01(\tR\vdisplayName\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\x12\x16\n" +
+	"\x06groups\x18\x04 \x03(\tR\x06groups\"A\n" +
+	"\tGroupInfo\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\"\x11\n" +
+	"\x0fResponseSuccess\"\xdc\x03\n" +
+	"\rResponseError\x12O\n" +
+	"\fgrpcResponse\x18\x01 \x01(\x0e2+.ldap.adapter.v2.ResponseError.GrpcResponseR\fgrpcResponse\x12<\n" +
+	"\x04code\x18\x02 \x01(\x0e2(.ldap.adapter.v2.ResponseError.ErrorCodeR\x04code\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05value\x12\x12\n" +
+	"\x04desc\x18\x04 \x01(\tR\x04desc\"\xa5\x01\n" +
+	"\fGrpcResponse\x12\r\n" +
+	"\tUNIVERSAL\x10\x00\x12\x12\n" +
+	"\rBAD_PARAMETER\x10\x90\x03\x12\x10\n" +
+	"\vUNAUTORIZED\x10\x91\x03\x12\x0e\n" +
+	"\tFORBIDDEN\x10\x93\x03\x12\x0e\n" +
+	"\tNOT_FOUND\x10\x94\x03\x12\x13\n" +
+	"\x0eNOT_ACCEPTABLE\x10\x96\x03\x12\x11\n" +
+	"\fSERVER_ERROR\x10\xf4\x03\x12\x18\n" +
+	"\x13SERVICE_UNAVAILABLE\x10\xf7\x03\"j\n" +
+	"\tErrorCode\x12\v\n" +
+	"\aSUCCESS\x10\x00\x12\r\n" +
+	"\tNO_RECORD\x10d\x12\x12\n" +
+	"\x0eERROR_RESPONSE\x10e\x12\x10\n" +
+	"\fCONNECT_FAIL\x10f\x12\r\n" +
+	"\tERROR_ETC\x10g\x12\f\n" +
+	"\bDISABLED\x10h2\x84\x03\n" +
+	"\x12LdapAdapterService\x12]\n" +
+	"\fAuthenticate\x12$.ldap.adapter.v2.AuthenticateRequest\x1a%.ldap.adapter.v2.AuthenticateResponse\"\x00\x12W\n" +
+	"\n" +
+	"LookupUser\x12\".ldap.adapter.v2.LookupUserRequest\x1a#.ldap.adapter.v2.LookupUserResponse\"\x00\x12W\n" +
+	"\n" +
+	"ListGroups\x12\".ldap.adapter.v2.ListGroupsRequest\x1a#.ldap.adapter.v2.ListGroupsResponse\"\x00\x12]\n" +
+	"\fValidateUser\x12$.ldap.adapter.v2.ValidateUserRequest\x1a%.ldap.adapter.v2.ValidateUserResponse\"\x00B\x13Z\x11.;ldap_adapter_v2b\x06proto3"
+
+var (
+	file_ldap_adapter_v2_proto_rawDescOnce sync.Once
+	file_ldap_adapter_v2_proto_rawDescData []byte
+)
+
+func file_ldap_adapter_v2_proto_rawDescGZIP() []byte {
+	file_ldap_adapter_v2_proto_rawDescOnce.Do(func() {
+		file_ldap_adapter_v2_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_ldap_adapter_v2_proto_rawDesc), len(file_ldap_adapter_v2_proto_rawDesc)))
+	})
+	return file_ldap_adapter_v2_proto_rawDescData
+}
+
+var file_ldap_adapter_v2_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_ldap_adapter_v2_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_ldap_adapter_v2_proto_goTypes = []any{
+	(ResponseError_GrpcResponse)(0), // 0: ldap.adapter.v2.ResponseError.GrpcResponse
+	(ResponseError_ErrorCode)(0),    // 1: ldap.adapter.v2.ResponseError.ErrorCode
+	(*AuthenticateRequest)(nil),     // 2: ldap.adapter.v2.AuthenticateRequest
+	(*AuthenticateResponse)(nil),    // 3: ldap.adapter.v2.AuthenticateResponse
+	(*LookupUserRequest)(nil),       // 4: ldap.adapter.v2.LookupUserRequest
+	(*LookupUserResponse)(nil),      // 5: ldap.adapter.v2.LookupUserResponse
+	(*ListGroupsRequest)(nil),       // 6: ldap.adapter.v2.ListGroupsRequest
+	(*ListGroupsResponse)(nil),      // 7: ldap.adapter.v2.ListGroupsResponse
+	(*ValidateUserRequest)(nil),     // 8: ldap.adapter.v2.ValidateUserRequest
+	(*ValidateUserResponse)(nil),    // 9: ldap.adapter.v2.ValidateUserResponse
+	(*UserInfo)(nil),                // 10: ldap.adapter.v2.UserInfo
+	(*GroupInfo)(nil),               // 11: ldap.adapter.v2.GroupInfo
+	(*ResponseSuccess)(nil),         // 12: ldap.adapter.v2.ResponseSuccess
+	(*ResponseError)(nil),           // 13: ldap.adapter.v2.ResponseError
+}
+var file_ldap_adapter_v2_proto_depIdxs = []int32{
+	12, // 0: ldap.adapter.v2.AuthenticateResponse.success:type_name -> ldap.adapter.v2.ResponseSuccess
+	13, // 1: ldap.adapter.v2.AuthenticateResponse.error:type_name -> ldap.adapter.v2.ResponseError
+	10, // 2: ldap.adapter.v2.AuthenticateResponse.user:type_name -> ldap.adapter.v2.UserInfo
+	12, // 3: ldap.adapter.v2.LookupUserResponse.success:type_name -> ldap.adapter.v2.ResponseSuccess
+	13, // 4: ldap.adapter.v2.LookupUserResponse.error:type_name -> ldap.adapter.v2.ResponseError
+	10, // 5: ldap.adapter.v2.LookupUserResponse.user:type_name -> ldap.adapter.v2.UserInfo
+	12, // 6: ldap.adapter.v2.ListGroupsResponse.success:type_name -> ldap.adapter.v2.ResponseSuccess
+	13, // 7: ldap.adapter.v2.ListGroupsResponse.error:type_name -> ldap.adapter.v2.ResponseError
+	11, // 8: ldap.adapter.v2.ListGroupsResponse.groups:type_name -> ldap.adapter.v2.GroupInfo
+	12, // 9: ldap.adapter.v2.ValidateUserResponse.success:type_name -> ldap.adapter.v2.ResponseSuccess
+	13, // 10: ldap.adapter.v2.ValidateUserResponse.error:type_name -> ldap.adapter.v2.ResponseError
+	0,  // 11: ldap.adapter.v2.ResponseError.grpcResponse:type_name -> ldap.adapter.v2.ResponseError.GrpcResponse
+	1,  // 12: ldap.adapter.v2.ResponseError.code:type_name -> ldap.adapter.v2.ResponseError.ErrorCode
+	2,  // 13: ldap.adapter.v2.LdapAdapterService.Authenticate:input_type -> ldap.adapter.v2.AuthenticateRequest
+	4,  // 14: ldap.adapter.v2.LdapAdapterService.LookupUser:input_type -> ldap.adapter.v2.LookupUserRequest
+	6,  // 15: ldap.adapter.v2.LdapAdapterService.ListGroups:input_type -> ldap.adapter.v2.ListGroupsRequest
+	8,  // 16: ldap.adapter.v2.LdapAdapterService.ValidateUser:input_type -> ldap.adapter.v2.ValidateUserRequest
+	3,  // 17: ldap.adapter.v2.LdapAdapterService.Authenticate:output_type -> ldap.adapter.v2.AuthenticateResponse
+	5,  // 18: ldap.adapter.v2.LdapAdapterService.LookupUser:output_type -> ldap.adapter.v2.LookupUserResponse
+	7,  // 19: ldap.adapter.v2.LdapAdapterService.ListGroups:output_type -> ldap.adapter.v2.ListGroupsResponse
+	9,  // 20: ldap.adapter.v2.LdapAdapterService.ValidateUser:output_type -> ldap.adapter.v2.ValidateUserResponse
+	17, // [17:21] is the sub-list for method output_type
+	13, // [13:17] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_ldap_adapter_v2_proto_init() }
+func file_ldap_adapter_v2_proto_init() {
+	if File_ldap_adapter_v2_proto != nil {
+		return
+	}
+	file_ldap_adapter_v2_proto_msgTypes[1].OneofWrappers = []any{
+		(*AuthenticateResponse_Success)(nil),
+		(*AuthenticateResponse_Error)(nil),
+	}
+	file_ldap_adapter_v2_proto_msgTypes[3].OneofWrappers = []any{
+		(*LookupUserResponse_Success)(nil),
+		(*LookupUserResponse_Error)(nil),
+	}
+	file_ldap_adapter_v2_proto_msgTypes[5].OneofWrappers = []any{
+		(*ListGroupsResponse_Success)(nil),
+		(*ListGroupsResponse_Error)(nil),
+	}
+	file_ldap_adapter_v2_proto_msgTypes[7].OneofWrappers = []any{
+		(*ValidateUserResponse_Success)(nil),
+		(*ValidateUserResponse_Error)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ldap_adapter_v2_proto_rawDesc), len(file_ldap_adapter_v2_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_ldap_adapter_v2_proto_goTypes,
+		DependencyIndexes: file_ldap_adapter_v2_proto_depIdxs,
+		EnumInfos:         file_ldap_adapter_v2_proto_enumTypes,
+		MessageInfos:      file_ldap_adapter_v2_proto_msgTypes,
+	}.Build()
+	File_ldap_adapter_v2_proto = out.File
+	file_ldap_adapter_v2_proto_goTypes = nil
+	file_ldap_adapter_v2_proto_depIdxs = nil
+}
@@ -0,0 +1,121 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 9:20
+ */
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/infra"
+	"regexp"
+	"time"
+)
+
+const (
+	apiKeyLength = 48
+)
+
+var apiKeyNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,64}$`)
+
+// CreateApiKey issues new api key. returned key is shown only once, repository keeps its digest.
+// empty scope means role on every group, zero expireAt means no expiry
+func (interactor *DomainInteractor) CreateApiKey(caller domain.Session, name string, role domain.Role, scope string, expireAt time.Time) (string, error) {
+	if !apiKeyNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid api key name %s", name)
+	}
+	if role == domain.ROLE_UNKNOWN {
+		return "", errors.New("unknown role")
+	}
+	if !expireAt.IsZero() && expireAt.Before(time.Now()) {
+		return "", errors.New("expire time is past")
+	}
+	if _, ok := interactor.apiKeyRepository.FindByName(name); ok {
+		return "", fmt.Errorf("api key %s already exists", name)
+	}
+
+	secret := domain.API_KEY_PREFIX + infra.GenerateSecret(apiKeyLength)
+	key := domain.ApiKey{
+		Name:      name,
+		Digest:    domain.DigestApiKey(secret),
+		Role:      role,
+		Scope:     scope,
+		CreatedBy: caller.UserId,
+		CreatedAt: time.Now(),
+		ExpireAt:  expireAt,
+	}
+	err := interactor.apiKeyRepository.Save(key)
+	if err != nil {
+		return "", err
+	}
+
+	log.Info("api key %s created by %s. role=%s, scope=%s", name, caller.UserId, role, scope)
+	return secret, nil
+}
+
+// FindAllApiKeys returns api keys without digest
+func (interactor *DomainInteractor) FindAllApiKeys() []domain.ApiKey {
+	list := interactor.apiKeyRepository.FindAll()
+	for i := range list {
+		list[i].Digest = ""
+	}
+	return list
+}
+
+func (interactor *DomainInteractor) RevokeApiKey(name string) error {
+	deleted, err := interactor.apiKeyRepository.Delete(name)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("not found api key %s", name)
+	}
+	log.Info("api key %s revoked", name)
+	return nil
+}
+
+// ValidateApiKey returns session of api key and records its usage
+func (interactor *DomainInteractor) ValidateApiKey(key string, role domain.Role, clientAddress string) (domain.Session, error) {
+	found, ok := interactor.apiKeyRepository.FindByDigest(domain.DigestApiKey(key))
+	if !ok {
+		return domain.Session{}, errors.New("not found api key")
+	}
+
+	if found.IsExpired() {
+		return domain.Session{}, fmt.Errorf("api key %s is expired", found.Name)
+	}
+
+	if err := interactor.validateApiKeyOwner(found); err != nil {
+		return domain.Session{}, err
+	}
+
+	session := found.AsSession()
+	if !session.Grant().HasRole(role) {
+		return domain.Session{}, fmt.Errorf("insufficient previledge of api key %s", found.Name)
+	}
+
+	interactor.apiKeyRepository.Touch(found.Name, time.Now(), infra.ExtractIpAddress(clientAddress))
+	session.ClientAddress = clientAddress
+	return session, nil
+}
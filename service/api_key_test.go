@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 5:40
+ */
+package service
+
+import (
+	"github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/infra"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateApiKey(t *testing.T) {
+	apiKeyFilePath := filepath.Join(t.TempDir(), infra.API_KEY_DATA_FILE)
+	interactor := &DomainInteractor{apiKeyRepository: infra.NewFileApiKeyRepositoryWithPath(apiKeyFilePath)}
+	caller := domain.Session{UserId: "admin", Role: domain.ROLE_OPERATOR}
+
+	ci, err := interactor.CreateApiKey(caller, "ci-deploy", domain.ROLE_OPERATOR, "payment", time.Time{})
+	if err != nil {
+		t.Fatalf("fail to create api key : %s", err.Error())
+	}
+	monitor, _ := interactor.CreateApiKey(caller, "ci-monitor", domain.ROLE_MONITOR, "", time.Now().Add(time.Hour))
+	expiring, _ := interactor.CreateApiKey(caller, "ci-expiring", domain.ROLE_MONITOR, "", time.Now().Add(time.Hour))
+	expired, _ := interactor.apiKeyRepository.FindByName("ci-expiring")
+	expired.ExpireAt = time.Now().Add(-time.Second)
+	interactor.apiKeyRepository.Save(expired)
+
+	if !strings.HasPrefix(ci, domain.API_KEY_PREFIX) {
+		t.Fatalf("api key without prefix : %s", ci)
+	}
+	content, _ := os.ReadFile(apiKeyFilePath)
+	if strings.Contains(string(content), ci) || !strings.Contains(string(content), domain.DigestApiKey(ci)) {
+		t.Fatalf("api key file should keep only digest")
+	}
+
+	// repository is reloaded like restart
+	interactor.apiKeyRepository = infra.NewFileApiKeyRepositoryWithPath(apiKeyFilePath)
+
+	cases := []struct {
+		name  string
+		key   string
+		role  domain.Role
+		valid bool
+	}{
+		{"operator key", ci, domain.ROLE_OPERATOR, true},
+		{"monitor key", monitor, domain.ROLE_MONITOR, true},
+		{"monitor key for operator", monitor, domain.ROLE_OPERATOR, false},
+		{"expired key", expiring, domain.ROLE_MONITOR, false},
+		{"changed key", ci[:len(ci)-1] + "x", domain.ROLE_MONITOR, false},
+		{"digest as key", domain.DigestApiKey(ci), domain.ROLE_MONITOR, false},
+	}
+	for _, c := range cases {
+		if _, err = interactor.ValidateApiKey(c.key, c.role, "10.0.0.1:5000"); (err == nil) != c.valid {
+			t.Fatalf("%s : valid %t", c.name, err == nil)
+		}
+	}
+
+	session, _ := interactor.ValidateApiKey(ci, domain.ROLE_OPERATOR, "10.0.0.1:5000")
+	if !session.Grant().PermitsGroup("payment", domain.ROLE_OPERATOR) || session.Grant().PermitsGroup("billing", domain.ROLE_MONITOR) {
+		t.Fatalf("scope of api key is not applied : %v", session.Bindings)
+	}
+	used, _ := interactor.apiKeyRepository.FindByName("ci-deploy")
+	if used.LastUsedAt.IsZero() || used.LastUsedAddress != "10.0.0.1" {
+		t.Fatalf("usage of api key is not recorded : %v", used)
+	}
+
+	if err = interactor.RevokeApiKey("ci-deploy"); err != nil {
+		t.Fatalf("fail to revoke api key : %s", err.Error())
+	}
+	if _, err = interactor.ValidateApiKey(ci, domain.ROLE_MONITOR, "10.0.0.1:5000"); err == nil {
+		t.Fatalf("revoked api key is accepted")
+	}
+	if err = interactor.RevokeApiKey("ci-deploy"); err == nil {
+		t.Fatalf("revoked api key is revoked again")
+	}
+}
+
+func TestCreateApiKeyInvalid(t *testing.T) {
+	interactor := &DomainInteractor{apiKeyRepository: infra.NewFileApiKeyRepositoryWithPath(filepath.Join(t.TempDir(), infra.API_KEY_DATA_FILE))}
+	caller := domain.Session{UserId: "admin", Role: domain.ROLE_OPERATOR}
+	interactor.CreateApiKey(caller, "ci-deploy", domain.ROLE_OPERATOR, "", time.Time{})
+
+	cases := []struct {
+		name     string
+		key      string
+		role     domain.Role
+		expireAt time.Time
+	}{
+		{"short name", "ci", domain.ROLE_MONITOR, time.Time{}},
+		{"name with space", "ci deploy", domain.ROLE_MONITOR, time.Time{}},
+		{"unknown role", "ci-other", domain.ROLE_UNKNOWN, time.Time{}},
+		{"past expiry", "ci-other", domain.ROLE_MONITOR, time.Now().Add(-time.Minute)},
+		{"existing name", "ci-deploy", domain.ROLE_MONITOR, time.Time{}},
+	}
+	for _, c := range cases {
+		if _, err := interactor.CreateApiKey(caller, c.key, c.role, "", c.expireAt); err == nil {
+			t.Fatalf("%s : api key is created", c.name)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 19. 오후 6:00
+ */
+package service
+
+import (
+	"github.com/fatima-go/fatima-core"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/infra"
+	"time"
+)
+
+// audit.retention.days=90
+const (
+	propAuditRetentionDays    = "audit.retention.days"
+	defaultAuditRetentionDays = 90
+)
+
+func newAuditRepository(fatimaRuntime fatima.FatimaRuntime) (domain.AuditRepository, error) {
+	days, err := fatimaRuntime.GetConfig().GetInt(propAuditRetentionDays)
+	if err != nil {
+		days = defaultAuditRetentionDays
+	}
+
+	log.Info("audit log retention : %d days", days)
+	return infra.NewFileAuditRepository(fatimaRuntime, days)
+}
+
+// RecordAudit appends event to audit trail. failure is logged only, it never stops the action
+func (interactor *DomainInteractor) RecordAudit(event domain.AuditEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if len(event.Result) == 0 {
+		event.Result = domain.AUDIT_RESULT_SUCCESS
+	}
+	event.ClientAddress = infra.ExtractIpAddress(event.ClientAddress)
+
+	if err := interactor.auditRepository.Append(event); err != nil {
+		log.Error("fail to write audit %s of %s : %s", event.Action, event.UserId, err.Error())
+	}
+}
+
+func (interactor *DomainInteractor) FindAuditEvents(filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	return interactor.auditRepository.Find(filter)
+}
+
+// auditAction records result of action performed by caller
+func (interactor *DomainInteractor) auditAction(caller domain.Session, clientAddress string, action string, target string, err error) {
+	event := domain.AuditEvent{
+		UserId:        caller.UserId,
+		Role:          caller.Role.String(),
+		ClientAddress: clientAddress,
+		Action:        action,
+		Target:        target,
+	}
+	if err != nil {
+		event.Result = domain.AUDIT_RESULT_FAIL
+		event.Message = err.Error()
+	}
+	interactor.RecordAudit(event)
+}
@@ -0,0 +1,73 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 7:55
+ */
+package service
+
+import (
+	"errors"
+	"github.com/fatima-go/jupiter/domain"
+	"testing"
+)
+
+// testAuditRepository keeps appended events in memory
+type testAuditRepository struct {
+	events []domain.AuditEvent
+}
+
+func (r *testAuditRepository) Append(event domain.AuditEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *testAuditRepository) Find(filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	return r.events, nil
+}
+
+func TestAuditAction(t *testing.T) {
+	repo := &testAuditRepository{}
+	interactor := &DomainInteractor{auditRepository: repo}
+	caller := domain.Session{UserId: "alice", Role: domain.ROLE_OPERATOR}
+
+	cases := []struct {
+		name    string
+		err     error
+		result  string
+		message string
+	}{
+		{"success", nil, domain.AUDIT_RESULT_SUCCESS, ""},
+		{"fail", errors.New("no such package"), domain.AUDIT_RESULT_FAIL, "no such package"},
+	}
+
+	for _, c := range cases {
+		interactor.auditAction(caller, "10.0.0.1:51234", "deploy", "host:pkg", c.err)
+		event := repo.events[len(repo.events)-1]
+		if event.Result != c.result || event.Message != c.message {
+			t.Fatalf("%s : result %s, message %s", c.name, event.Result, event.Message)
+		}
+		if event.Time.IsZero() {
+			t.Fatalf("%s : time is not set", c.name)
+		}
+		if event.UserId != "alice" || event.Role != "OPERATOR" || event.ClientAddress != "10.0.0.1" {
+			t.Fatalf("%s : event %+v", c.name, event)
+		}
+	}
+}
@@ -29,45 +29,86 @@ import (
 	"github.com/fatima-go/fatima-core"
 	"github.com/fatima-go/fatima-log"
 	. "github.com/fatima-go/jupiter/domain"
-	"github.com/fatima-go/jupiter/infra"
 	"strings"
+	"sync"
 )
 
-func NewBasicAuthenticator(fatimaRuntime fatima.FatimaRuntime) (Authenticate, error) {
+// NewBasicAuthenticator creates authenticator on userRepository.
+// userLock is the lock which serializes user changes. it guards password hash upgrade
+func NewBasicAuthenticator(fatimaRuntime fatima.FatimaRuntime, userRepository UserRepository, encdec Encdec, userLock sync.Locker) (Authenticate, error) {
 	log.Info("creating BasicAuthenticator")
 	auth := &BasicAuthenticator{}
 
-	auth.encdec = infra.NewDefaultEncdec()
-	userRepository := infra.NewMemoryUserRepository()
+	auth.encdec = encdec
+	auth.userRepository = userRepository
+	auth.userLock = userLock
 
-	repo, ok := fatimaRuntime.GetConfig().GetValue("repo")
-	if ok {
-		switch strings.ToLower(repo) {
-		case "file":
-			userRepository = infra.NewFileUserRepository(fatimaRuntime)
+	if ids, ok := fatimaRuntime.GetConfig().GetValue(propAuthBasicAllowIds); ok && len(strings.TrimSpace(ids)) > 0 {
+		auth.allowIds = make(map[string]bool)
+		for _, id := range strings.Split(ids, ",") {
+			auth.allowIds[strings.TrimSpace(id)] = true
 		}
+		log.Info("BasicAuthenticator allows only %s", ids)
 	}
-
-	auth.userRepository = userRepository
 	return auth, nil
 }
 
+// auth.basic.allow.ids=admin,breakglass
+const (
+	propAuthBasicAllowIds = "auth.basic.allow.ids"
+)
+
 type BasicAuthenticator struct {
 	userRepository UserRepository
 	encdec         Encdec
+	userLock       sync.Locker
+	allowIds       map[string]bool
 	Authenticate
 }
 
+// UserAuthenticate answers ErrNotMine for unknown user or user not in auth.basic.allow.ids
 func (b *BasicAuthenticator) UserAuthenticate(id, password string) (Role, error) {
+	if b.allowIds != nil && !b.allowIds[id] {
+		return ROLE_UNKNOWN, fmt.Errorf("%w : user %s is not allowed for basic", ErrNotMine, id)
+	}
+
 	found := b.userRepository.FindById(id)
 	if found == nil {
-		return ROLE_UNKNOWN, errors.New("not found user")
+		return ROLE_UNKNOWN, fmt.Errorf("%w : not found user %s", ErrNotMine, id)
 	}
 
-	if b.encdec.Hash(found.Password) != b.encdec.Hash(password) {
-		//if found.Password != interactor.Encdec.Hash(user.Password) {
+	if !b.encdec.Verify(password, found.Password) {
 		return ROLE_UNKNOWN, errors.New(fmt.Sprintf("missmatch password for user %s", id))
 	}
 
+	if b.encdec.NeedRehash(found.Password) {
+		b.upgradePassword(id, found.Password, password)
+	}
+
 	return found.Role, nil
 }
+
+// upgradePassword replaces legacy(plain text or another algorithm) password with current hash.
+// user is read again under user lock and only password is changed, when it is still the verified one
+func (b *BasicAuthenticator) upgradePassword(id string, verified string, password string) {
+	hashed := b.encdec.Hash(password)
+	if len(hashed) == 0 {
+		return
+	}
+
+	b.userLock.Lock()
+	defer b.userLock.Unlock()
+
+	user := b.userRepository.FindById(id)
+	if user == nil || user.Password != verified {
+		return
+	}
+
+	user.Password = hashed
+	err := b.userRepository.Save(*user)
+	if err != nil {
+		log.Warn("fail to upgrade password hash of user %s : %s", id, err.Error())
+		return
+	}
+	log.Info("password hash of user %s upgraded", id)
+}
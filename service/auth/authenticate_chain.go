@@ -0,0 +1,90 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 10:50
+ */
+
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fatima-go/fatima-log"
+	. "github.com/fatima-go/jupiter/domain"
+	"strings"
+)
+
+// NewChainAuthenticator creates authenticator which asks each authenticator in order (e.g. auth=ldap,basic).
+// authenticator answering ErrNotMine passes the attempt on to next one, any other error stops the chain
+func NewChainAuthenticator() *ChainAuthenticator {
+	return &ChainAuthenticator{steps: make([]chainStep, 0)}
+}
+
+type chainStep struct {
+	name          string
+	authenticator Authenticate
+}
+
+type ChainAuthenticator struct {
+	steps []chainStep
+	Authenticate
+}
+
+func (c *ChainAuthenticator) Append(name string, authenticator Authenticate) {
+	c.steps = append(c.steps, chainStep{name: name, authenticator: authenticator})
+}
+
+func (c *ChainAuthenticator) String() string {
+	names := make([]string, 0, len(c.steps))
+	for _, s := range c.steps {
+		names = append(names, s.name)
+	}
+	return strings.Join(names, ",")
+}
+
+func (c *ChainAuthenticator) UserAuthenticate(id, password string) (Role, error) {
+	for _, s := range c.steps {
+		role, err := s.authenticator.UserAuthenticate(id, password)
+		if err == nil {
+			log.Info("user %s authenticated by %s", id, s.name)
+			return role, nil
+		}
+
+		if !errors.Is(err, ErrNotMine) {
+			log.Warn("user %s rejected by %s : %s", id, s.name, err.Error())
+			return ROLE_UNKNOWN, err
+		}
+		log.Debug("user %s passed by %s : %s", id, s.name, err.Error())
+	}
+
+	return ROLE_UNKNOWN, fmt.Errorf("no authenticator accepted user %s", id)
+}
+
+// Directory returns first UserDirectory provided by authenticators in chain. nil when nothing provides it
+func (c *ChainAuthenticator) Directory() UserDirectory {
+	for _, s := range c.steps {
+		if provider, ok := s.authenticator.(interface{ Directory() UserDirectory }); ok {
+			if directory := provider.Directory(); directory != nil {
+				return directory
+			}
+		}
+	}
+	return nil
+}
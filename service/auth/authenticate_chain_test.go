@@ -0,0 +1,132 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 6:20
+ */
+package auth
+
+import (
+	"errors"
+	"fmt"
+	. "github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/infra"
+	"sync"
+	"testing"
+)
+
+// testAuthenticator knows users of passwords. unknown user and down authenticator answer ErrNotMine
+type testAuthenticator struct {
+	passwords map[string]string
+	role      Role
+	down      bool
+	calls     int
+}
+
+func (a *testAuthenticator) UserAuthenticate(id, password string) (Role, error) {
+	a.calls++
+	if a.down {
+		return ROLE_UNKNOWN, fmt.Errorf("%w : unreachable", ErrNotMine)
+	}
+	expected, ok := a.passwords[id]
+	if !ok {
+		return ROLE_UNKNOWN, fmt.Errorf("%w : not found user %s", ErrNotMine, id)
+	}
+	if expected != password {
+		return ROLE_UNKNOWN, errors.New("missmatch password")
+	}
+	return a.role, nil
+}
+
+func newTestBasicAuthenticator(allowIds ...string) *BasicAuthenticator {
+	users := infra.NewMemoryUserRepository()
+	users.Save(User{Id: "bob", Password: "bob-local", Role: ROLE_OPERATOR})
+	users.Save(User{Id: "carol", Password: "carol-local", Role: ROLE_MONITOR})
+	basic := &BasicAuthenticator{userRepository: users, encdec: infra.NewDefaultEncdec(), userLock: &sync.Mutex{}}
+	if len(allowIds) > 0 {
+		basic.allowIds = make(map[string]bool)
+		for _, id := range allowIds {
+			basic.allowIds[id] = true
+		}
+	}
+	return basic
+}
+
+func TestChainAuthenticator(t *testing.T) {
+	cases := []struct {
+		name     string
+		down     bool
+		allowIds []string
+		id       string
+		password string
+		role     Role
+		accepted bool
+	}{
+		{"directory user", false, nil, "alice", "alice-ldap", ROLE_MONITOR, true},
+		{"directory wrong password", false, nil, "alice", "wrong", ROLE_UNKNOWN, false},
+		{"local user falls through", false, nil, "admin", "admin", ROLE_OPERATOR, true},
+		{"directory rejection stops chain", false, nil, "bob", "bob-local", ROLE_UNKNOWN, false},
+		{"directory down falls through", true, nil, "bob", "bob-local", ROLE_OPERATOR, true},
+		{"directory down wrong local password", true, nil, "bob", "bob-ldap", ROLE_UNKNOWN, false},
+		{"directory down unknown user", true, nil, "alice", "alice-ldap", ROLE_UNKNOWN, false},
+		{"directory down allowed id", true, []string{"admin"}, "admin", "admin", ROLE_OPERATOR, true},
+		{"directory down not allowed id", true, []string{"admin"}, "carol", "carol-local", ROLE_UNKNOWN, false},
+		{"unknown user", false, nil, "dave", "dave", ROLE_UNKNOWN, false},
+	}
+
+	for _, c := range cases {
+		directory := &testAuthenticator{
+			passwords: map[string]string{"alice": "alice-ldap", "bob": "bob-ldap"},
+			role:      ROLE_MONITOR,
+			down:      c.down,
+		}
+		chain := NewChainAuthenticator()
+		chain.Append("ldap", directory)
+		chain.Append("basic", newTestBasicAuthenticator(c.allowIds...))
+
+		role, err := chain.UserAuthenticate(c.id, c.password)
+		if (err == nil) != c.accepted {
+			t.Fatalf("%s : accepted %t", c.name, err == nil)
+		}
+		if role != c.role {
+			t.Fatalf("%s : role %s, expected %s", c.name, role, c.role)
+		}
+		if directory.calls != 1 {
+			t.Fatalf("%s : directory is asked %d times", c.name, directory.calls)
+		}
+	}
+}
+
+func TestChainAuthenticatorOrder(t *testing.T) {
+	first := &testAuthenticator{passwords: map[string]string{"alice": "first"}, role: ROLE_OPERATOR}
+	second := &testAuthenticator{passwords: map[string]string{"alice": "second"}, role: ROLE_MONITOR}
+	chain := NewChainAuthenticator()
+	chain.Append("first", first)
+	chain.Append("second", second)
+
+	if chain.String() != "first,second" {
+		t.Fatalf("chain is %s", chain.String())
+	}
+	if role, err := chain.UserAuthenticate("alice", "first"); err != nil || role != ROLE_OPERATOR {
+		t.Fatalf("first authenticator is not asked first")
+	}
+	if _, err := chain.UserAuthenticate("alice", "second"); err == nil || second.calls != 0 {
+		t.Fatalf("rejection of first authenticator is passed to second")
+	}
+}
@@ -0,0 +1,290 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 11:20
+ */
+
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/fatima-go/fatima-core"
+	"github.com/fatima-go/fatima-core/crypt"
+	"github.com/fatima-go/fatima-log"
+	. "github.com/fatima-go/jupiter/domain"
+	"github.com/go-ldap/ldap/v3"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// auth=directory
+// auth.directory.url=ldaps://ad.example.com:636
+// auth.directory.base.dn=dc=example,dc=com
+// auth.directory.role.OPERATOR=cn=ops,ou=groups,dc=example,dc=com
+const (
+	propAuthDirectoryUrl                = "auth.directory.url"
+	propAuthDirectoryStartTls           = "auth.directory.starttls"
+	propAuthDirectoryCaFile             = "auth.directory.ca.file"
+	propAuthDirectoryInsecureSkipVerify = "auth.directory.insecure.skip.verify"
+	propAuthDirectoryBindDn             = "auth.directory.bind.dn"
+	propAuthDirectoryBindPassword       = "auth.directory.bind.password"
+	propAuthDirectoryBaseDn             = "auth.directory.base.dn"
+	propAuthDirectoryUserFilter         = "auth.directory.user.filter"
+	propAuthDirectoryGroupAttribute     = "auth.directory.group.attribute"
+	propAuthDirectoryRolePrefix         = "auth.directory.role."
+	propAuthDirectoryTimeoutSeconds     = "auth.directory.timeout.seconds"
+	defaultAuthDirectoryUserFilter      = "(&(objectClass=person)(uid=%s))"
+	defaultAuthDirectoryGroupAttribute  = "memberOf"
+	defaultAuthDirectoryTimeoutSeconds  = 5
+)
+
+// NewDirectoryAuthenticator creates authenticator which talks to LDAP/AD directly (without ldap helper).
+// it binds with service account, searches user entry with auth.directory.user.filter and binds as the user.
+// groups of user(auth.directory.group.attribute) are mapped to role with auth.directory.role.OPERATOR|MONITOR.
+// each role property is semicolon separated group DN list
+func NewDirectoryAuthenticator(fatimaRuntime fatima.FatimaRuntime) (Authenticate, error) {
+	log.Info("creating DirectoryAuthenticator")
+	config := fatimaRuntime.GetConfig()
+	auth := &DirectoryAuthenticator{}
+
+	var err error
+	auth.url, err = config.GetString(propAuthDirectoryUrl)
+	if err != nil {
+		return nil, fmt.Errorf("%s is required for directory authentication", propAuthDirectoryUrl)
+	}
+	auth.baseDn, err = config.GetString(propAuthDirectoryBaseDn)
+	if err != nil {
+		return nil, fmt.Errorf("%s is required for directory authentication", propAuthDirectoryBaseDn)
+	}
+
+	auth.startTls, _ = config.GetBool(propAuthDirectoryStartTls)
+	auth.bindDn, _ = config.GetValue(propAuthDirectoryBindDn)
+	if password, ok := config.GetValue(propAuthDirectoryBindPassword); ok {
+		auth.bindPassword = crypt.ResolveSecret(password)
+	}
+
+	auth.userFilter, err = config.GetString(propAuthDirectoryUserFilter)
+	if err != nil {
+		auth.userFilter = defaultAuthDirectoryUserFilter
+	}
+	auth.groupAttribute, err = config.GetString(propAuthDirectoryGroupAttribute)
+	if err != nil {
+		auth.groupAttribute = defaultAuthDirectoryGroupAttribute
+	}
+	timeout, err := config.GetInt(propAuthDirectoryTimeoutSeconds)
+	if err != nil {
+		timeout = defaultAuthDirectoryTimeoutSeconds
+	}
+	auth.timeout = time.Second * time.Duration(timeout)
+
+	auth.tlsConfig, err = buildDirectoryTlsConfig(fatimaRuntime, auth.url)
+	if err != nil {
+		return nil, err
+	}
+
+	auth.roleGroups = make(map[Role][]string)
+	for _, role := range []Role{ROLE_OPERATOR, ROLE_MONITOR} {
+		groups, ok := config.GetValue(propAuthDirectoryRolePrefix + role.String())
+		if !ok {
+			continue
+		}
+		for _, dn := range strings.Split(groups, ";") {
+			if dn = normalizeDn(dn); len(dn) > 0 {
+				auth.roleGroups[role] = append(auth.roleGroups[role], dn)
+			}
+		}
+	}
+	if len(auth.roleGroups) == 0 {
+		return nil, fmt.Errorf("%sOPERATOR or %sMONITOR is required for directory authentication", propAuthDirectoryRolePrefix, propAuthDirectoryRolePrefix)
+	}
+
+	if !strings.HasPrefix(strings.ToLower(auth.url), "ldaps://") && !auth.startTls {
+		log.Warn("directory %s is used without TLS. password is sent as plain text", auth.url)
+	}
+	log.Info("using directory %s. base.dn=%s, user.filter=%s", auth.url, auth.baseDn, auth.userFilter)
+	return auth, nil
+}
+
+func buildDirectoryTlsConfig(fatimaRuntime fatima.FatimaRuntime, url string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if host, _, err := net.SplitHostPort(strings.TrimPrefix(strings.TrimPrefix(url, "ldaps://"), "ldap://")); err == nil {
+		config.ServerName = host
+	}
+
+	if skip, err := fatimaRuntime.GetConfig().GetBool(propAuthDirectoryInsecureSkipVerify); err == nil && skip {
+		log.Warn("certificate of directory is not verified")
+		config.InsecureSkipVerify = true
+	}
+
+	caFile, ok := fatimaRuntime.GetConfig().GetValue(propAuthDirectoryCaFile)
+	if !ok {
+		return config, nil
+	}
+
+	b, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("fail to read %s : %s", caFile, err.Error())
+	}
+	config.RootCAs = x509.NewCertPool()
+	if !config.RootCAs.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no certificate in %s", caFile)
+	}
+	return config, nil
+}
+
+type DirectoryAuthenticator struct {
+	url            string
+	startTls       bool
+	tlsConfig      *tls.Config
+	bindDn         string
+	bindPassword   string
+	baseDn         string
+	userFilter     string
+	groupAttribute string
+	roleGroups     map[Role][]string
+	timeout        time.Duration
+	Authenticate
+}
+
+// UserAuthenticate answers ErrNotMine when directory is unreachable or user is not found in directory
+func (d *DirectoryAuthenticator) UserAuthenticate(id, password string) (Role, error) {
+	if len(password) == 0 {
+		// empty password is unauthenticated bind which always succeeds
+		return ROLE_UNKNOWN, errors.New("empty password")
+	}
+
+	conn, err := d.connect()
+	if err != nil {
+		return ROLE_UNKNOWN, fmt.Errorf("%w : fail to connect directory : %s", ErrNotMine, err.Error())
+	}
+	defer conn.Close()
+
+	if len(d.bindDn) > 0 {
+		err = conn.Bind(d.bindDn, d.bindPassword)
+	} else {
+		err = conn.UnauthenticatedBind("")
+	}
+	if err != nil {
+		return ROLE_UNKNOWN, fmt.Errorf("%w : fail to bind service account : %s", ErrNotMine, err.Error())
+	}
+
+	entry, err := d.findUser(conn, id)
+	if err != nil {
+		return ROLE_UNKNOWN, err
+	}
+
+	err = conn.Bind(entry.DN, password)
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return ROLE_UNKNOWN, fmt.Errorf("missmatch password for user %s", id)
+		}
+		return ROLE_UNKNOWN, fmt.Errorf("fail to bind user %s : %s", id, err.Error())
+	}
+
+	role := d.resolveRole(entry.GetEqualFoldAttributeValues(d.groupAttribute))
+	if role == ROLE_UNKNOWN {
+		return ROLE_UNKNOWN, fmt.Errorf("user %s is not member of any mapped group", id)
+	}
+	return role, nil
+}
+
+func (d *DirectoryAuthenticator) connect() (*ldap.Conn, error) {
+	dialer := &net.Dialer{Timeout: d.timeout}
+	conn, err := ldap.DialURL(d.url, ldap.DialWithDialer(dialer), ldap.DialWithTLSConfig(d.tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(d.timeout)
+
+	if d.startTls {
+		err = conn.StartTLS(d.tlsConfig)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("starttls fail : %s", err.Error())
+		}
+	}
+	return conn, nil
+}
+
+func (d *DirectoryAuthenticator) findUser(conn *ldap.Conn, id string) (*ldap.Entry, error) {
+	req := ldap.NewSearchRequest(
+		d.baseDn,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		2,
+		int(d.timeout.Seconds()),
+		false,
+		fmt.Sprintf(d.userFilter, ldap.EscapeFilter(id)),
+		[]string{"dn", d.groupAttribute},
+		nil)
+
+	res, err := conn.Search(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w : fail to search user %s : %s", ErrNotMine, id, err.Error())
+	}
+
+	switch len(res.Entries) {
+	case 0:
+		return nil, fmt.Errorf("%w : not found user %s in directory", ErrNotMine, id)
+	case 1:
+		return res.Entries[0], nil
+	}
+	return nil, fmt.Errorf("user %s is ambiguous in directory", id)
+}
+
+// resolveRole returns highest role mapped to given groups
+func (d *DirectoryAuthenticator) resolveRole(groups []string) Role {
+	memberOf := make(map[string]bool)
+	for _, g := range groups {
+		memberOf[normalizeDn(g)] = true
+	}
+
+	for _, role := range []Role{ROLE_OPERATOR, ROLE_MONITOR} {
+		for _, dn := range d.roleGroups[role] {
+			if memberOf[dn] {
+				return role
+			}
+		}
+	}
+	return ROLE_UNKNOWN
+}
+
+// normalizeDn lowers DN and removes spaces around separators for comparison
+func normalizeDn(dn string) string {
+	parsed, err := ldap.ParseDN(strings.TrimSpace(dn))
+	if err != nil || len(parsed.RDNs) == 0 {
+		return strings.ToLower(strings.TrimSpace(dn))
+	}
+
+	rdns := make([]string, 0, len(parsed.RDNs))
+	for _, rdn := range parsed.RDNs {
+		attrs := make([]string, 0, len(rdn.Attributes))
+		for _, a := range rdn.Attributes {
+			attrs = append(attrs, strings.ToLower(a.Type)+"="+strings.ToLower(a.Value))
+		}
+		rdns = append(rdns, strings.Join(attrs, "+"))
+	}
+	return strings.Join(rdns, ",")
+}
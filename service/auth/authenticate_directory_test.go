@@ -0,0 +1,212 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오전 10:12
+ */
+
+package auth
+
+import (
+	"errors"
+	. "github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/service/auth/ldaptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testDirectoryBaseDn      = "dc=example,dc=com"
+	testDirectoryServiceDn   = "cn=jupiter,ou=service,dc=example,dc=com"
+	testDirectoryServicePass = "service-secret"
+	testDirectoryOperatorDn  = "cn=ops,ou=groups,dc=example,dc=com"
+	testDirectoryMonitorDn   = "cn=viewers,ou=groups,dc=example,dc=com"
+)
+
+func newTestDirectoryUser(uid string, password string, groups ...string) ldaptest.Entry {
+	return ldaptest.Entry{
+		DN:       "uid=" + uid + ",ou=people," + testDirectoryBaseDn,
+		Password: password,
+		Attributes: map[string][]string{
+			"objectClass": {"person"},
+			"uid":         {uid},
+			"memberOf":    groups,
+		},
+	}
+}
+
+func newTestDirectory(t *testing.T) *ldaptest.Server {
+	server, err := ldaptest.NewServer(
+		ldaptest.Entry{DN: testDirectoryBaseDn, Attributes: map[string][]string{"objectClass": {"domain"}}},
+		ldaptest.Entry{DN: testDirectoryServiceDn, Password: testDirectoryServicePass},
+		newTestDirectoryUser("alice", "alice-pw", testDirectoryOperatorDn, testDirectoryMonitorDn),
+		newTestDirectoryUser("bob", "bob-pw", "CN=Viewers, OU=Groups, DC=Example, DC=Com"),
+		newTestDirectoryUser("carol", "carol-pw", "cn=other,ou=groups,dc=example,dc=com"),
+		newTestDirectoryUser("dave", "dave-pw"),
+	)
+	if err != nil {
+		t.Fatalf("fail to start ldap server : %s", err.Error())
+	}
+	t.Cleanup(func() { server.Close() })
+	return server
+}
+
+func newTestDirectoryAuthenticator(url string) *DirectoryAuthenticator {
+	return &DirectoryAuthenticator{
+		url:            url,
+		bindDn:         testDirectoryServiceDn,
+		bindPassword:   testDirectoryServicePass,
+		baseDn:         testDirectoryBaseDn,
+		userFilter:     defaultAuthDirectoryUserFilter,
+		groupAttribute: defaultAuthDirectoryGroupAttribute,
+		roleGroups: map[Role][]string{
+			ROLE_OPERATOR: {normalizeDn(testDirectoryOperatorDn)},
+			ROLE_MONITOR:  {normalizeDn(testDirectoryMonitorDn)},
+		},
+		timeout: time.Second * 3,
+	}
+}
+
+func TestDirectoryAuthenticateRole(t *testing.T) {
+	server := newTestDirectory(t)
+	auth := newTestDirectoryAuthenticator(server.URL())
+
+	cases := []struct {
+		id       string
+		password string
+		role     Role
+	}{
+		{"alice", "alice-pw", ROLE_OPERATOR}, // highest role wins
+		{"bob", "bob-pw", ROLE_MONITOR},      // group DN is compared after normalizing
+	}
+
+	for _, c := range cases {
+		role, err := auth.UserAuthenticate(c.id, c.password)
+		if err != nil {
+			t.Fatalf("%s : unexpected error : %s", c.id, err.Error())
+		}
+		if role != c.role {
+			t.Fatalf("%s : role %s, want %s", c.id, role, c.role)
+		}
+	}
+}
+
+func TestDirectoryAuthenticateUnmappedGroup(t *testing.T) {
+	server := newTestDirectory(t)
+	auth := newTestDirectoryAuthenticator(server.URL())
+
+	for _, id := range []string{"carol", "dave"} {
+		_, err := auth.UserAuthenticate(id, id+"-pw")
+		if err == nil {
+			t.Fatalf("%s : user without mapped group is authenticated", id)
+		}
+		if errors.Is(err, ErrNotMine) {
+			t.Fatalf("%s : user found in directory should not be passed to next authenticator", id)
+		}
+	}
+}
+
+func TestDirectoryAuthenticateServiceAccount(t *testing.T) {
+	server := newTestDirectory(t)
+	auth := newTestDirectoryAuthenticator(server.URL())
+	auth.bindPassword = "wrong"
+
+	_, err := auth.UserAuthenticate("alice", "alice-pw")
+	if !errors.Is(err, ErrNotMine) {
+		t.Fatalf("service account bind failure should be ErrNotMine : %v", err)
+	}
+}
+
+func TestDirectoryAuthenticateWrongPassword(t *testing.T) {
+	server := newTestDirectory(t)
+	auth := newTestDirectoryAuthenticator(server.URL())
+
+	_, err := auth.UserAuthenticate("alice", "bob-pw")
+	if err == nil {
+		t.Fatalf("wrong password is authenticated")
+	}
+	if errors.Is(err, ErrNotMine) {
+		t.Fatalf("wrong password should not be passed to next authenticator : %s", err.Error())
+	}
+}
+
+func TestDirectoryAuthenticateEmptyPassword(t *testing.T) {
+	server := newTestDirectory(t)
+	auth := newTestDirectoryAuthenticator(server.URL())
+
+	_, err := auth.UserAuthenticate("alice", "")
+	if err == nil {
+		t.Fatalf("empty password is authenticated")
+	}
+	if errors.Is(err, ErrNotMine) {
+		t.Fatalf("empty password should not be passed to next authenticator : %s", err.Error())
+	}
+}
+
+func TestDirectoryAuthenticateUnknownUser(t *testing.T) {
+	server := newTestDirectory(t)
+	auth := newTestDirectoryAuthenticator(server.URL())
+
+	_, err := auth.UserAuthenticate("mallory", "mallory-pw")
+	if !errors.Is(err, ErrNotMine) {
+		t.Fatalf("unknown user should be ErrNotMine : %v", err)
+	}
+}
+
+func TestDirectoryAuthenticateUnreachable(t *testing.T) {
+	server := newTestDirectory(t)
+	url := server.URL()
+	server.Close()
+
+	_, err := newTestDirectoryAuthenticator(url).UserAuthenticate("alice", "alice-pw")
+	if !errors.Is(err, ErrNotMine) {
+		t.Fatalf("unreachable directory should be ErrNotMine : %v", err)
+	}
+}
+
+func TestDirectoryAuthenticateFilterEscape(t *testing.T) {
+	server := newTestDirectory(t)
+	auth := newTestDirectoryAuthenticator(server.URL())
+
+	// every id would match some entries if it was not escaped
+	for _, id := range []string{"*", "a*", "alice)(uid=*", "*)(objectClass=*"} {
+		_, err := auth.UserAuthenticate(id, "alice-pw")
+		if !errors.Is(err, ErrNotMine) {
+			t.Fatalf("id %q should not match any entry : %v", id, err)
+		}
+	}
+
+	// escaped value is still compared literally
+	server.Add(newTestDirectoryUser("star*", "star-pw", testDirectoryMonitorDn))
+	role, err := auth.UserAuthenticate("star*", "star-pw")
+	if err != nil || role != ROLE_MONITOR {
+		t.Fatalf("user with special character : %s, %v", role, err)
+	}
+}
+
+func TestNormalizeDn(t *testing.T) {
+	got := normalizeDn(" CN=Ops , OU=Groups,DC=Example,DC=com ")
+	if got != "cn=ops,ou=groups,dc=example,dc=com" {
+		t.Fatalf("normalized dn %s", got)
+	}
+	if !strings.Contains(normalizeDn("not a dn"), "not a dn") {
+		t.Fatalf("invalid dn should be kept")
+	}
+}
@@ -25,36 +25,71 @@ package auth
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"github.com/fatima-go/fatima-core"
+	"github.com/fatima-go/fatima-core/crypt"
 	"github.com/fatima-go/fatima-log"
 	. "github.com/fatima-go/jupiter/domain"
 	proto "github.com/fatima-go/jupiter/proto/ldap.adapter.v1"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
-	"sync"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"os"
+	"strings"
 	"time"
 )
 
 func NewLdapAuthenticator(fatimaRuntime fatima.FatimaRuntime) (Authenticate, error) {
 	log.Info("creating LdapAuthenticator")
+	config := fatimaRuntime.GetConfig()
 	auth := &LdapAuthenticator{}
 
 	var err error
-	auth.ldapHelperAddress, err = fatimaRuntime.GetConfig().GetString(propAuthLdapHelperIp)
+	auth.ldapHelperAddress, err = config.GetString(propAuthLdapHelperIp)
 	if err != nil {
 		auth.ldapHelperAddress = defaultAuthLdapHelperIp
 	}
-	auth.ldapHelperPort, err = fatimaRuntime.GetConfig().GetInt(propAuthLdapHelperPort)
+	auth.ldapHelperPort, err = config.GetInt(propAuthLdapHelperPort)
 	if err != nil {
 		auth.ldapHelperPort = defaultAuthLdapHelperPort
 	}
+	auth.callTimeout = getMillis(fatimaRuntime, propAuthLdapHelperCallTimeoutMillis, defaultAuthLdapHelperCallTimeoutMillis)
+	auth.healthCheck, err = config.GetBool(propAuthLdapHelperHealth)
+	if err != nil {
+		auth.healthCheck = true
+	}
+	auth.protocol = valueAuthLdapHelperProtocolV1
+	if protocol, ok := config.GetValue(propAuthLdapHelperProtocol); ok && strings.ToLower(protocol) == valueAuthLdapHelperProtocolV2 {
+		auth.protocol = valueAuthLdapHelperProtocolV2
+	}
+	if token, ok := config.GetValue(propAuthLdapHelperToken); ok {
+		auth.token = crypt.ResolveSecret(token)
+	}
 
-	log.Info("Using ldap helper %s:%d", auth.ldapHelperAddress, auth.ldapHelperPort)
+	threshold, err := config.GetInt(propAuthLdapHelperBreakerFailures)
+	if err != nil {
+		threshold = defaultAuthLdapHelperBreakerFailures
+	}
+	openSeconds, err := config.GetInt(propAuthLdapHelperBreakerOpenSeconds)
+	if err != nil {
+		openSeconds = defaultAuthLdapHelperBreakerOpenSeconds
+	}
+	auth.breaker = newCircuitBreaker("ldap helper", threshold, time.Second*time.Duration(openSeconds))
 
-	auth.connectToHelper() // we don't need check error response
+	log.Info("Using ldap helper %s:%d (protocol %s)", auth.ldapHelperAddress, auth.ldapHelperPort, auth.protocol)
 
+	auth.conn, err = auth.newClient(fatimaRuntime)
+	if err != nil {
+		return nil, err
+	}
 	return auth, nil
 }
 
@@ -62,67 +97,202 @@ func NewLdapAuthenticator(fatimaRuntime fatima.FatimaRuntime) (Authenticate, err
 // auth.ldap.helper.ip=127.0.0.1
 // auth.ldap.helper.port=6413
 const (
-	propAuthLdapHelperIp      = "auth.ldap.helper.ip"
-	propAuthLdapHelperPort    = "auth.ldap.helper.port"
-	defaultAuthLdapHelperIp   = "127.0.0.1"
-	defaultAuthLdapHelperPort = 6413
+	propAuthLdapHelperIp                    = "auth.ldap.helper.ip"
+	propAuthLdapHelperPort                  = "auth.ldap.helper.port"
+	defaultAuthLdapHelperIp                 = "127.0.0.1"
+	defaultAuthLdapHelperPort               = 6413
+	propAuthLdapHelperProtocol              = "auth.ldap.helper.protocol"
+	valueAuthLdapHelperProtocolV1           = "v1"
+	valueAuthLdapHelperProtocolV2           = "v2"
+	propAuthLdapHelperTls                   = "auth.ldap.helper.tls"
+	propAuthLdapHelperCaFile                = "auth.ldap.helper.ca.file"
+	propAuthLdapHelperCertFile              = "auth.ldap.helper.cert.file"
+	propAuthLdapHelperKeyFile               = "auth.ldap.helper.key.file"
+	propAuthLdapHelperServerName            = "auth.ldap.helper.server.name"
+	propAuthLdapHelperToken                 = "auth.ldap.helper.token"
+	propAuthLdapHelperConnectTimeoutMillis  = "auth.ldap.helper.connect.timeout.millis"
+	defaultAuthLdapHelperConnectTimeoutMs   = 1000
+	propAuthLdapHelperCallTimeoutMillis     = "auth.ldap.helper.call.timeout.millis"
+	defaultAuthLdapHelperCallTimeoutMillis  = 1000
+	propAuthLdapHelperKeepaliveSeconds      = "auth.ldap.helper.keepalive.seconds"
+	defaultAuthLdapHelperKeepaliveSeconds   = 30
+	propAuthLdapHelperHealth                = "auth.ldap.helper.health"
+	propAuthLdapHelperBreakerFailures       = "auth.ldap.helper.breaker.failures"
+	defaultAuthLdapHelperBreakerFailures    = 3
+	propAuthLdapHelperBreakerOpenSeconds    = "auth.ldap.helper.breaker.open.seconds"
+	defaultAuthLdapHelperBreakerOpenSeconds = 30
+
+	ldapHelperTokenMetadata = "authorization"
 )
 
+// LdapAuthenticator asks ldap helper over grpc.
+// connection is created once and reconnected by grpc itself, so it is safe for concurrent login.
+// when helper keeps failing, circuit breaker answers ErrNotMine immediately instead of waiting for timeout
 type LdapAuthenticator struct {
 	ldapHelperAddress string
 	ldapHelperPort    int
+	protocol          string
 	conn              *grpc.ClientConn
-	mu                sync.Mutex
+	callTimeout       time.Duration
+	token             string
+	healthCheck       bool
+	breaker           *circuitBreaker
 	Authenticate
 }
 
-func (l *LdapAuthenticator) connectToHelper() error {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+func getMillis(fatimaRuntime fatima.FatimaRuntime, prop string, defaultValue int) time.Duration {
+	v, err := fatimaRuntime.GetConfig().GetInt(prop)
+	if err != nil {
+		v = defaultValue
+	}
+	return time.Millisecond * time.Duration(v)
+}
 
-	if l.conn != nil {
-		return nil // maybe exist available connection
+func (l *LdapAuthenticator) newClient(fatimaRuntime fatima.FatimaRuntime) (*grpc.ClientConn, error) {
+	transport, err := buildLdapHelperCredentials(fatimaRuntime)
+	if err != nil {
+		return nil, err
+	}
+	if len(l.token) > 0 && transport.Info().SecurityProtocol != "tls" {
+		log.Warn("ldap helper token is sent without TLS")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
+	keepaliveSeconds, err := fatimaRuntime.GetConfig().GetInt(propAuthLdapHelperKeepaliveSeconds)
+	if err != nil {
+		keepaliveSeconds = defaultAuthLdapHelperKeepaliveSeconds
+	}
 
-	gConn, err := grpc.DialContext(
-		ctx,
-		fmt.Sprintf("%s:%d", l.ldapHelperAddress, l.ldapHelperPort),
-		grpc.WithBlock(),
-		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	options := []grpc.DialOption{
+		grpc.WithTransportCredentials(transport),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			MinConnectTimeout: getMillis(fatimaRuntime, propAuthLdapHelperConnectTimeoutMillis, defaultAuthLdapHelperConnectTimeoutMs),
+		}),
+	}
+	if keepaliveSeconds > 0 {
+		options = append(options, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                time.Second * time.Duration(keepaliveSeconds),
+			Timeout:             l.callTimeout * 3,
+			PermitWithoutStream: true,
+		}))
+	}
 
+	conn, err := grpc.NewClient(fmt.Sprintf("%s:%d", l.ldapHelperAddress, l.ldapHelperPort), options...)
 	if err != nil {
-		return fmt.Errorf("fail to connect saturn : %s", err.Error())
+		return nil, fmt.Errorf("fail to create ldap helper client : %s", err.Error())
+	}
+	conn.Connect() // connect in background. we don't need check result here
+	return conn, nil
+}
+
+// buildLdapHelperCredentials returns TLS(mTLS when cert and key are given) or insecure credentials
+func buildLdapHelperCredentials(fatimaRuntime fatima.FatimaRuntime) (credentials.TransportCredentials, error) {
+	config := fatimaRuntime.GetConfig()
+	useTls, err := config.GetBool(propAuthLdapHelperTls)
+	if err != nil || !useTls {
+		return insecure.NewCredentials(), nil
 	}
 
-	log.Info("connected to ldap helper")
-	l.conn = gConn
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	tlsConfig.ServerName, _ = config.GetValue(propAuthLdapHelperServerName)
+
+	if caFile, ok := config.GetValue(propAuthLdapHelperCaFile); ok {
+		b, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("fail to read %s : %s", caFile, err.Error())
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificate in %s", caFile)
+		}
+	}
+
+	certFile, ok1 := config.GetValue(propAuthLdapHelperCertFile)
+	keyFile, ok2 := config.GetValue(propAuthLdapHelperKeyFile)
+	if ok1 && ok2 {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("fail to load client certificate : %s", err.Error())
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+		log.Info("ldap helper channel uses mTLS")
+	} else {
+		log.Info("ldap helper channel uses TLS")
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// callContext returns context with call timeout and token metadata
+func (l *LdapAuthenticator) callContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), l.callTimeout)
+	if len(l.token) > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, ldapHelperTokenMetadata, "Bearer "+l.token)
+	}
+	return ctx, cancel
+}
+
+// checkHealth asks grpc health service of helper. helper without health service is regarded as serving
+func (l *LdapAuthenticator) checkHealth() error {
+	ctx, cancel := l.callContext()
+	defer cancel()
+
+	res, err := grpc_health_v1.NewHealthClient(l.conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			return nil
+		}
+		return err
+	}
+	if res.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		return fmt.Errorf("ldap helper is %s", res.Status)
+	}
+	return nil
+}
+
+// invoke calls helper through circuit breaker. transport failure is answered as ErrNotMine
+func (l *LdapAuthenticator) invoke(name string, call func(ctx context.Context) error) error {
+	allowed, probe := l.breaker.Allow()
+	if !allowed {
+		return fmt.Errorf("%w : ldap helper circuit is open", ErrNotMine)
+	}
+
+	if probe && l.healthCheck {
+		if err := l.checkHealth(); err != nil {
+			l.breaker.Failure()
+			return fmt.Errorf("%w : ldap helper health check fail : %s", ErrNotMine, err.Error())
+		}
+	}
+
+	ctx, cancel := l.callContext()
+	defer cancel()
+
+	if err := call(ctx); err != nil {
+		l.breaker.Failure()
+		return fmt.Errorf("%w : %s error : %s", ErrNotMine, name, err.Error())
+	}
+	l.breaker.Success()
 	return nil
 }
 
+// UserAuthenticate answers ErrNotMine when ldap helper is unreachable or user is not found in ldap
 func (l *LdapAuthenticator) UserAuthenticate(id, password string) (Role, error) {
 	log.Info("Ask id=%s", id)
-	if l.conn == nil {
-		err := l.connectToHelper()
-		if err != nil {
-			return ROLE_UNKNOWN, fmt.Errorf("userAuthenticate::connectToHelper error : %s", err.Error())
-		}
+
+	if l.protocol == valueAuthLdapHelperProtocolV2 {
+		return l.authenticateV2(id, password)
 	}
 
 	req := proto.AuthenticateRequest{}
 	req.Id = id
 	req.Password = password
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
-
-	res, err := proto.NewLdapAdapterServiceClient(l.conn).Authenticate(ctx, &req)
+	var res *proto.AuthenticateResponse
+	err := l.invoke("userAuthenticate::Authenticate", func(ctx context.Context) (err error) {
+		res, err = proto.NewLdapAdapterServiceClient(l.conn).Authenticate(ctx, &req)
+		return
+	})
 	if err != nil {
-		l.conn.Close()
-		l.conn = nil
-		return ROLE_UNKNOWN, fmt.Errorf("userAuthenticate::Authenticate error : %s", err.Error())
+		return ROLE_UNKNOWN, err
 	}
 
 	if errRes, ok := res.Response.(*proto.AuthenticateResponse_Error); ok {
@@ -135,7 +305,7 @@ func (l *LdapAuthenticator) UserAuthenticate(id, password string) (Role, error)
 			return ROLE_UNKNOWN, errors.New(fmt.Sprintf("bad parameter for user %s", id))
 		case proto.ResponseError_NOT_FOUND:
 			log.Warn("NOT_FOUND : [%s] %s", errRes.Error.Code, errRes.Error.Desc)
-			return ROLE_UNKNOWN, errors.New(fmt.Sprintf("not found for user %s", id))
+			return ROLE_UNKNOWN, fmt.Errorf("%w : not found for user %s", ErrNotMine, id)
 		default:
 			log.Warn("UserAuthenticate fail : [%s] %s", errRes.Error.Code, errRes.Error.Desc)
 		}
@@ -0,0 +1,146 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 19. 오전 9:40
+ */
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fatima-go/fatima-log"
+	. "github.com/fatima-go/jupiter/domain"
+	proto "github.com/fatima-go/jupiter/proto/ldap.adapter.v2"
+)
+
+// Directory returns UserDirectory of ldap helper. helper speaking v1 protocol has no directory
+func (l *LdapAuthenticator) Directory() UserDirectory {
+	if l.protocol != valueAuthLdapHelperProtocolV2 {
+		return nil
+	}
+	return l
+}
+
+func (l *LdapAuthenticator) authenticateV2(id, password string) (Role, error) {
+	req := proto.AuthenticateRequest{Id: id, Password: password}
+
+	var res *proto.AuthenticateResponse
+	err := l.invoke("userAuthenticate::Authenticate", func(ctx context.Context) (err error) {
+		res, err = proto.NewLdapAdapterServiceClient(l.conn).Authenticate(ctx, &req)
+		return
+	})
+	if err != nil {
+		return ROLE_UNKNOWN, err
+	}
+
+	if errRes, ok := res.Response.(*proto.AuthenticateResponse_Error); ok {
+		return ROLE_UNKNOWN, toLdapHelperError("Authenticate", id, errRes.Error)
+	}
+
+	return ToRole(res.Role), nil
+}
+
+func (l *LdapAuthenticator) LookupUser(id string) (UserProfile, error) {
+	req := proto.LookupUserRequest{Id: id}
+
+	var res *proto.LookupUserResponse
+	err := l.invoke("LookupUser", func(ctx context.Context) (err error) {
+		res, err = proto.NewLdapAdapterServiceClient(l.conn).LookupUser(ctx, &req)
+		return
+	})
+	if err != nil {
+		return UserProfile{}, err
+	}
+
+	if errRes, ok := res.Response.(*proto.LookupUserResponse_Error); ok {
+		return UserProfile{}, toLdapHelperError("LookupUser", id, errRes.Error)
+	}
+
+	profile := UserProfile{Id: id}
+	if res.User != nil {
+		profile.DisplayName = res.User.DisplayName
+		profile.Email = res.User.Email
+		profile.Groups = res.User.Groups
+	}
+	return profile, nil
+}
+
+func (l *LdapAuthenticator) ListGroups(prefix string) ([]string, error) {
+	req := proto.ListGroupsRequest{Prefix: prefix}
+
+	var res *proto.ListGroupsResponse
+	err := l.invoke("ListGroups", func(ctx context.Context) (err error) {
+		res, err = proto.NewLdapAdapterServiceClient(l.conn).ListGroups(ctx, &req)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if errRes, ok := res.Response.(*proto.ListGroupsResponse_Error); ok {
+		return nil, toLdapHelperError("ListGroups", prefix, errRes.Error)
+	}
+
+	groups := make([]string, 0, len(res.Groups))
+	for _, g := range res.Groups {
+		groups = append(groups, g.Name)
+	}
+	return groups, nil
+}
+
+// ValidateUser checks user without password. disabled user is rejected, unknown user answers ErrNotMine
+func (l *LdapAuthenticator) ValidateUser(id string) (Role, error) {
+	req := proto.ValidateUserRequest{Id: id}
+
+	var res *proto.ValidateUserResponse
+	err := l.invoke("ValidateUser", func(ctx context.Context) (err error) {
+		res, err = proto.NewLdapAdapterServiceClient(l.conn).ValidateUser(ctx, &req)
+		return
+	})
+	if err != nil {
+		return ROLE_UNKNOWN, err
+	}
+
+	if errRes, ok := res.Response.(*proto.ValidateUserResponse_Error); ok {
+		return ROLE_UNKNOWN, toLdapHelperError("ValidateUser", id, errRes.Error)
+	}
+
+	return ToRole(res.Role), nil
+}
+
+func toLdapHelperError(name, id string, e *proto.ResponseError) error {
+	log.Warn("%s fail for %s : %s [%s] %s", name, id, e.GrpcResponse, e.Code, e.Desc)
+
+	if e.Code == proto.ResponseError_DISABLED {
+		return fmt.Errorf("user %s is disabled", id)
+	}
+
+	switch e.GrpcResponse {
+	case proto.ResponseError_NOT_FOUND:
+		return fmt.Errorf("%w : not found for %s", ErrNotMine, id)
+	case proto.ResponseError_UNAUTORIZED:
+		return fmt.Errorf("unauthorized for user %s", id)
+	case proto.ResponseError_BAD_PARAMETER:
+		return fmt.Errorf("bad parameter for %s", id)
+	}
+	return errors.New(fmt.Sprintf("%s fail for %s", name, id))
+}
@@ -0,0 +1,236 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오전 10:41
+ */
+
+package auth
+
+import (
+	"errors"
+	"fmt"
+	. "github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/service/auth/ldaphelpertest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLdapHelper(t *testing.T) *ldaphelpertest.Server {
+	server, err := ldaphelpertest.NewServer(
+		ldaphelpertest.User{Id: "alice", Password: "alice-pw", Role: "OPERATOR", DisplayName: "Alice", Email: "alice@example.com", Groups: []string{"dev-backend", "ops"}},
+		ldaphelpertest.User{Id: "bob", Password: "bob-pw", Role: "MONITOR", Groups: []string{"dev-frontend"}},
+		ldaphelpertest.User{Id: "carol", Password: "carol-pw", Role: "OPERATOR", Disabled: true},
+	)
+	if err != nil {
+		t.Fatalf("fail to start ldap helper : %s", err.Error())
+	}
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestLdapAuthenticator connects helper without circuit breaker unless threshold is given
+func newTestLdapAuthenticator(t *testing.T, server *ldaphelpertest.Server, protocol string, threshold int) *LdapAuthenticator {
+	conn, err := grpc.NewClient(fmt.Sprintf("%s:%d", server.Addr(), server.Port()), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("fail to create client : %s", err.Error())
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &LdapAuthenticator{
+		ldapHelperAddress: server.Addr(),
+		ldapHelperPort:    server.Port(),
+		protocol:          protocol,
+		conn:              conn,
+		callTimeout:       time.Second * 3,
+		healthCheck:       true,
+		breaker:           newCircuitBreaker("ldap helper", threshold, time.Minute),
+	}
+}
+
+func TestLdapAuthenticateV2(t *testing.T) {
+	server := newTestLdapHelper(t)
+	auth := newTestLdapAuthenticator(t, server, valueAuthLdapHelperProtocolV2, 0)
+
+	cases := []struct {
+		id       string
+		password string
+		role     Role
+		notMine  bool
+		fail     bool
+	}{
+		{"alice", "alice-pw", ROLE_OPERATOR, false, false},
+		{"bob", "bob-pw", ROLE_MONITOR, false, false},
+		{"alice", "wrong", ROLE_UNKNOWN, false, true},
+		{"alice", "", ROLE_UNKNOWN, false, true},
+		{"carol", "carol-pw", ROLE_UNKNOWN, false, true}, // disabled
+		{"mallory", "mallory-pw", ROLE_UNKNOWN, true, true},
+	}
+
+	for _, c := range cases {
+		role, err := auth.UserAuthenticate(c.id, c.password)
+		if c.fail != (err != nil) {
+			t.Fatalf("%s/%s : unexpected result %s, %v", c.id, c.password, role, err)
+		}
+		if c.notMine != errors.Is(err, ErrNotMine) {
+			t.Fatalf("%s/%s : ErrNotMine expected %t : %v", c.id, c.password, c.notMine, err)
+		}
+		if role != c.role {
+			t.Fatalf("%s/%s : role %s, want %s", c.id, c.password, role, c.role)
+		}
+	}
+
+	if server.Calls("Authenticate") != len(cases) {
+		t.Fatalf("v2 Authenticate is called %d times", server.Calls("Authenticate"))
+	}
+}
+
+func TestLdapAuthenticateV2Token(t *testing.T) {
+	server := newTestLdapHelper(t)
+	server.RequireToken("helper-token")
+	auth := newTestLdapAuthenticator(t, server, valueAuthLdapHelperProtocolV2, 0)
+
+	_, err := auth.UserAuthenticate("alice", "alice-pw")
+	if !errors.Is(err, ErrNotMine) {
+		t.Fatalf("call without token should be ErrNotMine : %v", err)
+	}
+
+	auth.token = "helper-token"
+	role, err := auth.UserAuthenticate("alice", "alice-pw")
+	if err != nil || role != ROLE_OPERATOR {
+		t.Fatalf("call with token : %s, %v", role, err)
+	}
+}
+
+func TestLdapLookupUser(t *testing.T) {
+	server := newTestLdapHelper(t)
+	auth := newTestLdapAuthenticator(t, server, valueAuthLdapHelperProtocolV2, 0)
+
+	profile, err := auth.LookupUser("alice")
+	if err != nil {
+		t.Fatalf("lookup fail : %s", err.Error())
+	}
+	want := UserProfile{Id: "alice", DisplayName: "Alice", Email: "alice@example.com", Groups: []string{"dev-backend", "ops"}}
+	if !reflect.DeepEqual(profile, want) {
+		t.Fatalf("profile %+v, want %+v", profile, want)
+	}
+
+	// disabled user is still visible in directory
+	if profile, err = auth.LookupUser("carol"); err != nil || profile.Id != "carol" {
+		t.Fatalf("lookup disabled user : %+v, %v", profile, err)
+	}
+
+	if _, err = auth.LookupUser("mallory"); !errors.Is(err, ErrNotMine) {
+		t.Fatalf("lookup unknown user should be ErrNotMine : %v", err)
+	}
+}
+
+func TestLdapListGroups(t *testing.T) {
+	server := newTestLdapHelper(t)
+	server.AddGroup("admin", "administrators")
+	auth := newTestLdapAuthenticator(t, server, valueAuthLdapHelperProtocolV2, 0)
+
+	cases := map[string][]string{
+		"":        {"admin", "dev-backend", "dev-frontend", "ops"},
+		"dev-":    {"dev-backend", "dev-frontend"},
+		"unknown": {},
+	}
+	for prefix, want := range cases {
+		groups, err := auth.ListGroups(prefix)
+		if err != nil {
+			t.Fatalf("prefix %q : %s", prefix, err.Error())
+		}
+		if !reflect.DeepEqual(groups, want) {
+			t.Fatalf("prefix %q : groups %v, want %v", prefix, groups, want)
+		}
+	}
+}
+
+func TestLdapValidateUser(t *testing.T) {
+	server := newTestLdapHelper(t)
+	auth := newTestLdapAuthenticator(t, server, valueAuthLdapHelperProtocolV2, 0)
+
+	if role, err := auth.ValidateUser("bob"); err != nil || role != ROLE_MONITOR {
+		t.Fatalf("validate bob : %s, %v", role, err)
+	}
+	if _, err := auth.ValidateUser("carol"); err == nil || errors.Is(err, ErrNotMine) {
+		t.Fatalf("disabled user should be rejected : %v", err)
+	}
+	if _, err := auth.ValidateUser("mallory"); !errors.Is(err, ErrNotMine) {
+		t.Fatalf("unknown user should be ErrNotMine : %v", err)
+	}
+}
+
+func TestLdapDirectoryByProtocol(t *testing.T) {
+	server := newTestLdapHelper(t)
+
+	if newTestLdapAuthenticator(t, server, valueAuthLdapHelperProtocolV1, 0).Directory() != nil {
+		t.Fatalf("v1 helper should not provide directory")
+	}
+	if newTestLdapAuthenticator(t, server, valueAuthLdapHelperProtocolV2, 0).Directory() == nil {
+		t.Fatalf("v2 helper should provide directory")
+	}
+}
+
+func TestLdapAuthenticateV1Fallback(t *testing.T) {
+	server := newTestLdapHelper(t)
+	auth := newTestLdapAuthenticator(t, server, valueAuthLdapHelperProtocolV1, 2)
+
+	// v1 call to helper which speaks only v2 is passed to next authenticator
+	for i := 0; i < 2; i++ {
+		_, err := auth.UserAuthenticate("alice", "alice-pw")
+		if !errors.Is(err, ErrNotMine) {
+			t.Fatalf("v1 call to v2 helper should be ErrNotMine : %v", err)
+		}
+	}
+
+	// circuit is opened after threshold and helper is not called anymore
+	_, err := auth.UserAuthenticate("alice", "alice-pw")
+	if !errors.Is(err, ErrNotMine) || !strings.Contains(err.Error(), "circuit is open") {
+		t.Fatalf("open circuit should be ErrNotMine : %v", err)
+	}
+	if server.Calls("Authenticate") != 0 {
+		t.Fatalf("v2 Authenticate is called by v1 protocol")
+	}
+}
+
+func TestLdapHealthProbe(t *testing.T) {
+	server := newTestLdapHelper(t)
+	auth := newTestLdapAuthenticator(t, server, valueAuthLdapHelperProtocolV2, 1)
+	auth.breaker.openDuration = 0
+
+	// open circuit and make next call a probe
+	auth.breaker.Failure()
+	server.SetServing(false)
+	if _, err := auth.UserAuthenticate("alice", "alice-pw"); !errors.Is(err, ErrNotMine) {
+		t.Fatalf("probe to not serving helper should be ErrNotMine : %v", err)
+	}
+	if server.Calls("Authenticate") != 0 {
+		t.Fatalf("helper is called although health check failed")
+	}
+
+	server.SetServing(true)
+	if role, err := auth.UserAuthenticate("alice", "alice-pw"); err != nil || role != ROLE_OPERATOR {
+		t.Fatalf("probe to serving helper : %s, %v", role, err)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 11:58
+ */
+
+package auth
+
+import (
+	"github.com/fatima-go/fatima-log"
+	"sync"
+	"time"
+)
+
+const (
+	breakerClosed = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// circuitBreaker opens after consecutive failures and rejects calls during open duration.
+// after that, single probe call is allowed (half open). success of probe closes breaker
+type circuitBreaker struct {
+	name         string
+	threshold    int
+	openDuration time.Duration
+	state        int
+	failures     int
+	openedAt     time.Time
+	mutex        sync.Mutex
+}
+
+func newCircuitBreaker(name string, threshold int, openDuration time.Duration) *circuitBreaker {
+	return &circuitBreaker{name: name, threshold: threshold, openDuration: openDuration}
+}
+
+// Allow returns whether call is allowed. probe is true when the call decides state of half open breaker
+func (b *circuitBreaker) Allow() (allowed bool, probe bool) {
+	if b.threshold <= 0 {
+		return true, false
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.openDuration {
+			return false, false
+		}
+		b.state = breakerHalfOpen
+		return true, true
+	case breakerHalfOpen:
+		return false, false
+	}
+	return true, false
+}
+
+func (b *circuitBreaker) Success() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.state != breakerClosed {
+		log.Info("%s circuit closed", b.name)
+	}
+	b.state = breakerClosed
+	b.failures = 0
+}
+
+func (b *circuitBreaker) Failure() {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.failures++
+	if b.state == breakerHalfOpen || (b.state == breakerClosed && b.failures >= b.threshold) {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+		log.Warn("%s circuit opened for %s after %d failures", b.name, b.openDuration, b.failures)
+	}
+}
@@ -0,0 +1,99 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 6:40
+ */
+package auth
+
+import (
+	"errors"
+	. "github.com/fatima-go/jupiter/domain"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	type step struct {
+		action  string // allow, success, failure, wait
+		allowed bool
+		probe   bool
+	}
+	cases := []struct {
+		name      string
+		threshold int
+		steps     []step
+	}{
+		{"disabled", 0, []step{
+			{"failure", false, false}, {"failure", false, false}, {"allow", true, false},
+		}},
+		{"opens at threshold", 2, []step{
+			{"failure", false, false}, {"allow", true, false}, {"failure", false, false}, {"allow", false, false},
+		}},
+		{"success resets failures", 2, []step{
+			{"failure", false, false}, {"success", false, false}, {"failure", false, false}, {"allow", true, false},
+		}},
+		{"single probe after open duration", 1, []step{
+			{"failure", false, false}, {"wait", false, false}, {"allow", true, true}, {"allow", false, false},
+		}},
+		{"probe success closes", 1, []step{
+			{"failure", false, false}, {"wait", false, false}, {"allow", true, true}, {"success", false, false}, {"allow", true, false},
+		}},
+		{"probe failure opens again", 3, []step{
+			{"failure", false, false}, {"failure", false, false}, {"failure", false, false}, {"wait", false, false},
+			{"allow", true, true}, {"failure", false, false}, {"allow", false, false},
+		}},
+	}
+
+	for _, c := range cases {
+		breaker := newCircuitBreaker("test", c.threshold, time.Hour)
+		for i, s := range c.steps {
+			switch s.action {
+			case "allow":
+				allowed, probe := breaker.Allow()
+				if allowed != s.allowed || probe != s.probe {
+					t.Fatalf("%s : step %d allowed %t, probe %t", c.name, i, allowed, probe)
+				}
+			case "success":
+				breaker.Success()
+			case "failure":
+				breaker.Failure()
+			case "wait":
+				breaker.openedAt = breaker.openedAt.Add(-breaker.openDuration)
+			}
+		}
+	}
+}
+
+func TestLdapHelperDown(t *testing.T) {
+	server := newTestLdapHelper(t)
+	auth := newTestLdapAuthenticator(t, server, valueAuthLdapHelperProtocolV2, 2)
+	auth.callTimeout = time.Millisecond * 500
+	server.Close()
+
+	for i := 0; i < 3; i++ {
+		role, err := auth.UserAuthenticate("alice", "alice-pw")
+		if !errors.Is(err, ErrNotMine) || role != ROLE_UNKNOWN {
+			t.Fatalf("call %d to stopped helper should be ErrNotMine : %v", i, err)
+		}
+	}
+	if allowed, _ := auth.breaker.Allow(); allowed {
+		t.Fatalf("circuit is not opened by stopped helper")
+	}
+}
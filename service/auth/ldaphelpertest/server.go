@@ -0,0 +1,243 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 19. 오전 11:05
+ */
+// Package ldaphelpertest provides in-memory ldap helper speaking ldap.adapter.v2 protocol for testing.
+// users and groups are kept in memory and grpc health service is served together
+package ldaphelpertest
+
+import (
+	"context"
+	"fmt"
+	proto "github.com/fatima-go/jupiter/proto/ldap.adapter.v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// User is directory user. disabled user fails Authenticate and ValidateUser with DISABLED code
+type User struct {
+	Id          string
+	Password    string
+	Role        string
+	DisplayName string
+	Email       string
+	Groups      []string
+	Disabled    bool
+}
+
+type Server struct {
+	proto.UnimplementedLdapAdapterServiceServer
+	listener net.Listener
+	grpc     *grpc.Server
+	health   *health.Server
+	token    string
+	users    map[string]User
+	groups   map[string]string
+	calls    map[string]int
+	mutex    sync.RWMutex
+}
+
+// NewServer starts ldap helper on 127.0.0.1 with given users.
+// groups of users are registered to group list automatically
+func NewServer(users ...User) (*Server, error) {
+	s := &Server{users: make(map[string]User), groups: make(map[string]string), calls: make(map[string]int)}
+	for _, u := range users {
+		s.Add(u)
+	}
+
+	var err error
+	s.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s.grpc = grpc.NewServer(grpc.UnaryInterceptor(s.intercept))
+	s.health = health.NewServer()
+	proto.RegisterLdapAdapterServiceServer(s.grpc, s)
+	grpc_health_v1.RegisterHealthServer(s.grpc, s.health)
+
+	go s.grpc.Serve(s.listener)
+	return s, nil
+}
+
+// Addr returns ip address of server
+func (s *Server) Addr() string {
+	return s.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port returns listening port of server
+func (s *Server) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// RequireToken makes server reject call without `authorization: Bearer <token>` metadata
+func (s *Server) RequireToken(token string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.token = token
+}
+
+// SetServing changes status of grpc health service
+func (s *Server) SetServing(serving bool) {
+	if serving {
+		s.health.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+		return
+	}
+	s.health.SetServingStatus("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+}
+
+// Add adds or replaces user
+func (s *Server) Add(user User) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.users[user.Id] = user
+	for _, g := range user.Groups {
+		if _, ok := s.groups[g]; !ok {
+			s.groups[g] = ""
+		}
+	}
+}
+
+// AddGroup adds group with description
+func (s *Server) AddGroup(name string, description string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.groups[name] = description
+}
+
+// Calls returns number of calls of rpc method (e.g. ValidateUser)
+func (s *Server) Calls(method string) int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.calls[method]
+}
+
+func (s *Server) Close() {
+	s.grpc.Stop()
+}
+
+func (s *Server) intercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	s.mutex.Lock()
+	s.calls[info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]]++
+	token := s.token
+	s.mutex.Unlock()
+
+	if len(token) > 0 {
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get("authorization")
+		if len(values) == 0 || values[0] != "Bearer "+token {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) find(id string) (User, *proto.ResponseError) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if len(id) == 0 {
+		return User{}, newError(proto.ResponseError_BAD_PARAMETER, proto.ResponseError_ERROR_ETC, "empty id")
+	}
+	user, ok := s.users[id]
+	if !ok {
+		return User{}, newError(proto.ResponseError_NOT_FOUND, proto.ResponseError_NO_RECORD, fmt.Sprintf("not found %s", id))
+	}
+	if user.Disabled {
+		return user, newError(proto.ResponseError_FORBIDDEN, proto.ResponseError_DISABLED, fmt.Sprintf("%s is disabled", id))
+	}
+	return user, nil
+}
+
+func (s *Server) Authenticate(ctx context.Context, req *proto.AuthenticateRequest) (*proto.AuthenticateResponse, error) {
+	user, e := s.find(req.Id)
+	if e != nil {
+		return &proto.AuthenticateResponse{Response: &proto.AuthenticateResponse_Error{Error: e}}, nil
+	}
+	if len(req.Password) == 0 || user.Password != req.Password {
+		e = newError(proto.ResponseError_UNAUTORIZED, proto.ResponseError_ERROR_RESPONSE, "invalid credentials")
+		return &proto.AuthenticateResponse{Response: &proto.AuthenticateResponse_Error{Error: e}}, nil
+	}
+
+	return &proto.AuthenticateResponse{
+		Response: &proto.AuthenticateResponse_Success{Success: &proto.ResponseSuccess{}},
+		Role:     user.Role,
+		User:     toUserInfo(user),
+	}, nil
+}
+
+func (s *Server) LookupUser(ctx context.Context, req *proto.LookupUserRequest) (*proto.LookupUserResponse, error) {
+	user, e := s.find(req.Id)
+	if e != nil && e.Code != proto.ResponseError_DISABLED {
+		return &proto.LookupUserResponse{Response: &proto.LookupUserResponse_Error{Error: e}}, nil
+	}
+
+	return &proto.LookupUserResponse{
+		Response: &proto.LookupUserResponse_Success{Success: &proto.ResponseSuccess{}},
+		User:     toUserInfo(user),
+	}, nil
+}
+
+func (s *Server) ListGroups(ctx context.Context, req *proto.ListGroupsRequest) (*proto.ListGroupsResponse, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	groups := make([]*proto.GroupInfo, 0)
+	for name, desc := range s.groups {
+		if strings.HasPrefix(name, req.Prefix) {
+			groups = append(groups, &proto.GroupInfo{Name: name, Description: desc})
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+
+	return &proto.ListGroupsResponse{
+		Response: &proto.ListGroupsResponse_Success{Success: &proto.ResponseSuccess{}},
+		Groups:   groups,
+	}, nil
+}
+
+func (s *Server) ValidateUser(ctx context.Context, req *proto.ValidateUserRequest) (*proto.ValidateUserResponse, error) {
+	user, e := s.find(req.Id)
+	if e != nil {
+		return &proto.ValidateUserResponse{Response: &proto.ValidateUserResponse_Error{Error: e}}, nil
+	}
+
+	return &proto.ValidateUserResponse{
+		Response: &proto.ValidateUserResponse_Success{Success: &proto.ResponseSuccess{}},
+		Role:     user.Role,
+	}, nil
+}
+
+func toUserInfo(user User) *proto.UserInfo {
+	return &proto.UserInfo{Id: user.Id, DisplayName: user.DisplayName, Email: user.Email, Groups: user.Groups}
+}
+
+func newError(response proto.ResponseError_GrpcResponse, code proto.ResponseError_ErrorCode, desc string) *proto.ResponseError {
+	return &proto.ResponseError{GrpcResponse: response, Code: code, Desc: desc}
+}
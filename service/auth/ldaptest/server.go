@@ -0,0 +1,437 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 11:50
+ */
+
+// Package ldaptest provides small in-process LDAP server for testing directory authentication.
+// it supports simple bind, search (and, or, not, equality, substrings, present filters),
+// LDAPS and StartTLS with self-signed certificate
+package ldaptest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	appBindRequest      = 0
+	appBindResponse     = 1
+	appUnbindRequest    = 2
+	appSearchRequest    = 3
+	appSearchEntry      = 4
+	appSearchDone       = 5
+	appExtendedRequest  = 23
+	appExtendedResponse = 24
+
+	resultSuccess            = 0
+	resultProtocolError      = 2
+	resultNoSuchObject       = 32
+	resultInvalidCredentials = 49
+	resultUnwillingToPerform = 53
+
+	oidStartTLS = "1.3.6.1.4.1.1466.20037"
+)
+
+// Entry is directory entry. Password is used for simple bind of DN
+type Entry struct {
+	DN         string
+	Password   string
+	Attributes map[string][]string
+}
+
+func (e Entry) values(name string) []string {
+	for k, v := range e.Attributes {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return nil
+}
+
+type Server struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	caPEM     []byte
+	secure    bool
+	entries   []Entry
+	mutex     sync.RWMutex
+	wg        sync.WaitGroup
+}
+
+// NewServer starts plain LDAP server on 127.0.0.1. StartTLS is available
+func NewServer(entries ...Entry) (*Server, error) {
+	return newServer(false, entries)
+}
+
+// NewTLSServer starts LDAPS server on 127.0.0.1
+func NewTLSServer(entries ...Entry) (*Server, error) {
+	return newServer(true, entries)
+}
+
+func newServer(secure bool, entries []Entry) (*Server, error) {
+	s := &Server{secure: secure, entries: entries}
+
+	var err error
+	s.tlsConfig, s.caPEM, err = generateTlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	s.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	if secure {
+		s.listener = tls.NewListener(s.listener, s.tlsConfig)
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// URL returns ldap:// or ldaps:// url of server
+func (s *Server) URL() string {
+	if s.secure {
+		return "ldaps://" + s.listener.Addr().String()
+	}
+	return "ldap://" + s.listener.Addr().String()
+}
+
+// CACertificate returns PEM encoded certificate of server which clients should trust
+func (s *Server) CACertificate() []byte {
+	return s.caPEM
+}
+
+func (s *Server) Add(entry Entry) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.entries = append(s.entries, entry)
+}
+
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer func() { conn.Close() }()
+
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+
+		messageId, _ := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+		switch op.Tag {
+		case appBindRequest:
+			writeResult(conn, messageId, appBindResponse, s.bind(op))
+		case appUnbindRequest:
+			return
+		case appSearchRequest:
+			code := s.search(conn, messageId, op)
+			writeResult(conn, messageId, appSearchDone, code)
+		case appExtendedRequest:
+			if len(op.Children) == 0 || op.Children[0].Data.String() != oidStartTLS {
+				writeResult(conn, messageId, appExtendedResponse, resultProtocolError)
+				continue
+			}
+			if s.secure {
+				writeResult(conn, messageId, appExtendedResponse, resultUnwillingToPerform)
+				continue
+			}
+			writeResult(conn, messageId, appExtendedResponse, resultSuccess)
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+		default:
+			// abandon and others are ignored
+		}
+	}
+}
+
+func (s *Server) bind(op *ber.Packet) int {
+	if len(op.Children) < 3 {
+		return resultProtocolError
+	}
+
+	dn := stringOf(op.Children[1])
+	password := op.Children[2].Data.String()
+	if len(dn) == 0 && len(password) == 0 {
+		return resultSuccess // anonymous
+	}
+
+	entry, ok := s.find(dn)
+	if !ok || len(entry.Password) == 0 || entry.Password != password {
+		return resultInvalidCredentials
+	}
+	return resultSuccess
+}
+
+func (s *Server) find(dn string) (Entry, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	comp := normalizeDn(dn)
+	for _, e := range s.entries {
+		if normalizeDn(e.DN) == comp {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+func (s *Server) search(conn net.Conn, messageId int64, op *ber.Packet) int {
+	if len(op.Children) < 8 {
+		return resultProtocolError
+	}
+
+	base := normalizeDn(stringOf(op.Children[0]))
+	scope, _ := op.Children[1].Value.(int64)
+	filter := op.Children[6]
+	attributes := make([]string, 0)
+	for _, a := range op.Children[7].Children {
+		attributes = append(attributes, stringOf(a))
+	}
+
+	s.mutex.RLock()
+	entries := append([]Entry(nil), s.entries...)
+	s.mutex.RUnlock()
+
+	if _, ok := s.find(base); !ok && len(base) > 0 {
+		return resultNoSuchObject
+	}
+
+	for _, e := range entries {
+		if !inScope(normalizeDn(e.DN), base, scope) || !matches(e, filter) {
+			continue
+		}
+		writeEntry(conn, messageId, e, attributes)
+	}
+	return resultSuccess
+}
+
+func inScope(dn string, base string, scope int64) bool {
+	switch scope {
+	case 0:
+		return dn == base
+	case 1:
+		idx := strings.Index(dn, ",")
+		return idx > 0 && dn[idx+1:] == base
+	}
+	return len(base) == 0 || dn == base || strings.HasSuffix(dn, ","+base)
+}
+
+func matches(e Entry, filter *ber.Packet) bool {
+	switch filter.Tag {
+	case 0: // and
+		for _, f := range filter.Children {
+			if !matches(e, f) {
+				return false
+			}
+		}
+		return true
+	case 1: // or
+		for _, f := range filter.Children {
+			if matches(e, f) {
+				return true
+			}
+		}
+		return false
+	case 2: // not
+		return len(filter.Children) == 1 && !matches(e, filter.Children[0])
+	case 3: // equality
+		if len(filter.Children) != 2 {
+			return false
+		}
+		want := stringOf(filter.Children[1])
+		for _, v := range e.values(stringOf(filter.Children[0])) {
+			if strings.EqualFold(v, want) {
+				return true
+			}
+		}
+		return false
+	case 4: // substrings
+		if len(filter.Children) != 2 {
+			return false
+		}
+		for _, v := range e.values(stringOf(filter.Children[0])) {
+			if matchSubstrings(strings.ToLower(v), filter.Children[1].Children) {
+				return true
+			}
+		}
+		return false
+	case 7: // present
+		name := filter.Data.String()
+		return strings.EqualFold(name, "objectClass") || len(e.values(name)) > 0
+	}
+	return false
+}
+
+func matchSubstrings(value string, parts []*ber.Packet) bool {
+	for _, p := range parts {
+		sub := strings.ToLower(p.Data.String())
+		switch p.Tag {
+		case 0: // initial
+			if !strings.HasPrefix(value, sub) {
+				return false
+			}
+			value = value[len(sub):]
+		case 1: // any
+			idx := strings.Index(value, sub)
+			if idx < 0 {
+				return false
+			}
+			value = value[idx+len(sub):]
+		case 2: // final
+			if !strings.HasSuffix(value, sub) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func writeEntry(conn net.Conn, messageId int64, e Entry, attributes []string) {
+	all := len(attributes) == 0
+	for _, a := range attributes {
+		if a == "*" {
+			all = true
+		}
+	}
+
+	attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attributes")
+	for k, v := range e.Attributes {
+		if !all && !containsFold(attributes, k) {
+			continue
+		}
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attribute")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, k, "type"))
+		values := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "values")
+		for _, value := range v {
+			values.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "value"))
+		}
+		attr.AppendChild(values)
+		attrs.AppendChild(attr)
+	}
+
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, appSearchEntry, nil, "search result entry")
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.DN, "dn"))
+	op.AppendChild(attrs)
+	writeMessage(conn, messageId, op)
+}
+
+func writeResult(conn net.Conn, messageId int64, tag ber.Tag, code int) {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "result")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "code"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matched dn"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "message"))
+	writeMessage(conn, messageId, op)
+}
+
+func writeMessage(conn net.Conn, messageId int64, op *ber.Packet) {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "message")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageId, "id"))
+	packet.AppendChild(op)
+	conn.Write(packet.Bytes())
+}
+
+func stringOf(p *ber.Packet) string {
+	if v, ok := p.Value.(string); ok {
+		return v
+	}
+	return p.Data.String()
+}
+
+func containsFold(list []string, value string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeDn(dn string) string {
+	parts := strings.Split(dn, ",")
+	for i := range parts {
+		parts[i] = strings.ToLower(strings.TrimSpace(parts[i]))
+	}
+	return strings.Join(parts, ",")
+}
+
+// generateTlsConfig creates self-signed certificate for 127.0.0.1 and localhost
+func generateTlsConfig() (*tls.Config, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "ldaptest"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour * 24),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:              []string{"localhost"},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fail to create certificate : %s", err.Error())
+	}
+
+	cert := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, caPEM, nil
+}
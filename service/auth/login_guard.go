@@ -0,0 +1,227 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 10:15
+ */
+
+package auth
+
+import (
+	"github.com/fatima-go/fatima-core"
+	"github.com/fatima-go/fatima-log"
+	. "github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/infra"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	propLockoutThreshold          = "auth.lockout.threshold"
+	defaultLockoutThreshold       = 5
+	propLockoutClientThreshold    = "auth.lockout.client.threshold"
+	defaultLockoutClientThreshold = 20
+	propLockoutSeconds            = "auth.lockout.seconds"
+	defaultLockoutSeconds         = 300
+	propLockoutBackoffMillis      = "auth.lockout.backoff.millis"
+	defaultLockoutBackoffMillis   = 1000
+
+	loginGuardCleanupInterval = time.Minute
+)
+
+// NewLoginGuard creates guard which counts failed logins per user id and per client address.
+// each failure delays next attempt exponentially (backoff, 2*backoff, 4*backoff...)
+// and reaching threshold locks the user id or client address for auth.lockout.seconds.
+// threshold 0 disables the guard
+func NewLoginGuard(fatimaRuntime fatima.FatimaRuntime) *LoginGuard {
+	g := &LoginGuard{}
+	g.users = make(map[string]*loginFailure)
+	g.clients = make(map[string]*loginFailure)
+
+	var err error
+	g.threshold, err = fatimaRuntime.GetConfig().GetInt(propLockoutThreshold)
+	if err != nil {
+		g.threshold = defaultLockoutThreshold
+	}
+	g.clientThreshold, err = fatimaRuntime.GetConfig().GetInt(propLockoutClientThreshold)
+	if err != nil {
+		g.clientThreshold = defaultLockoutClientThreshold
+	}
+	d1, err := fatimaRuntime.GetConfig().GetInt(propLockoutSeconds)
+	if err != nil {
+		d1 = defaultLockoutSeconds
+	}
+	g.lockoutDuration = time.Second * time.Duration(d1)
+	d2, err := fatimaRuntime.GetConfig().GetInt(propLockoutBackoffMillis)
+	if err != nil {
+		d2 = defaultLockoutBackoffMillis
+	}
+	g.backoff = time.Millisecond * time.Duration(d2)
+
+	log.Info("login guard. threshold : %d, client.threshold : %d, lockout : %d seconds, backoff : %d millis",
+		g.threshold, g.clientThreshold, d1, d2)
+
+	cleanupTick := time.NewTicker(loginGuardCleanupInterval)
+	go func() {
+		for range cleanupTick.C {
+			g.cleanup()
+		}
+	}()
+
+	return g
+}
+
+type loginFailure struct {
+	count       int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// retryAfter returns remaining duration until next attempt is allowed
+func (f *loginFailure) retryAfter(now time.Time, backoff time.Duration, limit time.Duration) time.Duration {
+	if now.Before(f.lockedUntil) {
+		return f.lockedUntil.Sub(now)
+	}
+	if f.count == 0 || backoff <= 0 {
+		return 0
+	}
+
+	delay := backoff << uint(f.count-1)
+	if delay <= 0 || delay > limit {
+		delay = limit
+	}
+	return f.lastFailure.Add(delay).Sub(now)
+}
+
+type LoginGuard struct {
+	threshold       int
+	clientThreshold int
+	lockoutDuration time.Duration
+	backoff         time.Duration
+	users           map[string]*loginFailure
+	clients         map[string]*loginFailure
+	mutex           sync.Mutex
+}
+
+// Check returns LockoutError when user id or client address is locked or in backoff
+func (g *LoginGuard) Check(userId string, clientAddress string) error {
+	if g.threshold <= 0 {
+		return nil
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	now := time.Now()
+	if f, ok := g.users[userId]; ok {
+		if wait := f.retryAfter(now, g.backoff, g.lockoutDuration); wait > 0 {
+			return LockoutError{Kind: LOCKOUT_KIND_USER, Key: userId, RetryAfter: wait}
+		}
+	}
+
+	client := infra.ExtractIpAddress(clientAddress)
+	if f, ok := g.clients[client]; ok && now.Before(f.lockedUntil) {
+		return LockoutError{Kind: LOCKOUT_KIND_CLIENT, Key: client, RetryAfter: f.lockedUntil.Sub(now)}
+	}
+
+	return nil
+}
+
+func (g *LoginGuard) Failure(userId string, clientAddress string) {
+	if g.threshold <= 0 {
+		return
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.fail(LOCKOUT_KIND_USER, g.users, userId, g.threshold)
+	g.fail(LOCKOUT_KIND_CLIENT, g.clients, infra.ExtractIpAddress(clientAddress), g.clientThreshold)
+}
+
+func (g *LoginGuard) fail(kind string, failures map[string]*loginFailure, key string, threshold int) {
+	now := time.Now()
+	f, ok := failures[key]
+	if !ok {
+		f = &loginFailure{}
+		failures[key] = f
+	}
+
+	f.count++
+	f.lastFailure = now
+	if threshold > 0 && f.count >= threshold && !now.Before(f.lockedUntil) {
+		f.lockedUntil = now.Add(g.lockoutDuration)
+		log.Warn("%s %s is locked until %s after %d failed logins", kind, key, f.lockedUntil.Format(TIME_YYYYMMDDHHMMSS), f.count)
+	}
+}
+
+// Success clears failures of user id. failures of client address remains until they expire
+func (g *LoginGuard) Success(userId string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	delete(g.users, userId)
+}
+
+func (g *LoginGuard) FindAllLockouts() []Lockout {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	list := make([]Lockout, 0)
+	for k, f := range g.users {
+		list = append(list, Lockout{Kind: LOCKOUT_KIND_USER, Key: k, Failures: f.count, LastFailure: f.lastFailure, LockedUntil: f.lockedUntil})
+	}
+	for k, f := range g.clients {
+		list = append(list, Lockout{Kind: LOCKOUT_KIND_CLIENT, Key: k, Failures: f.count, LastFailure: f.lastFailure, LockedUntil: f.lockedUntil})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].LastFailure.After(list[j].LastFailure) })
+	return list
+}
+
+// Unlock clears failures of user id or client address
+func (g *LoginGuard) Unlock(kind string, key string) bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	failures := g.users
+	if kind == LOCKOUT_KIND_CLIENT {
+		failures = g.clients
+	}
+
+	if _, ok := failures[key]; !ok {
+		return false
+	}
+	delete(failures, key)
+	return true
+}
+
+// cleanup removes failures which are neither locked nor recent
+func (g *LoginGuard) cleanup() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	now := time.Now()
+	for _, failures := range []map[string]*loginFailure{g.users, g.clients} {
+		for k, f := range failures {
+			if now.After(f.lockedUntil) && now.Sub(f.lastFailure) > g.lockoutDuration {
+				delete(failures, k)
+			}
+		}
+	}
+}
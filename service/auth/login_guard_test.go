@@ -0,0 +1,153 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 6:00
+ */
+package auth
+
+import (
+	"errors"
+	. "github.com/fatima-go/jupiter/domain"
+	"testing"
+	"time"
+)
+
+func newTestLoginGuard(threshold int, clientThreshold int) *LoginGuard {
+	return &LoginGuard{
+		threshold:       threshold,
+		clientThreshold: clientThreshold,
+		lockoutDuration: time.Minute,
+		users:           make(map[string]*loginFailure),
+		clients:         make(map[string]*loginFailure),
+	}
+}
+
+func lockoutKind(err error) string {
+	var lockout LockoutError
+	if errors.As(err, &lockout) {
+		return lockout.Kind
+	}
+	return ""
+}
+
+func TestLoginGuardUserLockout(t *testing.T) {
+	guard := newTestLoginGuard(3, 100)
+
+	for i := 1; i <= 3; i++ {
+		if err := guard.Check("alice", "10.0.0.1:5000"); err != nil {
+			t.Fatalf("alice is locked after %d failures", i-1)
+		}
+		guard.Failure("alice", "10.0.0.1:5000")
+	}
+
+	if kind := lockoutKind(guard.Check("alice", "10.0.0.2:5000")); kind != LOCKOUT_KIND_USER {
+		t.Fatalf("alice is not locked after 3 failures")
+	}
+	if err := guard.Check("bob", "10.0.0.1:5000"); err != nil {
+		t.Fatalf("other user is locked : %s", err.Error())
+	}
+
+	if !guard.Unlock(LOCKOUT_KIND_USER, "alice") {
+		t.Fatalf("fail to unlock alice")
+	}
+	if err := guard.Check("alice", "10.0.0.1:5000"); err != nil {
+		t.Fatalf("unlocked alice is locked : %s", err.Error())
+	}
+	if guard.Unlock(LOCKOUT_KIND_USER, "alice") {
+		t.Fatalf("alice is unlocked again")
+	}
+
+	// login success clears failures
+	guard.Failure("alice", "10.0.0.1:5000")
+	guard.Failure("alice", "10.0.0.1:5000")
+	guard.Success("alice")
+	guard.Failure("alice", "10.0.0.1:5000")
+	if err := guard.Check("alice", "10.0.0.1:5000"); err != nil {
+		t.Fatalf("failures before success are counted : %s", err.Error())
+	}
+}
+
+func TestLoginGuardClientLockout(t *testing.T) {
+	guard := newTestLoginGuard(3, 4)
+
+	for _, user := range []string{"u1", "u2", "u3", "u4"} {
+		guard.Failure(user, "10.0.0.1:5000")
+	}
+
+	if kind := lockoutKind(guard.Check("alice", "10.0.0.1:6000")); kind != LOCKOUT_KIND_CLIENT {
+		t.Fatalf("client is not locked after 4 failures")
+	}
+	if err := guard.Check("alice", "10.0.0.2:5000"); err != nil {
+		t.Fatalf("other client is locked : %s", err.Error())
+	}
+
+	// success of user does not clear client failures
+	guard.Success("u1")
+	if err := guard.Check("alice", "10.0.0.1:5000"); err == nil {
+		t.Fatalf("client is unlocked by login success")
+	}
+
+	if !guard.Unlock(LOCKOUT_KIND_CLIENT, "10.0.0.1") {
+		t.Fatalf("fail to unlock client")
+	}
+	if err := guard.Check("alice", "10.0.0.1:5000"); err != nil {
+		t.Fatalf("unlocked client is locked : %s", err.Error())
+	}
+
+	// failures of u2, u3 and u4 remain
+	if list := guard.FindAllLockouts(); len(list) != 3 {
+		t.Fatalf("expected 3 lockouts but %d", len(list))
+	}
+}
+
+func TestLoginGuardDisabled(t *testing.T) {
+	guard := newTestLoginGuard(0, 0)
+	for i := 0; i < 10; i++ {
+		guard.Failure("alice", "10.0.0.1:5000")
+	}
+	if err := guard.Check("alice", "10.0.0.1:5000"); err != nil {
+		t.Fatalf("disabled guard locks user : %s", err.Error())
+	}
+}
+
+func TestLoginFailureRetryAfter(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name     string
+		failure  loginFailure
+		backoff  time.Duration
+		expected time.Duration
+	}{
+		{"no failure", loginFailure{}, time.Second, 0},
+		{"first failure", loginFailure{count: 1, lastFailure: now}, time.Second, time.Second},
+		{"third failure", loginFailure{count: 3, lastFailure: now}, time.Second, time.Second * 4},
+		{"limited backoff", loginFailure{count: 10, lastFailure: now}, time.Second, time.Minute},
+		{"overflow backoff", loginFailure{count: 80, lastFailure: now}, time.Second, time.Minute},
+		{"passed backoff", loginFailure{count: 1, lastFailure: now.Add(-time.Second * 3)}, time.Second, -time.Second * 2},
+		{"no backoff", loginFailure{count: 3, lastFailure: now}, 0, 0},
+		{"locked", loginFailure{count: 5, lastFailure: now, lockedUntil: now.Add(time.Second * 30)}, 0, time.Second * 30},
+	}
+
+	for _, c := range cases {
+		if wait := c.failure.retryAfter(now, c.backoff, time.Minute); wait != c.expected {
+			t.Fatalf("%s : retry after %s, expected %s", c.name, wait, c.expected)
+		}
+	}
+}
@@ -25,19 +25,28 @@ package auth
 
 import (
 	"errors"
+	"fmt"
 	"github.com/fatima-go/fatima-core"
-	"github.com/fatima-go/fatima-core/lib"
 	"github.com/fatima-go/fatima-log"
 	. "github.com/fatima-go/jupiter/domain"
 	"github.com/fatima-go/jupiter/infra"
+	"sort"
+	"strings"
 	"time"
 )
 
-func NewTokenHelper(fatimaRuntime fatima.FatimaRuntime) TokenService {
+// NewTokenHelper creates TokenService which issues random token kept in token repository(token.repo).
+// refresh reloads grant of user with loadGrant
+func NewTokenHelper(fatimaRuntime fatima.FatimaRuntime, loadGrant GrantLoader) (TokenService, error) {
 	t := &TokenHelper{}
-	t.tokenRepository = infra.NewMemoryTokenRepository()
+	t.loadGrant = loadGrant
 
 	var err error
+	t.tokenRepository, err = newTokenRepository(fatimaRuntime)
+	if err != nil {
+		return nil, err
+	}
+
 	var d1, d2 int
 	d1, err = fatimaRuntime.GetConfig().GetInt(propTokenDurationSeconds)
 	if err != nil {
@@ -52,47 +61,290 @@ func NewTokenHelper(fatimaRuntime fatima.FatimaRuntime) TokenService {
 	t.instantDurationSeconds = time.Second * time.Duration(d2)
 
 	log.Info("duration : %d seconds, instant.duration : %d seconds", d1, d2)
-	return t
+
+	t.sliding, err = fatimaRuntime.GetConfig().GetBool(propTokenSliding)
+	if err != nil {
+		t.sliding = false
+	}
+	if t.sliding {
+		d3, err := fatimaRuntime.GetConfig().GetInt(propTokenSlidingMaxSeconds)
+		if err != nil {
+			d3 = defaultTokenSlidingMaxSeconds
+		}
+		t.slidingMaxSeconds = time.Second * time.Duration(d3)
+		log.Info("sliding expiry enabled. max : %d seconds", d3)
+	}
+
+	d4, err := fatimaRuntime.GetConfig().GetInt(propTokenRefreshDurationSeconds)
+	if err != nil {
+		d4 = defaultTokenRefreshDurationSeconds
+	}
+	t.refreshDurationSeconds = time.Second * time.Duration(d4)
+	t.refreshMaxSeconds = loadRefreshMaxSeconds(fatimaRuntime)
+	log.Info("refresh.duration : %d seconds, refresh.max : %d seconds", d4, t.refreshMaxSeconds/time.Second)
+
+	d5, err := fatimaRuntime.GetConfig().GetInt(propTokenDurationPendingSeconds)
+	if err != nil {
+		d5 = defaultTokenDurationPendingSeconds
+	}
+	t.pendingDurationSeconds = time.Second * time.Duration(d5)
+	return t, nil
+}
+
+// token.repo=file
+// token repository follows repo=sql when token.repo is not set. otherwise memory is used
+func newTokenRepository(fatimaRuntime fatima.FatimaRuntime) (TokenRepository, error) {
+	repo, ok := fatimaRuntime.GetConfig().GetValue(propTokenRepo)
+	if !ok {
+		repo, ok = fatimaRuntime.GetConfig().GetValue(propRepo)
+		if !ok || strings.ToLower(repo) != valueTokenRepoSql {
+			repo = valueTokenRepoMemory
+		}
+	}
+
+	switch strings.ToLower(repo) {
+	case valueTokenRepoMemory:
+		return infra.NewMemoryTokenRepository(), nil
+	case valueTokenRepoFile:
+		log.Info("using file token repository")
+		return infra.NewFileTokenRepository(fatimaRuntime), nil
+	case valueTokenRepoSql:
+		database, err := infra.NewSqlDatabase(fatimaRuntime)
+		if err != nil {
+			return nil, fmt.Errorf("fail to open token database : %s", err.Error())
+		}
+		log.Info("using sql token repository")
+		return infra.NewSqlTokenRepository(database), nil
+	}
+	return nil, fmt.Errorf("unknown token repository %s", repo)
 }
 
 const (
+	propRepo                           = "repo"
+	propTokenRepo                      = "token.repo"
+	valueTokenRepoMemory               = "memory"
+	valueTokenRepoFile                 = "file"
+	valueTokenRepoSql                  = "sql"
 	propTokenDurationSeconds           = "token.duration.seconds"
 	propTokenDurationInstantSeconds    = "token.duration.instant.seconds"
 	defaultTokenDurationSeconds        = 3600
 	defaultTokenDurationInstantSeconds = 10
+	propTokenSliding                   = "token.sliding"
+	propTokenSlidingMaxSeconds         = "token.sliding.max.seconds"
+	defaultTokenSlidingMaxSeconds      = 43200
+	propTokenRefreshDurationSeconds    = "token.refresh.duration.seconds"
+	defaultTokenRefreshDurationSeconds = 86400
+	propTokenRefreshMaxSeconds         = "token.refresh.max.seconds"
+	defaultTokenRefreshMaxSeconds      = 604800
+	propTokenDurationPendingSeconds    = "token.duration.pending.seconds"
+	defaultTokenDurationPendingSeconds = 120
+
+	// sliding expiry is written only when token gains at least this duration
+	slidingExtendThreshold = time.Minute
+	tokenLength            = 64
 )
 
+// token.refresh.max.seconds=604800
+// 0 allows refresh without limit
+func loadRefreshMaxSeconds(fatimaRuntime fatima.FatimaRuntime) time.Duration {
+	d, err := fatimaRuntime.GetConfig().GetInt(propTokenRefreshMaxSeconds)
+	if err != nil {
+		d = defaultTokenRefreshMaxSeconds
+	}
+	return time.Second * time.Duration(d)
+}
+
+// checkRefreshLifetime refuses refresh when login of owner is older than max
+func checkRefreshLifetime(owner Session, max time.Duration) error {
+	if max > 0 && time.Since(owner.AuthTime()) > max {
+		return fmt.Errorf("refresh of user %s is over max lifetime. login again", owner.UserId)
+	}
+	return nil
+}
+
 type TokenHelper struct {
 	tokenRepository        TokenRepository
+	loadGrant              GrantLoader
 	instantDurationSeconds time.Duration
 	durationSeconds        time.Duration
+	sliding                bool
+	slidingMaxSeconds      time.Duration
+	refreshDurationSeconds time.Duration
+	refreshMaxSeconds      time.Duration
+	pendingDurationSeconds time.Duration
+}
+
+func (t *TokenHelper) GenerateInstantToken(owner Session) (string, error) {
+	token := infra.GenerateSecret(tokenLength)
+	owner.Kind = TOKEN_KIND_INSTANT
+	t.tokenRepository.Save(token, owner, t.instantDurationSeconds)
+	return token, nil
+}
+
+func (t *TokenHelper) GenerateToken(owner Session) (string, error) {
+	token := infra.GenerateSecret(tokenLength)
+	owner.Kind = TOKEN_KIND_ACCESS
+	owner.IssuedAt = time.Now()
+	if owner.AuthenticatedAt.IsZero() {
+		owner.AuthenticatedAt = owner.IssuedAt
+	}
+	t.tokenRepository.Save(token, owner, t.durationSeconds)
+	return token, nil
 }
 
-func (t *TokenHelper) GenerateInstantToken(role Role) (string, error) {
-	token := lib.RandomAlphanumeric(64)
-	t.tokenRepository.Save(token, role, t.instantDurationSeconds)
+// GenerateRefreshToken returns empty token when refresh is disabled (token.refresh.duration.seconds=0)
+func (t *TokenHelper) GenerateRefreshToken(owner Session) (string, error) {
+	if t.refreshDurationSeconds <= 0 {
+		return "", nil
+	}
+
+	token := infra.GenerateSecret(tokenLength)
+	owner.Kind = TOKEN_KIND_REFRESH
+	owner.IssuedAt = time.Now()
+	if owner.AuthenticatedAt.IsZero() {
+		owner.AuthenticatedAt = owner.IssuedAt
+	}
+	t.tokenRepository.Save(token, owner, t.refreshDurationSeconds)
 	return token, nil
 }
 
-func (t *TokenHelper) GenerateToken(role Role) (string, error) {
-	token := lib.RandomAlphanumeric(64)
-	t.tokenRepository.Save(token, role, t.durationSeconds)
+// GeneratePendingToken issues token which is only redeemable by second factor verification
+func (t *TokenHelper) GeneratePendingToken(owner Session) (string, error) {
+	token := infra.GenerateSecret(tokenLength)
+	owner.Kind = TOKEN_KIND_PENDING
+	owner.IssuedAt = time.Now()
+	t.tokenRepository.Save(token, owner, t.pendingDurationSeconds)
 	return token, nil
 }
 
-func (t *TokenHelper) ValidateToken(token string, role Role) error {
+func (t *TokenHelper) RedeemPendingToken(token string) (Session, error) {
+	if len(token) < 1 {
+		return Session{}, errors.New("invalid pending token")
+	}
+
+	owner, ok := t.tokenRepository.FindById(token)
+	if !ok || !owner.IsPending() {
+		return Session{}, errors.New("not found pending token")
+	}
+
+	t.tokenRepository.Delete(token)
+	return owner, nil
+}
+
+// RefreshToken issues new token pair with grant reloaded from user repository or directory.
+// given refresh token is revoked and single use. refresh is refused token.refresh.max.seconds after login
+func (t *TokenHelper) RefreshToken(refreshToken string) (string, string, error) {
+	if len(refreshToken) < 1 {
+		return "", "", errors.New("invalid refresh token")
+	}
+
+	owner, ok := t.tokenRepository.FindById(refreshToken)
+	if !ok || owner.IsExpired() {
+		return "", "", errors.New("not found refresh token")
+	}
+
+	if !owner.IsRefresh() {
+		return "", "", errors.New("not a refresh token")
+	}
+
+	err := checkRefreshLifetime(owner, t.refreshMaxSeconds)
+	if err != nil {
+		return "", "", err
+	}
+
+	grant, err := t.loadGrant(owner.UserId)
+	if err != nil {
+		return "", "", err
+	}
+
+	// only one of concurrent requests deletes refresh token and gets new tokens
+	if !t.tokenRepository.DeleteSession(owner.Id) {
+		return "", "", errors.New("refresh token is already used")
+	}
+
+	owner.Role = grant.Role
+	owner.Bindings = grant.Bindings
+	token, err := t.GenerateToken(owner)
+	if err != nil {
+		return "", "", err
+	}
+	refreshToken, err = t.GenerateRefreshToken(owner)
+	if err != nil {
+		return "", "", err
+	}
+
+	log.Info("token of user %s refreshed", owner.UserId)
+	return token, refreshToken, nil
+}
+
+func (t *TokenHelper) ValidateToken(token string, role Role) (Session, error) {
 	if len(token) < 1 {
-		return errors.New("invalid fatima token")
+		return Session{}, errors.New("invalid fatima token")
 	}
 
-	savedRole, ok := t.tokenRepository.FindById(token)
+	session, ok := t.tokenRepository.FindById(token)
 	if !ok {
-		return errors.New("not found fatima token")
+		return Session{}, errors.New("not found fatima token")
 	}
 
-	if !savedRole.Acceptable(role) {
-		return errors.New("insufficient previledge")
+	if session.IsRefresh() {
+		return Session{}, errors.New("refresh token is not acceptable")
 	}
 
-	return nil
+	if session.IsPending() {
+		return Session{}, errors.New("second factor is not verified")
+	}
+
+	if !session.Grant().HasRole(role) {
+		return Session{}, errors.New("insufficient previledge")
+	}
+
+	if t.sliding {
+		t.slide(token, session)
+	}
+
+	return session, nil
+}
+
+// slide extends expiry of access token up to issued time + token.sliding.max.seconds
+func (t *TokenHelper) slide(token string, session Session) {
+	if session.Kind == TOKEN_KIND_INSTANT || session.Kind == TOKEN_KIND_REFRESH {
+		return
+	}
+
+	expireAt := time.Now().Add(t.durationSeconds)
+	limit := session.IssuedAt.Add(t.slidingMaxSeconds)
+	if expireAt.After(limit) {
+		expireAt = limit
+	}
+
+	if expireAt.Sub(session.ExpireAt) < slidingExtendThreshold {
+		return
+	}
+
+	t.tokenRepository.Extend(token, expireAt)
+}
+
+func (t *TokenHelper) RevokeToken(token string) {
+	t.tokenRepository.Delete(token)
+}
+
+func (t *TokenHelper) RevokeSession(id string) bool {
+	return t.tokenRepository.DeleteSession(id)
+}
+
+func (t *TokenHelper) RevokeUserSessions(userId string) int {
+	count := 0
+	for _, s := range t.tokenRepository.FindAll() {
+		if s.UserId == userId && t.tokenRepository.DeleteSession(s.Id) {
+			count++
+		}
+	}
+	return count
+}
+
+func (t *TokenHelper) FindAllSessions() []Session {
+	list := t.tokenRepository.FindAll()
+	sort.Slice(list, func(i, j int) bool { return list[i].IssuedAt.Before(list[j].IssuedAt) })
+	return list
 }
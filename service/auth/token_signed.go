@@ -0,0 +1,458 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 7:30
+ */
+
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fatima-go/fatima-core"
+	"github.com/fatima-go/fatima-log"
+	. "github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/infra"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GrantLoader returns current grant of user. error means user is deleted or not valid anymore
+type GrantLoader func(userId string) (Grant, error)
+
+// NewSignedTokenHelper creates TokenService which issues JWT(HS256) signed by keyset in data folder.
+// every jupiter sharing same keyset can validate token without shared token store.
+// issued tokens and revocations (jti denylist, user cutoff) are kept in token repository(token.repo) until tokens expire,
+// so revocation applies to jupiters sharing the token repository. sliding expiry is not supported
+func NewSignedTokenHelper(fatimaRuntime fatima.FatimaRuntime, loadGrant GrantLoader) (TokenService, error) {
+	t := &SignedTokenHelper{}
+	t.loadGrant = loadGrant
+
+	var err error
+	t.tokenRepository, err = newTokenRepository(fatimaRuntime)
+	if err != nil {
+		return nil, err
+	}
+
+	t.keyRepository, err = infra.NewFileSigningKeyRepository(fatimaRuntime)
+	if err != nil {
+		return nil, err
+	}
+
+	d1, err := fatimaRuntime.GetConfig().GetInt(propTokenDurationSeconds)
+	if err != nil {
+		d1 = defaultTokenDurationSeconds
+	}
+	t.durationSeconds = time.Second * time.Duration(d1)
+
+	d2, err := fatimaRuntime.GetConfig().GetInt(propTokenDurationInstantSeconds)
+	if err != nil {
+		d2 = defaultTokenDurationInstantSeconds
+	}
+	t.instantDurationSeconds = time.Second * time.Duration(d2)
+
+	d3, err := fatimaRuntime.GetConfig().GetInt(propTokenRefreshDurationSeconds)
+	if err != nil {
+		d3 = defaultTokenRefreshDurationSeconds
+	}
+	t.refreshDurationSeconds = time.Second * time.Duration(d3)
+
+	d4, err := fatimaRuntime.GetConfig().GetInt(propTokenDurationPendingSeconds)
+	if err != nil {
+		d4 = defaultTokenDurationPendingSeconds
+	}
+	t.pendingDurationSeconds = time.Second * time.Duration(d4)
+	t.refreshMaxSeconds = loadRefreshMaxSeconds(fatimaRuntime)
+
+	t.maxDurationSeconds = t.durationSeconds
+	for _, d := range []time.Duration{t.instantDurationSeconds, t.refreshDurationSeconds, t.pendingDurationSeconds} {
+		if d > t.maxDurationSeconds {
+			t.maxDurationSeconds = d
+		}
+	}
+
+	log.Info("signed token. duration : %d seconds, instant.duration : %d seconds, refresh.duration : %d seconds", d1, d2, d3)
+	return t, nil
+}
+
+const (
+	signedTokenAlgorithm = "HS256"
+	signedTokenType      = "JWT"
+
+	// revoked token id and user cutoff are stored in token repository with these kinds
+	signedKindRevoked   = "revoked"
+	signedKindCutoff    = "cutoff"
+	signedRevokedPrefix = "revoked:"
+	signedCutoffPrefix  = "cutoff:"
+)
+
+type SignedTokenHelper struct {
+	keyRepository          SigningKeyRepository
+	tokenRepository        TokenRepository
+	loadGrant              GrantLoader
+	instantDurationSeconds time.Duration
+	durationSeconds        time.Duration
+	refreshDurationSeconds time.Duration
+	pendingDurationSeconds time.Duration
+	refreshMaxSeconds      time.Duration
+	maxDurationSeconds     time.Duration
+	mutex                  sync.Mutex
+}
+
+type signedTokenHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+	KeyId     string `json:"kid"`
+}
+
+type signedTokenClaims struct {
+	Id        string            `json:"jti"`
+	Subject   string            `json:"sub"`
+	Role      string            `json:"role"`
+	Kind      string            `json:"knd"`
+	IssuedAt  int64             `json:"iat"`
+	ExpiresAt int64             `json:"exp"`
+	Scopes    map[string]string `json:"scp,omitempty"`
+	AuthTime  int64             `json:"auth_time,omitempty"`
+}
+
+// toSession returns session of claims. session id is digest of jti like id of random token
+func (c signedTokenClaims) toSession() Session {
+	session := Session{}
+	session.Id = infra.DigestToken(c.Id)
+	session.UserId = c.Subject
+	session.Role = ToRole(c.Role)
+	session.Kind = c.Kind
+	session.IssuedAt = time.Unix(c.IssuedAt, 0)
+	session.ExpireAt = time.Unix(c.ExpiresAt, 0)
+	if c.AuthTime > 0 {
+		session.AuthenticatedAt = time.Unix(c.AuthTime, 0)
+	}
+	for scope, role := range c.Scopes {
+		session.Bindings = append(session.Bindings, NewRoleBinding(scope, role))
+	}
+	return session
+}
+
+func (t *SignedTokenHelper) GenerateInstantToken(owner Session) (string, error) {
+	owner.Kind = TOKEN_KIND_INSTANT
+	return t.sign(owner, t.instantDurationSeconds)
+}
+
+func (t *SignedTokenHelper) GenerateToken(owner Session) (string, error) {
+	owner.Kind = TOKEN_KIND_ACCESS
+	return t.sign(owner, t.durationSeconds)
+}
+
+func (t *SignedTokenHelper) GenerateRefreshToken(owner Session) (string, error) {
+	if t.refreshDurationSeconds <= 0 {
+		return "", nil
+	}
+
+	owner.Kind = TOKEN_KIND_REFRESH
+	return t.sign(owner, t.refreshDurationSeconds)
+}
+
+func (t *SignedTokenHelper) GeneratePendingToken(owner Session) (string, error) {
+	owner.Kind = TOKEN_KIND_PENDING
+	return t.sign(owner, t.pendingDurationSeconds)
+}
+
+// RedeemPendingToken verifies pending token and revokes it. pending token is single use
+func (t *SignedTokenHelper) RedeemPendingToken(token string) (Session, error) {
+	owner, err := t.verify(token)
+	if err != nil {
+		return Session{}, err
+	}
+
+	if !owner.IsPending() {
+		return Session{}, errors.New("not a pending token")
+	}
+
+	if !t.redeem(owner) {
+		return Session{}, errors.New("pending token is already used")
+	}
+	return owner, nil
+}
+
+// RefreshToken issues new token pair with grant reloaded from user repository or directory.
+// given refresh token is revoked and single use. refresh is refused token.refresh.max.seconds after login
+func (t *SignedTokenHelper) RefreshToken(refreshToken string) (string, string, error) {
+	owner, err := t.verify(refreshToken)
+	if err != nil {
+		return "", "", err
+	}
+
+	if !owner.IsRefresh() {
+		return "", "", errors.New("not a refresh token")
+	}
+
+	err = checkRefreshLifetime(owner, t.refreshMaxSeconds)
+	if err != nil {
+		return "", "", err
+	}
+
+	grant, err := t.loadGrant(owner.UserId)
+	if err != nil {
+		return "", "", err
+	}
+
+	if !t.redeem(owner) {
+		return "", "", errors.New("refresh token is already used")
+	}
+
+	owner.Role = grant.Role
+	owner.Bindings = grant.Bindings
+	token, err := t.GenerateToken(owner)
+	if err != nil {
+		return "", "", err
+	}
+	refreshToken, err = t.GenerateRefreshToken(owner)
+	if err != nil {
+		return "", "", err
+	}
+
+	log.Info("token of user %s refreshed", owner.UserId)
+	return token, refreshToken, nil
+}
+
+func (t *SignedTokenHelper) ValidateToken(token string, role Role) (Session, error) {
+	if len(token) < 1 {
+		return Session{}, errors.New("invalid fatima token")
+	}
+
+	session, err := t.verify(token)
+	if err != nil {
+		return Session{}, err
+	}
+
+	if session.IsRefresh() {
+		return Session{}, errors.New("refresh token is not acceptable")
+	}
+
+	if session.IsPending() {
+		return Session{}, errors.New("second factor is not verified")
+	}
+
+	if !session.Grant().HasRole(role) {
+		return Session{}, errors.New("insufficient previledge")
+	}
+
+	return session, nil
+}
+
+func (t *SignedTokenHelper) RevokeToken(token string) {
+	session, err := t.parse(token)
+	if err != nil {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.revoke(session.Id, session.UserId, session.ExpireAt)
+}
+
+// RevokeSession revokes token of session id. token issued by another jupiter is denied too,
+// but false is returned when it is not issued by this token repository
+func (t *SignedTokenHelper) RevokeSession(id string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.revoke(id, "", time.Now().Add(t.maxDurationSeconds))
+}
+
+// RevokeUserSessions denies every token of user issued until now and returns number of revoked known sessions.
+// iat of token has second precision, so cutoff denies tokens issued before current second.
+// known tokens issued in current second are denied by id, new login in current second is accepted
+func (t *SignedTokenHelper) RevokeUserSessions(userId string) int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	cutoff := Session{UserId: userId, Kind: signedKindCutoff, IssuedAt: time.Now().Truncate(time.Second)}
+	t.tokenRepository.Save(signedCutoffPrefix+userId, cutoff, t.maxDurationSeconds)
+
+	count := 0
+	for _, s := range t.tokenRepository.FindAll() {
+		if s.UserId == userId && isSignedSession(s) && t.revoke(s.Id, userId, s.ExpireAt) {
+			count++
+		}
+	}
+	return count
+}
+
+func (t *SignedTokenHelper) FindAllSessions() []Session {
+	list := make([]Session, 0)
+	for _, s := range t.tokenRepository.FindAll() {
+		if isSignedSession(s) {
+			list = append(list, s)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].IssuedAt.Before(list[j].IssuedAt) })
+	return list
+}
+
+func isSignedSession(s Session) bool {
+	return s.Kind != signedKindRevoked && s.Kind != signedKindCutoff
+}
+
+// revoke denies session id until expireAt and removes issued session. caller holds mutex
+func (t *SignedTokenHelper) revoke(id string, userId string, expireAt time.Time) bool {
+	denied := Session{UserId: userId, Kind: signedKindRevoked}
+	t.tokenRepository.Save(signedRevokedPrefix+id, denied, time.Until(expireAt)+time.Second)
+	return t.tokenRepository.DeleteSession(id)
+}
+
+// revoked returns true when session id is denied or user revoked sessions after the second it is issued
+func (t *SignedTokenHelper) revoked(session Session) bool {
+	if _, ok := t.tokenRepository.FindById(signedRevokedPrefix + session.Id); ok {
+		return true
+	}
+	cutoff, ok := t.tokenRepository.FindById(signedCutoffPrefix + session.UserId)
+	return ok && session.IssuedAt.Unix() < cutoff.IssuedAt.Unix()
+}
+
+// redeem revokes single use token. only one of concurrent callers gets true
+func (t *SignedTokenHelper) redeem(session Session) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.revoked(session) {
+		return false
+	}
+	t.revoke(session.Id, session.UserId, session.ExpireAt)
+	return true
+}
+
+func (t *SignedTokenHelper) sign(owner Session, duration time.Duration) (string, error) {
+	key, ok := t.keyRepository.FindActive()
+	if !ok {
+		return "", errors.New("not found active signing key")
+	}
+
+	now := time.Now()
+	if owner.AuthenticatedAt.IsZero() {
+		owner.AuthenticatedAt = now
+	}
+	header := signedTokenHeader{Algorithm: signedTokenAlgorithm, Type: signedTokenType, KeyId: key.Id}
+	claims := signedTokenClaims{
+		Id:        infra.GenerateSecret(16),
+		Subject:   owner.UserId,
+		Role:      owner.Role.String(),
+		Kind:      owner.Kind,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(duration).Unix(),
+		AuthTime:  owner.AuthenticatedAt.Unix(),
+	}
+	if len(owner.Bindings) > 0 {
+		claims.Scopes = make(map[string]string)
+		for _, b := range owner.Bindings {
+			claims.Scopes[b.Scope] = b.Role.String()
+		}
+	}
+
+	h, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	// issued token is kept for session listing and revocation
+	owner.IssuedAt = now
+	t.tokenRepository.Save(claims.Id, owner, duration)
+
+	content := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+	return content + "." + base64.RawURLEncoding.EncodeToString(signContent(key.Secret, content)), nil
+}
+
+// verify returns session of valid token which is not revoked
+func (t *SignedTokenHelper) verify(token string) (Session, error) {
+	session, err := t.parse(token)
+	if err != nil {
+		return Session{}, err
+	}
+
+	if t.revoked(session) {
+		return Session{}, errors.New("revoked fatima token")
+	}
+	return session, nil
+}
+
+// parse checks signature and expiry of token
+func (t *SignedTokenHelper) parse(token string) (Session, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Session{}, errors.New("malformed signed token")
+	}
+
+	var header signedTokenHeader
+	if err := decodeTokenPart(parts[0], &header); err != nil {
+		return Session{}, fmt.Errorf("invalid token header : %s", err.Error())
+	}
+	if header.Algorithm != signedTokenAlgorithm {
+		return Session{}, fmt.Errorf("unsupported token algorithm %s", header.Algorithm)
+	}
+
+	key, ok := t.keyRepository.FindById(header.KeyId)
+	if !ok {
+		return Session{}, fmt.Errorf("unknown signing key %s", header.KeyId)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Session{}, errors.New("invalid token signature")
+	}
+	if !hmac.Equal(signature, signContent(key.Secret, parts[0]+"."+parts[1])) {
+		return Session{}, errors.New("missmatch token signature")
+	}
+
+	var claims signedTokenClaims
+	if err := decodeTokenPart(parts[1], &claims); err != nil {
+		return Session{}, fmt.Errorf("invalid token claims : %s", err.Error())
+	}
+
+	session := claims.toSession()
+	if session.IsExpired() {
+		return Session{}, errors.New("expired fatima token")
+	}
+
+	return session, nil
+}
+
+func signContent(secret []byte, content string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(content))
+	return mac.Sum(nil)
+}
+
+func decodeTokenPart(part string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
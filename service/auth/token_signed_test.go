@@ -0,0 +1,212 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 3:05
+ */
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	. "github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/infra"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testSigningKeys is signing key set. first key is active
+type testSigningKeys []SigningKey
+
+func (keys testSigningKeys) FindActive() (SigningKey, bool) {
+	if len(keys) == 0 {
+		return SigningKey{}, false
+	}
+	return keys[0], true
+}
+
+func (keys testSigningKeys) FindById(id string) (SigningKey, bool) {
+	for _, k := range keys {
+		if k.Id == id {
+			return k, true
+		}
+	}
+	return SigningKey{}, false
+}
+
+func newTestSignedTokenHelper(grants testGrants) *SignedTokenHelper {
+	return &SignedTokenHelper{
+		keyRepository:          testSigningKeys{{Id: "k1", Secret: []byte("0123456789abcdef0123456789abcdef")}},
+		tokenRepository:        infra.NewMemoryTokenRepository(),
+		loadGrant:              grants.load,
+		instantDurationSeconds: time.Second * 10,
+		durationSeconds:        time.Hour,
+		refreshDurationSeconds: time.Hour * 24,
+		refreshMaxSeconds:      time.Hour * 24 * 7,
+		pendingDurationSeconds: time.Minute,
+		maxDurationSeconds:     time.Hour * 24,
+	}
+}
+
+func TestSignedTokenHelperLoginAfterRevoke(t *testing.T) {
+	helper := newTestSignedTokenHelper(testGrants{"alice": {Role: ROLE_OPERATOR}})
+	owner := Session{UserId: "alice", Role: ROLE_OPERATOR}
+
+	for i := 0; i < 3; i++ {
+		before, err := helper.GenerateToken(owner)
+		if err != nil {
+			t.Fatalf("fail to sign token : %s", err.Error())
+		}
+
+		if count := helper.RevokeUserSessions("alice"); count == 0 {
+			t.Fatalf("no session is revoked")
+		}
+		if _, err = helper.ValidateToken(before, ROLE_MONITOR); err == nil {
+			t.Fatalf("token issued before revoke is accepted")
+		}
+
+		// login in the same second of revoke
+		after, _ := helper.GenerateToken(owner)
+		if _, err = helper.ValidateToken(after, ROLE_MONITOR); err != nil {
+			t.Fatalf("token issued right after revoke is rejected : %s", err.Error())
+		}
+	}
+}
+
+// resignTestToken rebuilds token with changed header and claims. signature is kept unless secret is given
+func resignTestToken(t *testing.T, token string, secret []byte, change func(header map[string]interface{}, claims map[string]interface{})) string {
+	parts := strings.Split(token, ".")
+	header := make(map[string]interface{})
+	claims := make(map[string]interface{})
+	if err := decodeTokenPart(parts[0], &header); err != nil {
+		t.Fatalf("fail to decode header : %s", err.Error())
+	}
+	if err := decodeTokenPart(parts[1], &claims); err != nil {
+		t.Fatalf("fail to decode claims : %s", err.Error())
+	}
+	change(header, claims)
+
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	content := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+	if secret == nil {
+		return content + "." + parts[2]
+	}
+	return content + "." + base64.RawURLEncoding.EncodeToString(signContent(secret, content))
+}
+
+func TestSignedTokenHelperReject(t *testing.T) {
+	helper := newTestSignedTokenHelper(testGrants{})
+	token, err := helper.GenerateToken(Session{UserId: "alice", Role: ROLE_MONITOR})
+	if err != nil {
+		t.Fatalf("fail to sign token : %s", err.Error())
+	}
+	if _, err = helper.ValidateToken(token, ROLE_MONITOR); err != nil {
+		t.Fatalf("signed token is rejected : %s", err.Error())
+	}
+
+	parts := strings.Split(token, ".")
+	cases := []struct {
+		name  string
+		token string
+	}{
+		{"elevated role", resignTestToken(t, token, nil, func(h, c map[string]interface{}) { c["role"] = "OPERATOR" })},
+		{"other user", resignTestToken(t, token, nil, func(h, c map[string]interface{}) { c["sub"] = "admin" })},
+		{"extended expiry", resignTestToken(t, token, nil, func(h, c map[string]interface{}) {
+			c["exp"] = time.Now().Add(time.Hour * 24).Unix()
+		})},
+		{"alg none", strings.Join([]string{
+			strings.Split(resignTestToken(t, token, nil, func(h, c map[string]interface{}) { h["alg"] = "none" }), ".")[0],
+			parts[1], ""}, ".")},
+		{"alg none signed", resignTestToken(t, token, []byte{}, func(h, c map[string]interface{}) { h["alg"] = "none" })},
+		{"unknown kid", resignTestToken(t, token, nil, func(h, c map[string]interface{}) { h["kid"] = "k9" })},
+		{"signed by unknown key", resignTestToken(t, token, []byte("fedcba9876543210fedcba9876543210"),
+			func(h, c map[string]interface{}) {})},
+		{"expired", resignTestToken(t, token, []byte("0123456789abcdef0123456789abcdef"), func(h, c map[string]interface{}) {
+			c["exp"] = time.Now().Add(-time.Second).Unix()
+		})},
+		{"broken signature", parts[0] + "." + parts[1] + ".!!"},
+		{"missing part", parts[0] + "." + parts[1]},
+		{"empty", ""},
+	}
+
+	for _, c := range cases {
+		if _, err = helper.ValidateToken(c.token, ROLE_MONITOR); err == nil {
+			t.Fatalf("%s : token is accepted", c.name)
+		}
+	}
+}
+
+func TestSignedTokenHelperKeyRotation(t *testing.T) {
+	helper := newTestSignedTokenHelper(testGrants{})
+	old := helper.keyRepository.(testSigningKeys)[0]
+	token, _ := helper.GenerateToken(Session{UserId: "alice", Role: ROLE_MONITOR})
+
+	// new active key, old key is kept for verification
+	helper.keyRepository = testSigningKeys{{Id: "k2", Secret: []byte("fedcba9876543210fedcba9876543210")}, old}
+	if _, err := helper.ValidateToken(token, ROLE_MONITOR); err != nil {
+		t.Fatalf("token of previous key is rejected : %s", err.Error())
+	}
+	rotated, _ := helper.GenerateToken(Session{UserId: "alice", Role: ROLE_MONITOR})
+	if header := strings.Split(rotated, ".")[0]; !strings.Contains(string(mustDecode(t, header)), `"kid":"k2"`) {
+		t.Fatalf("token is not signed by active key")
+	}
+
+	// old key is removed
+	helper.keyRepository = testSigningKeys{{Id: "k2", Secret: []byte("fedcba9876543210fedcba9876543210")}}
+	if _, err := helper.ValidateToken(token, ROLE_MONITOR); err == nil {
+		t.Fatalf("token of removed key is accepted")
+	}
+}
+
+func TestSignedTokenHelperRefreshToken(t *testing.T) {
+	grants := testGrants{"alice": {Role: ROLE_OPERATOR}}
+	helper := newTestSignedTokenHelper(grants)
+	refreshToken, _ := helper.GenerateRefreshToken(Session{UserId: "alice", Role: ROLE_OPERATOR})
+
+	if _, err := helper.ValidateToken(refreshToken, ROLE_MONITOR); err == nil {
+		t.Fatalf("refresh token is accepted as access token")
+	}
+
+	grants["alice"] = Grant{Role: ROLE_MONITOR}
+	token, next, err := helper.RefreshToken(refreshToken)
+	if err != nil {
+		t.Fatalf("fail to refresh : %s", err.Error())
+	}
+	if _, err = helper.ValidateToken(token, ROLE_OPERATOR); err == nil {
+		t.Fatalf("refreshed token keeps revoked OPERATOR role")
+	}
+	if _, _, err = helper.RefreshToken(refreshToken); err == nil {
+		t.Fatalf("used refresh token is accepted")
+	}
+
+	delete(grants, "alice")
+	if _, _, err = helper.RefreshToken(next); err == nil {
+		t.Fatalf("refresh token of deleted user is accepted")
+	}
+}
+
+func mustDecode(t *testing.T, part string) []byte {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		t.Fatalf("fail to decode %s : %s", part, err.Error())
+	}
+	return b
+}
@@ -0,0 +1,274 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 2:40
+ */
+package auth
+
+import (
+	"errors"
+	. "github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/infra"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testGrants is grant loader of users. deleted user is missing
+type testGrants map[string]Grant
+
+func (g testGrants) load(userId string) (Grant, error) {
+	grant, ok := g[userId]
+	if !ok {
+		return Grant{}, errors.New("not found user " + userId)
+	}
+	return grant, nil
+}
+
+func newTestTokenHelper(grants testGrants) *TokenHelper {
+	return &TokenHelper{
+		tokenRepository:        infra.NewMemoryTokenRepository(),
+		loadGrant:              grants.load,
+		instantDurationSeconds: time.Second * 10,
+		durationSeconds:        time.Hour,
+		refreshDurationSeconds: time.Hour * 24,
+		refreshMaxSeconds:      time.Hour * 24 * 7,
+		pendingDurationSeconds: time.Minute,
+	}
+}
+
+func TestTokenHelperRefreshToken(t *testing.T) {
+	grants := testGrants{"alice": {Role: ROLE_OPERATOR}}
+	helper := newTestTokenHelper(grants)
+
+	refreshToken, err := helper.GenerateRefreshToken(Session{UserId: "alice", Role: ROLE_OPERATOR})
+	if err != nil {
+		t.Fatalf("fail to generate refresh token : %s", err.Error())
+	}
+	login, _ := helper.tokenRepository.FindById(refreshToken)
+
+	// role change applies to refreshed token
+	grants["alice"] = Grant{Role: ROLE_MONITOR, Bindings: []RoleBinding{{Scope: "payment", Role: ROLE_MONITOR}}}
+	token, next, err := helper.RefreshToken(refreshToken)
+	if err != nil {
+		t.Fatalf("fail to refresh : %s", err.Error())
+	}
+
+	session, err := helper.ValidateToken(token, ROLE_MONITOR)
+	if err != nil {
+		t.Fatalf("refreshed token is not valid : %s", err.Error())
+	}
+	if session.Role != ROLE_MONITOR || len(session.Bindings) != 1 {
+		t.Fatalf("refreshed token has stale grant %s %v", session.Role, session.Bindings)
+	}
+	if _, err = helper.ValidateToken(token, ROLE_OPERATOR); err == nil {
+		t.Fatalf("refreshed token keeps revoked OPERATOR role")
+	}
+	if !session.AuthenticatedAt.Equal(login.AuthenticatedAt) {
+		t.Fatalf("login time %s is changed to %s", login.AuthenticatedAt, session.AuthenticatedAt)
+	}
+
+	// refresh token is single use
+	if _, _, err = helper.RefreshToken(refreshToken); err == nil {
+		t.Fatalf("used refresh token is accepted")
+	}
+
+	// deleted user cannot refresh
+	delete(grants, "alice")
+	if _, _, err = helper.RefreshToken(next); err == nil {
+		t.Fatalf("refresh token of deleted user is accepted")
+	}
+}
+
+func TestTokenHelperRefreshTokenLifetime(t *testing.T) {
+	cases := []struct {
+		name     string
+		loginAgo time.Duration
+		max      time.Duration
+		accepted bool
+	}{
+		{"recent login", time.Hour, time.Hour * 24, true},
+		{"old login", time.Hour * 25, time.Hour * 24, false},
+		{"no limit", time.Hour * 24 * 30, 0, true},
+	}
+
+	for _, c := range cases {
+		helper := newTestTokenHelper(testGrants{"alice": {Role: ROLE_OPERATOR}})
+		helper.refreshMaxSeconds = c.max
+
+		owner := Session{UserId: "alice", Role: ROLE_OPERATOR, AuthenticatedAt: time.Now().Add(-c.loginAgo)}
+		refreshToken, _ := helper.GenerateRefreshToken(owner)
+		_, _, err := helper.RefreshToken(refreshToken)
+		if (err == nil) != c.accepted {
+			t.Fatalf("%s : accepted %t", c.name, err == nil)
+		}
+	}
+}
+
+func TestTokenHelperRevoke(t *testing.T) {
+	helper := newTestTokenHelper(testGrants{})
+	alice1, _ := helper.GenerateToken(Session{UserId: "alice", Role: ROLE_OPERATOR})
+	alice2, _ := helper.GenerateToken(Session{UserId: "alice", Role: ROLE_OPERATOR})
+	alice3, _ := helper.GenerateToken(Session{UserId: "alice", Role: ROLE_OPERATOR})
+	bob, _ := helper.GenerateToken(Session{UserId: "bob", Role: ROLE_MONITOR})
+
+	if list := helper.FindAllSessions(); len(list) != 4 {
+		t.Fatalf("expected 4 sessions but %d", len(list))
+	}
+
+	// logout
+	helper.RevokeToken(alice1)
+	session, err := helper.ValidateToken(alice2, ROLE_MONITOR)
+	if err != nil {
+		t.Fatalf("other session is revoked by logout : %s", err.Error())
+	}
+
+	// revoke by session id. session id is not a token
+	if _, err = helper.ValidateToken(session.Id, ROLE_MONITOR); err == nil {
+		t.Fatalf("session id is accepted as token")
+	}
+	if !helper.RevokeSession(session.Id) {
+		t.Fatalf("fail to revoke session")
+	}
+	if helper.RevokeSession(session.Id) {
+		t.Fatalf("revoked session is revoked again")
+	}
+
+	if count := helper.RevokeUserSessions("alice"); count != 1 {
+		t.Fatalf("expected 1 revoked session but %d", count)
+	}
+
+	cases := []struct {
+		token string
+		valid bool
+	}{
+		{alice1, false},
+		{alice2, false},
+		{alice3, false},
+		{bob, true},
+	}
+	for _, c := range cases {
+		if _, err = helper.ValidateToken(c.token, ROLE_MONITOR); (err == nil) != c.valid {
+			t.Fatalf("token valid %t", err == nil)
+		}
+	}
+
+	list := helper.FindAllSessions()
+	if len(list) != 1 || list[0].UserId != "bob" {
+		t.Fatalf("sessions after revoke : %v", list)
+	}
+}
+
+func TestTokenHelperValidateToken(t *testing.T) {
+	helper := newTestTokenHelper(testGrants{})
+	owner := Session{UserId: "alice", Role: ROLE_MONITOR, Bindings: []RoleBinding{{Scope: "payment", Role: ROLE_OPERATOR}}}
+	access, _ := helper.GenerateToken(owner)
+	refresh, _ := helper.GenerateRefreshToken(owner)
+	pending, _ := helper.GeneratePendingToken(owner)
+	instant, _ := helper.GenerateInstantToken(owner)
+
+	cases := []struct {
+		name  string
+		token string
+		role  Role
+		valid bool
+	}{
+		{"access", access, ROLE_MONITOR, true},
+		{"access bound OPERATOR", access, ROLE_OPERATOR, true},
+		{"instant", instant, ROLE_MONITOR, true},
+		{"refresh", refresh, ROLE_MONITOR, false},
+		{"pending", pending, ROLE_MONITOR, false},
+		{"unknown", "unknown-token", ROLE_MONITOR, false},
+		{"empty", "", ROLE_MONITOR, false},
+	}
+	for _, c := range cases {
+		if _, err := helper.ValidateToken(c.token, c.role); (err == nil) != c.valid {
+			t.Fatalf("%s : valid %t", c.name, err == nil)
+		}
+	}
+}
+
+func TestTokenHelperRefreshTokenConcurrentReuse(t *testing.T) {
+	helper := newTestTokenHelper(testGrants{"alice": {Role: ROLE_OPERATOR}})
+	refreshToken, _ := helper.GenerateRefreshToken(Session{UserId: "alice", Role: ROLE_OPERATOR})
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	refreshed := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := helper.RefreshToken(refreshToken); err == nil {
+				mutex.Lock()
+				refreshed++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if refreshed != 1 {
+		t.Fatalf("refresh token is used %d times", refreshed)
+	}
+}
+
+func TestTokenHelperSliding(t *testing.T) {
+	cases := []struct {
+		name      string
+		max       time.Duration
+		remaining time.Duration
+		kind      string
+		expected  time.Duration // expected expiry from now. 0 keeps remaining
+	}{
+		{"extended", time.Hour * 12, time.Minute * 2, TOKEN_KIND_ACCESS, time.Hour},
+		{"limited by max", time.Minute * 30, time.Minute * 2, TOKEN_KIND_ACCESS, time.Minute * 30},
+		{"small gain", time.Hour * 12, time.Hour - time.Second*30, TOKEN_KIND_ACCESS, 0},
+		{"instant token", time.Hour * 12, time.Second * 5, TOKEN_KIND_INSTANT, 0},
+	}
+
+	for _, c := range cases {
+		helper := newTestTokenHelper(testGrants{})
+		helper.sliding = true
+		helper.slidingMaxSeconds = c.max
+
+		var token string
+		if c.kind == TOKEN_KIND_INSTANT {
+			token, _ = helper.GenerateInstantToken(Session{UserId: "alice", Role: ROLE_MONITOR})
+		} else {
+			token, _ = helper.GenerateToken(Session{UserId: "alice", Role: ROLE_MONITOR})
+		}
+		expireAt := time.Now().Add(c.remaining)
+		helper.tokenRepository.Extend(token, expireAt)
+
+		if _, err := helper.ValidateToken(token, ROLE_MONITOR); err != nil {
+			t.Fatalf("%s : %s", c.name, err.Error())
+		}
+
+		session, _ := helper.tokenRepository.FindById(token)
+		expected := expireAt
+		if c.expected > 0 {
+			expected = time.Now().Add(c.expected)
+		}
+		if diff := session.ExpireAt.Sub(expected); diff > time.Second*2 || diff < -time.Second*2 {
+			t.Fatalf("%s : expire at %s, expected %s", c.name, session.ExpireAt, expected)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 6:55
+ */
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+// base32 of RFC 6238 sha1 test secret "12345678901234567890"
+const testTotpSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTotpCode(t *testing.T) {
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, c := range cases {
+		code, err := TotpCode(testTotpSecret, TotpStep(time.Unix(c.unix, 0)))
+		if err != nil {
+			t.Fatalf("%d : %s", c.unix, err.Error())
+		}
+		if code != c.code {
+			t.Fatalf("%d : code %s, expected %s", c.unix, code, c.code)
+		}
+	}
+
+	if _, err := TotpCode("not base32 !", 1); err == nil {
+		t.Fatalf("invalid secret is accepted")
+	}
+}
+
+func TestVerifyTotp(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := TotpStep(now)
+	codeOf := func(step int64) string {
+		code, _ := TotpCode(testTotpSecret, step)
+		return code
+	}
+
+	cases := []struct {
+		name     string
+		code     string
+		skew     int
+		lastStep int64
+		step     int64
+		accepted bool
+	}{
+		{"current", codeOf(current), 1, 0, current, true},
+		{"with spaces", " " + codeOf(current) + " ", 1, 0, current, true},
+		{"previous in window", codeOf(current - 1), 1, 0, current - 1, true},
+		{"next in window", codeOf(current + 1), 1, 0, current + 1, true},
+		{"previous out of window", codeOf(current - 2), 1, 0, 0, false},
+		{"previous without skew", codeOf(current - 1), 0, 0, 0, false},
+		{"replay of last step", codeOf(current), 1, current, 0, false},
+		{"older than last step", codeOf(current - 1), 1, current, 0, false},
+		{"newer than last step", codeOf(current + 1), 1, current, current + 1, true},
+		{"wrong code", "000000", 1, 0, 0, false},
+		{"short code", codeOf(current)[:5], 1, 0, 0, false},
+	}
+
+	for _, c := range cases {
+		step, ok := VerifyTotp(testTotpSecret, c.code, now, c.skew, c.lastStep)
+		if ok != c.accepted {
+			t.Fatalf("%s : accepted %t", c.name, ok)
+		}
+		if step != c.step {
+			t.Fatalf("%s : step %d, expected %d", c.name, step, c.step)
+		}
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes()
+	if err != nil {
+		t.Fatalf("fail to generate recovery codes : %s", err.Error())
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("%d recovery codes, expected %d", len(codes), recoveryCodeCount)
+	}
+
+	seen := make(map[string]bool)
+	for _, c := range codes {
+		if len(c) != 17 || c[8] != '-' {
+			t.Fatalf("invalid recovery code format : %s", c)
+		}
+		if seen[c] {
+			t.Fatalf("duplicated recovery code : %s", c)
+		}
+		seen[c] = true
+	}
+
+	cases := []struct {
+		name  string
+		input string
+		equal bool
+	}{
+		{"same", "abcdefgh-ijklmnop", true},
+		{"upper case", "ABCDEFGH-IJKLMNOP", true},
+		{"without hyphen", "abcdefghijklmnop", true},
+		{"with spaces", " abcd efgh-ijkl mnop ", true},
+		{"different", "abcdefgh-ijklmnoq", false},
+	}
+	for _, c := range cases {
+		if (DigestRecoveryCode(c.input) == DigestRecoveryCode("abcdefgh-ijklmnop")) != c.equal {
+			t.Fatalf("%s : digest equal %t", c.name, !c.equal)
+		}
+	}
+}
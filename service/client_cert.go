@@ -0,0 +1,184 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 19. 오후 4:40
+ */
+package service
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/fatima-go/fatima-core"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// webserver.tls.client.rules=cn=deploy-bot:ci:OPERATOR;dns=*.build.example.com:builder:MONITOR@team-a
+const (
+	propWebServerTlsClientRules = "webserver.tls.client.rules"
+)
+
+// certificateRule maps client certificate to user and role when field of certificate matches pattern.
+// field is one of cn, ou, o, dns, email, uri, ip. pattern may have * wildcard
+type certificateRule struct {
+	field   string
+	pattern string
+	userId  string
+	role    domain.Role
+	scope   string
+}
+
+var certificateRoleSuffix = regexp.MustCompile(`(?i):(OPERATOR|MONITOR)(?:@(.+))?$`)
+
+var certificateFields = map[string]func(cert *x509.Certificate) []string{
+	"cn":    func(cert *x509.Certificate) []string { return []string{cert.Subject.CommonName} },
+	"ou":    func(cert *x509.Certificate) []string { return cert.Subject.OrganizationalUnit },
+	"o":     func(cert *x509.Certificate) []string { return cert.Subject.Organization },
+	"dns":   func(cert *x509.Certificate) []string { return cert.DNSNames },
+	"email": func(cert *x509.Certificate) []string { return cert.EmailAddresses },
+	"uri": func(cert *x509.Certificate) []string {
+		list := make([]string, 0, len(cert.URIs))
+		for _, u := range cert.URIs {
+			list = append(list, u.String())
+		}
+		return list
+	},
+	"ip": func(cert *x509.Certificate) []string {
+		list := make([]string, 0, len(cert.IPAddresses))
+		for _, ip := range cert.IPAddresses {
+			list = append(list, ip.String())
+		}
+		return list
+	},
+}
+
+func loadCertificateRules(fatimaRuntime fatima.FatimaRuntime) []certificateRule {
+	value, ok := fatimaRuntime.GetConfig().GetValue(propWebServerTlsClientRules)
+	if !ok {
+		return nil
+	}
+
+	rules := parseCertificateRules(value)
+	log.Info("%d client certificate rules loaded", len(rules))
+	return rules
+}
+
+// parseCertificateRules parses field=pattern:user:ROLE[@scope] list separated by semicolon
+func parseCertificateRules(value string) []certificateRule {
+	list := make([]certificateRule, 0)
+	for _, item := range strings.Split(value, ";") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+
+		rule, err := parseCertificateRule(item)
+		if err != nil {
+			log.Warn("invalid client certificate rule %s : %s", item, err.Error())
+			continue
+		}
+		list = append(list, rule)
+	}
+	return list
+}
+
+func parseCertificateRule(item string) (certificateRule, error) {
+	rule := certificateRule{}
+
+	// pattern may contain : or @ (uri, email), so role and scope are found from the end
+	m := certificateRoleSuffix.FindStringSubmatchIndex(item)
+	if m == nil {
+		return rule, errors.New("role is missing")
+	}
+	rule.role = domain.ToRole(item[m[2]:m[3]])
+	if m[4] >= 0 {
+		rule.scope = item[m[4]:m[5]]
+	}
+	entry := item[:m[0]]
+
+	idx := strings.LastIndex(entry, ":")
+	if idx < 0 {
+		return rule, errors.New("user is missing")
+	}
+	rule.userId = strings.TrimSpace(entry[idx+1:])
+	if len(rule.userId) == 0 {
+		return rule, errors.New("user is missing")
+	}
+	entry = entry[:idx]
+
+	eq := strings.Index(entry, "=")
+	if eq < 1 {
+		return rule, errors.New("field=pattern is missing")
+	}
+	rule.field = strings.ToLower(strings.TrimSpace(entry[:eq]))
+	rule.pattern = strings.TrimSpace(entry[eq+1:])
+	if _, ok := certificateFields[rule.field]; !ok {
+		return rule, fmt.Errorf("unknown field %s", rule.field)
+	}
+	if _, err := path.Match(rule.pattern, ""); err != nil {
+		return rule, fmt.Errorf("invalid pattern %s", rule.pattern)
+	}
+	return rule, nil
+}
+
+func (rule certificateRule) matches(cert *x509.Certificate) bool {
+	for _, v := range certificateFields[rule.field](cert) {
+		if ok, _ := path.Match(rule.pattern, v); ok && len(v) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateClientCertificate maps verified client certificate to session by first matching rule
+func (interactor *DomainInteractor) ValidateClientCertificate(cert *x509.Certificate, role domain.Role, clientAddress string) (domain.Session, error) {
+	for _, rule := range interactor.certificateRules {
+		if !rule.matches(cert) {
+			continue
+		}
+
+		sum := sha256.Sum256(cert.Raw)
+		session := domain.Session{
+			Id:            hex.EncodeToString(sum[:]),
+			UserId:        rule.userId,
+			Role:          rule.role,
+			IssuedAt:      cert.NotBefore,
+			ExpireAt:      cert.NotAfter,
+			ClientAddress: clientAddress,
+			Kind:          domain.TOKEN_KIND_CERTIFICATE,
+		}
+		if len(rule.scope) > 0 {
+			session.Bindings = []domain.RoleBinding{{Scope: rule.scope, Role: rule.role}}
+		}
+
+		if !session.Grant().HasRole(role) {
+			return domain.Session{}, fmt.Errorf("insufficient previledge of certificate %s", cert.Subject.CommonName)
+		}
+		return session, nil
+	}
+
+	return domain.Session{}, fmt.Errorf("no rule matched certificate %s", cert.Subject.String())
+}
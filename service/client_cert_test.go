@@ -0,0 +1,132 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 7:10
+ */
+package service
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"github.com/fatima-go/jupiter/domain"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseCertificateRule(t *testing.T) {
+	cases := []struct {
+		name  string
+		item  string
+		valid bool
+		rule  certificateRule
+	}{
+		{"cn", "cn=deploy-bot:ci:OPERATOR", true,
+			certificateRule{field: "cn", pattern: "deploy-bot", userId: "ci", role: domain.ROLE_OPERATOR}},
+		{"scope", "dns=*.build.example.com:builder:MONITOR@team-a", true,
+			certificateRule{field: "dns", pattern: "*.build.example.com", userId: "builder", role: domain.ROLE_MONITOR, scope: "team-a"}},
+		{"uri with colon", "uri=spiffe://example.com/ci:ci:operator", true,
+			certificateRule{field: "uri", pattern: "spiffe://example.com/ci", userId: "ci", role: domain.ROLE_OPERATOR}},
+		{"email with at", "EMAIL=bot@example.com:bot:MONITOR", true,
+			certificateRule{field: "email", pattern: "bot@example.com", userId: "bot", role: domain.ROLE_MONITOR}},
+		{"missing role", "cn=deploy-bot:ci", false, certificateRule{}},
+		{"missing user", "cn=deploy-bot::OPERATOR", false, certificateRule{}},
+		{"missing field", "deploy-bot:ci:OPERATOR", false, certificateRule{}},
+		{"unknown field", "serial=1234:ci:OPERATOR", false, certificateRule{}},
+		{"invalid pattern", "cn=[deploy:ci:OPERATOR", false, certificateRule{}},
+	}
+
+	for _, c := range cases {
+		rule, err := parseCertificateRule(c.item)
+		if (err == nil) != c.valid {
+			t.Fatalf("%s : valid %t", c.name, err == nil)
+		}
+		if c.valid && rule != c.rule {
+			t.Fatalf("%s : rule %+v, expected %+v", c.name, rule, c.rule)
+		}
+	}
+
+	rules := parseCertificateRules(" cn=deploy-bot:ci:OPERATOR ; serial=1:x:MONITOR;;ou=build:builder:MONITOR")
+	if len(rules) != 2 {
+		t.Fatalf("invalid rule is not skipped : %d rules", len(rules))
+	}
+}
+
+func TestValidateClientCertificate(t *testing.T) {
+	interactor := &DomainInteractor{
+		certificateRules: parseCertificateRules("cn=deploy-bot:ci:OPERATOR;dns=*.build.example.com:builder:OPERATOR@team-a;" +
+			"ip=10.0.0.*:agent:MONITOR;uri=spiffe://example.com/*:workload:MONITOR"),
+	}
+	newCert := func(cn string) *x509.Certificate {
+		return &x509.Certificate{
+			Raw:       []byte(cn),
+			Subject:   pkix.Name{CommonName: cn},
+			NotBefore: time.Now().Add(-time.Hour),
+			NotAfter:  time.Now().Add(time.Hour),
+		}
+	}
+
+	dns := newCert("host")
+	dns.DNSNames = []string{"a.build.example.com"}
+	ip := newCert("agent")
+	ip.IPAddresses = []net.IP{net.ParseIP("10.0.0.7")}
+	uri := newCert("workload")
+	spiffe, _ := url.Parse("spiffe://example.com/web")
+	uri.URIs = []*url.URL{spiffe}
+	other := newCert("host")
+	other.DNSNames = []string{"a.build.example.org"}
+
+	cases := []struct {
+		name   string
+		cert   *x509.Certificate
+		role   domain.Role
+		valid  bool
+		userId string
+		scope  string
+	}{
+		{"cn", newCert("deploy-bot"), domain.ROLE_OPERATOR, true, "ci", ""},
+		{"dns with scope", dns, domain.ROLE_OPERATOR, true, "builder", "team-a"},
+		{"ip", ip, domain.ROLE_MONITOR, true, "agent", ""},
+		{"uri", uri, domain.ROLE_MONITOR, true, "workload", ""},
+		{"insufficient role", ip, domain.ROLE_OPERATOR, false, "", ""},
+		{"other domain", other, domain.ROLE_MONITOR, false, "", ""},
+		{"no rule", newCert("stranger"), domain.ROLE_MONITOR, false, "", ""},
+	}
+
+	for _, c := range cases {
+		session, err := interactor.ValidateClientCertificate(c.cert, c.role, "127.0.0.1")
+		if (err == nil) != c.valid {
+			t.Fatalf("%s : valid %t", c.name, err == nil)
+		}
+		if !c.valid {
+			continue
+		}
+		if session.UserId != c.userId || session.Kind != domain.TOKEN_KIND_CERTIFICATE {
+			t.Fatalf("%s : session %+v", c.name, session)
+		}
+		if len(c.scope) > 0 && (len(session.Bindings) != 1 || session.Bindings[0].Scope != c.scope) {
+			t.Fatalf("%s : bindings %+v", c.name, session.Bindings)
+		}
+		if !session.ExpireAt.Equal(c.cert.NotAfter) {
+			t.Fatalf("%s : session does not expire with certificate", c.name)
+		}
+	}
+}
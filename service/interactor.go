@@ -26,72 +26,320 @@ package service
 import (
 	"fmt"
 	"github.com/fatima-go/fatima-core"
+	"github.com/fatima-go/fatima-log"
 	"github.com/fatima-go/jupiter/domain"
 	"github.com/fatima-go/jupiter/infra"
 	"github.com/fatima-go/jupiter/service/auth"
+	"strings"
 )
 
 const (
-	propAuth       = "auth"
-	valueAuthBasic = "basic"
-	valueAuthLdap  = "ldap"
+	propAuth        = "auth"
+	valueAuthBasic  = "basic"
+	valueAuthLdap   = "ldap"
+	valueAuthDir    = "directory"
+	propRepo        = "repo"
+	valueRepoFile   = "file"
+	valueRepoSql    = "sql"
+	valueRepoMemory = "memory"
+	propJunoRepo    = "juno.repo"
+
+	propAuthPasswordHash    = "auth.password.hash"
+	valueAuthPasswordHashNo = "none"
+
+	propTokenType        = "token.type"
+	valueTokenTypeRandom = "random"
+	valueTokenTypeSigned = "signed"
 )
 
 func NewDomainInteractor(fatimaRuntime fatima.FatimaRuntime) (*DomainInteractor, error) {
 	domainInteractor := new(DomainInteractor)
 	domainInteractor.fatimaRuntime = fatimaRuntime
-	domainInteractor.tokenService = auth.NewTokenHelper(fatimaRuntime)
-	domainInteractor.JunoRepository = infra.NewFileJunoRepository(fatimaRuntime)
 
 	var err error
+	domainInteractor.JunoRepository, err = newJunoRepository(fatimaRuntime)
+	if err != nil {
+		return domainInteractor, err
+	}
+	domainInteractor.healthHistory = newJunoHealthHistory(fatimaRuntime, domainInteractor.JunoRepository)
+	domainInteractor.healthMonitor = newJunoHealthMonitor(fatimaRuntime, domainInteractor.JunoRepository, domainInteractor.healthHistory)
+	domainInteractor.leaseReaper = newJunoLeaseReaper(fatimaRuntime, domainInteractor.JunoRepository, domainInteractor.healthHistory)
+	domainInteractor.junoKeyRepository = infra.NewFileJunoKeyRepository(fatimaRuntime)
+	domainInteractor.junoAuth = prepareJunoKey(fatimaRuntime, domainInteractor.junoKeyRepository)
+	domainInteractor.userRepository, err = newUserRepository(fatimaRuntime)
+	if err != nil {
+		return domainInteractor, err
+	}
+	domainInteractor.apiKeyRepository = infra.NewFileApiKeyRepository(fatimaRuntime)
+	domainInteractor.loginGuard = auth.NewLoginGuard(fatimaRuntime)
+	domainInteractor.certificateRules = loadCertificateRules(fatimaRuntime)
+
+	domainInteractor.tokenService, err = newTokenService(fatimaRuntime, domainInteractor.reloadGrant)
+	if err != nil {
+		return domainInteractor, err
+	}
+
+	domainInteractor.auditRepository, err = newAuditRepository(fatimaRuntime)
+	if err != nil {
+		return domainInteractor, err
+	}
+
+	domainInteractor.encdec, err = newEncdec(fatimaRuntime)
+	if err != nil {
+		return domainInteractor, err
+	}
+
+	domainInteractor.authenticator, err = newAuthenticator(fatimaRuntime, domainInteractor.userRepository, domainInteractor.encdec)
+	if err != nil {
+		return domainInteractor, err
+	}
+	domainInteractor.userDirectory = newUserDirectory(fatimaRuntime, domainInteractor.authenticator)
+
+	return domainInteractor, nil
+}
+
+type DomainInteractor struct {
+	fatimaRuntime     fatima.FatimaRuntime
+	authenticator     domain.Authenticate
+	tokenService      domain.TokenService
+	JunoRepository    domain.JunoRepository
+	healthHistory     *junoHealthHistory
+	healthMonitor     *junoHealthMonitor
+	leaseReaper       *junoLeaseReaper
+	junoKeyRepository domain.JunoKeyRepository
+	junoAuth          bool
+	userRepository    domain.UserRepository
+	apiKeyRepository  domain.ApiKeyRepository
+	loginGuard        *auth.LoginGuard
+	userDirectory     *userDirectory
+	certificateRules  []certificateRule
+	auditRepository   domain.AuditRepository
+	encdec            domain.Encdec
+}
 
-	// auth=basic
-	//auth.ldap.helper.ip=127.0.0.1
-	//auth.ldap.helper.port=6413
+// auth=ldap,basic
+// auth.ldap.helper.ip=127.0.0.1
+// auth.ldap.helper.port=6413
+func newAuthenticator(fatimaRuntime fatima.FatimaRuntime, userRepository domain.UserRepository, encdec domain.Encdec) (domain.Authenticate, error) {
 	authMethod, err := fatimaRuntime.GetConfig().GetString(propAuth)
 	if err != nil {
 		authMethod = valueAuthBasic
 	}
 
-	switch authMethod {
-	case valueAuthBasic:
-		domainInteractor.authenticator, err = auth.NewBasicAuthenticator(fatimaRuntime)
+	chain := auth.NewChainAuthenticator()
+	for _, method := range strings.Split(authMethod, ",") {
+		method = strings.ToLower(strings.TrimSpace(method))
+
+		var authenticator domain.Authenticate
+		switch method {
+		case valueAuthBasic:
+			authenticator, err = auth.NewBasicAuthenticator(fatimaRuntime, userRepository, encdec, &userMutex)
+		case valueAuthLdap:
+			authenticator, err = auth.NewLdapAuthenticator(fatimaRuntime)
+		case valueAuthDir:
+			authenticator, err = auth.NewDirectoryAuthenticator(fatimaRuntime)
+		default:
+			return nil, fmt.Errorf("unknown auth method %s", method)
+		}
 		if err != nil {
-			return domainInteractor, err
+			return nil, err
+		}
+		chain.Append(method, authenticator)
+	}
+
+	log.Info("authenticator chain : %s", chain)
+	return chain, nil
+}
+
+// repo=file
+func newUserRepository(fatimaRuntime fatima.FatimaRuntime) (domain.UserRepository, error) {
+	repo, ok := fatimaRuntime.GetConfig().GetValue(propRepo)
+	if ok {
+		switch strings.ToLower(repo) {
+		case valueRepoFile:
+			return infra.NewFileUserRepository(fatimaRuntime), nil
+		case valueRepoSql:
+			database, err := infra.NewSqlDatabase(fatimaRuntime)
+			if err != nil {
+				return nil, err
+			}
+			return infra.NewSqlUserRepository(database), nil
+		}
+	}
+	return infra.NewMemoryUserRepository(), nil
+}
+
+// juno.repo=file
+// when juno.repo is not set, juno packages are kept in file unless repo=sql
+func newJunoRepository(fatimaRuntime fatima.FatimaRuntime) (domain.JunoRepository, error) {
+	repo, ok := fatimaRuntime.GetConfig().GetValue(propJunoRepo)
+	if !ok {
+		repo, ok = fatimaRuntime.GetConfig().GetValue(propRepo)
+		if !ok || strings.ToLower(repo) != valueRepoSql {
+			repo = valueRepoFile
 		}
-	case valueAuthLdap:
-		domainInteractor.authenticator, err = auth.NewLdapAuthenticator(fatimaRuntime)
+	}
+
+	switch strings.ToLower(repo) {
+	case valueRepoFile:
+		return infra.NewFileJunoRepository(fatimaRuntime)
+	case valueRepoMemory:
+		log.Info("using memory juno repository. registrations are lost on restart")
+		return infra.NewMemoryDeployRepository(), nil
+	case valueRepoSql:
+		database, err := infra.NewSqlDatabase(fatimaRuntime)
 		if err != nil {
-			return domainInteractor, err
+			return nil, err
 		}
-	default:
-		return domainInteractor, fmt.Errorf("unknown auth method %s", authMethod)
+		log.Info("using sql juno repository")
+		return infra.NewSqlJunoRepository(database), nil
 	}
+	return nil, fmt.Errorf("unknown juno repository %s", repo)
+}
 
-	return domainInteractor, nil
+// token.type=random
+func newTokenService(fatimaRuntime fatima.FatimaRuntime, loadGrant auth.GrantLoader) (domain.TokenService, error) {
+	tokenType, ok := fatimaRuntime.GetConfig().GetValue(propTokenType)
+	if !ok {
+		tokenType = valueTokenTypeRandom
+	}
+
+	switch strings.ToLower(tokenType) {
+	case valueTokenTypeRandom:
+		return auth.NewTokenHelper(fatimaRuntime, loadGrant)
+	case valueTokenTypeSigned:
+		return auth.NewSignedTokenHelper(fatimaRuntime, loadGrant)
+	}
+	return nil, fmt.Errorf("unknown token type %s", tokenType)
 }
 
-type DomainInteractor struct {
-	fatimaRuntime  fatima.FatimaRuntime
-	authenticator  domain.Authenticate
-	tokenService   domain.TokenService
-	JunoRepository domain.JunoRepository
+// auth.password.hash=bcrypt
+func newEncdec(fatimaRuntime fatima.FatimaRuntime) (domain.Encdec, error) {
+	algorithm, ok := fatimaRuntime.GetConfig().GetValue(propAuthPasswordHash)
+	if !ok {
+		algorithm = infra.HASH_ALGORITHM_BCRYPT
+	}
+
+	if strings.ToLower(algorithm) == valueAuthPasswordHashNo {
+		log.Warn("password hash is disabled. passwords are stored as plain text")
+		return infra.NewDefaultEncdec(), nil
+	}
+
+	log.Info("using password hash : %s", algorithm)
+	return infra.NewHashEncdec(algorithm)
+}
+
+// ValidateUser authenticates user and returns owner session for token.
+// role bindings are loaded from user repository when user exists in it,
+// otherwise they are mapped from directory groups of user (auth.ldap.group.bindings).
+// repeated failure of user or client address is throttled by login guard
+func (interactor *DomainInteractor) ValidateUser(user domain.User, clientAddress string) (domain.Session, error) {
+	err := interactor.loginGuard.Check(user.Id, clientAddress)
+	if err != nil {
+		return domain.Session{}, err
+	}
+
+	role, err := interactor.authenticator.UserAuthenticate(user.Id, user.Password)
+	if err != nil {
+		interactor.loginGuard.Failure(user.Id, clientAddress)
+		return domain.Session{}, err
+	}
+	// failures are cleared after second factor when user has one
+	if !interactor.RequiresSecondFactor(user.Id) {
+		interactor.loginGuard.Success(user.Id)
+	}
+
+	owner := domain.Session{UserId: user.Id, Role: role}
+	if found := interactor.userRepository.FindById(user.Id); found != nil {
+		owner.Bindings = found.Bindings
+	}
+	if len(owner.Bindings) == 0 && interactor.userDirectory != nil {
+		owner.Bindings = interactor.userDirectory.resolveBindings(user.Id)
+	}
+	return owner, nil
+}
+
+// reloadGrant returns current grant of user for token refresh.
+// user of user repository keeps its role and bindings, directory user is validated on directory again
+func (interactor *DomainInteractor) reloadGrant(userId string) (domain.Grant, error) {
+	if found := interactor.userRepository.FindById(userId); found != nil {
+		return found.Grant(), nil
+	}
+
+	if interactor.userDirectory == nil {
+		return domain.Grant{}, fmt.Errorf("not found user %s", userId)
+	}
+
+	role, err := interactor.userDirectory.validateOwner(userId)
+	if err != nil {
+		return domain.Grant{}, fmt.Errorf("user %s is not valid : %s", userId, err.Error())
+	}
+	return domain.Grant{Role: role, Bindings: interactor.userDirectory.resolveBindings(userId)}, nil
 }
 
-func (interactor *DomainInteractor) ValidateUser(user domain.User) (domain.Role, error) {
-	return interactor.authenticator.UserAuthenticate(user.Id, user.Password)
+func (interactor *DomainInteractor) FindAllLockouts() []domain.Lockout {
+	return interactor.loginGuard.FindAllLockouts()
 }
 
-func (interactor *DomainInteractor) GenerateToken(role domain.Role) string {
-	token, _ := interactor.tokenService.GenerateToken(role)
+// Unlock clears failed logins of user id or client address(kind=client)
+func (interactor *DomainInteractor) Unlock(kind string, key string) error {
+	if !interactor.loginGuard.Unlock(kind, key) {
+		return fmt.Errorf("not found lockout of %s %s", kind, key)
+	}
+	log.Info("%s %s unlocked", kind, key)
+	return nil
+}
+
+func (interactor *DomainInteractor) GenerateToken(owner domain.Session) string {
+	token, _ := interactor.tokenService.GenerateToken(owner)
+	return token
+}
+
+func (interactor *DomainInteractor) GenerateInstantToken(owner domain.Session) string {
+	token, _ := interactor.tokenService.GenerateInstantToken(owner)
 	return token
 }
 
-func (interactor *DomainInteractor) GenerateInstantToken(role domain.Role) string {
-	token, _ := interactor.tokenService.GenerateInstantToken(role)
+func (interactor *DomainInteractor) GenerateRefreshToken(owner domain.Session) string {
+	token, _ := interactor.tokenService.GenerateRefreshToken(owner)
 	return token
 }
 
-func (interactor *DomainInteractor) ValidateToken(token string, role domain.Role) error {
+func (interactor *DomainInteractor) GeneratePendingToken(owner domain.Session) string {
+	token, _ := interactor.tokenService.GeneratePendingToken(owner)
+	return token
+}
+
+func (interactor *DomainInteractor) RedeemPendingToken(token string) (domain.Session, error) {
+	return interactor.tokenService.RedeemPendingToken(token)
+}
+
+func (interactor *DomainInteractor) RefreshToken(refreshToken string) (string, string, error) {
+	return interactor.tokenService.RefreshToken(refreshToken)
+}
+
+func (interactor *DomainInteractor) ValidateToken(token string, role domain.Role) (domain.Session, error) {
 	return interactor.tokenService.ValidateToken(token, role)
 }
+
+func (interactor *DomainInteractor) Logout(token string) {
+	interactor.tokenService.RevokeToken(token)
+}
+
+func (interactor *DomainInteractor) FindAllSessions() []domain.Session {
+	return interactor.tokenService.FindAllSessions()
+}
+
+func (interactor *DomainInteractor) RevokeSession(id string) error {
+	if !interactor.tokenService.RevokeSession(id) {
+		return fmt.Errorf("not found session %s", id)
+	}
+	log.Info("session %s revoked", id)
+	return nil
+}
+
+func (interactor *DomainInteractor) RevokeUserSessions(userId string) int {
+	count := interactor.tokenService.RevokeUserSessions(userId)
+	log.Info("%d sessions of user %s revoked", count, userId)
+	return count
+}
@@ -24,10 +24,8 @@
 package service
 
 import (
-	"github.com/fatima-go/fatima-core/lib"
 	"github.com/fatima-go/fatima-log"
 	"github.com/fatima-go/jupiter/domain"
-	"github.com/fatima-go/jupiter/web"
 	"strings"
 	"sync"
 	"time"
@@ -49,16 +47,30 @@ func (interactor *DomainInteractor) RegistJunoPackage(juno domain.JunoRegistrati
 	defer mutex.Unlock()
 
 	log.Info("try to REGIST juno registration : %s", juno)
+	juno.LeaseSeconds = interactor.leaseReaper.leaseSeconds(juno.LeaseSeconds)
+	if juno.LeaseSeconds > 0 {
+		interactor.leaseReaper.renew(juno.Endpoint, juno.LeaseSeconds, time.Now())
+	}
+
 	point := domain.PackagePoint{Host: juno.Host, Name: juno.Name}
-	summary := interactor.JunoRepository.FindAll()
-	element := summary.FindByPoint(point)
-	if element != nil {
-		log.Debug("update exist juno : %s", element)
+	exist := false
+	interactor.JunoRepository.Update(func(summary *domain.JunoSummary) bool {
+		element := summary.FindByPoint(point)
+		if element == nil {
+			return false
+		}
+		log.Debug("update exist juno : %s", element.Endpoint)
 		element.Endpoint = juno.Endpoint
 		element.RegistDate = time.Now().Unix()
 		element.Status = domain.JUNO_STATUS_ALIVE
 		element.Platform = juno.Platform
-		interactor.JunoRepository.SaveAll()
+		element.KeyId = juno.KeyId
+		element.LeaseSeconds = juno.LeaseSeconds
+		element.Labels = juno.Labels
+		exist = true
+		return true
+	})
+	if exist {
 		return
 	}
 
@@ -74,16 +86,17 @@ func (interactor *DomainInteractor) UnregistJunoPackage(endpoint string) {
 
 	log.Info("try to UNREGIST juno : %s", endpoint)
 
-	summary := interactor.JunoRepository.FindAll()
-	element := summary.FindByEndpoint(endpoint)
-	if element != nil {
-		log.Debug("update exist juno : %s", element)
+	interactor.JunoRepository.Update(func(summary *domain.JunoSummary) bool {
+		element := summary.FindByEndpoint(endpoint)
+		if element == nil {
+			return false
+		}
+		log.Debug("update exist juno : %s", element.Endpoint)
 		//element.Endpoint = juno.Endpoint
 		//element.RegistDate = time.Now().Unix()
 		element.Status = domain.JUNO_STATUS_DEAD
-		interactor.JunoRepository.SaveAll()
-		return
-	}
+		return true
+	})
 
 	/*
 		juno := interactor.JunoRepository.FindByEndpoint(endpoint)
@@ -98,6 +111,9 @@ func (interactor *DomainInteractor) UnregistJunoPackage(endpoint string) {
 }
 
 func (interactor *DomainInteractor) RemoveJunoPackage(endpoint string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
 	log.Info("try to REMOVE juno : %s", endpoint)
 
 	juno := interactor.JunoRepository.FindByEndpoint(endpoint)
@@ -107,22 +123,25 @@ func (interactor *DomainInteractor) RemoveJunoPackage(endpoint string) {
 	}
 
 	interactor.JunoRepository.Delete(endpoint)
+	interactor.leaseReaper.release(endpoint)
+	interactor.healthHistory.forget(endpoint)
 }
 
-func (interactor *DomainInteractor) GetPackageSummary(group string, location *time.Location) map[string]domain.PackageSummary {
+// GetPackageSummary returns deployment of packages on which caller has MONITOR role.
+// with selector, only packages matched by labels are reported
+func (interactor *DomainInteractor) GetPackageSummary(caller domain.Session, group string, selector domain.LabelSelector, location *time.Location) map[string]domain.PackageSummary {
 	report := make(map[string]domain.PackageSummary)
 	summary := domain.NewPackageSummary()
 	hostMap := make(map[string]int)
 
+	// status and last checked time are updated by juno health monitor
 	all := interactor.JunoRepository.FindAll()
-
-	refreshPackageHealth(all)
-	interactor.JunoRepository.SaveAll()
+	groups := selector.SelectGroups(filterGroups(caller, all.Groups, domain.ROLE_MONITOR))
 
 	if len(group) > 0 {
 		log.Debug("retrieve group : %s", group)
 		compName := strings.ToLower(group)
-		for _, g := range all.Groups {
+		for _, g := range groups {
 			if compName == strings.ToLower(g.Name) {
 				summary.Deployment = append(summary.Deployment, g.Clone(location))
 				summary.GroupCount = 1
@@ -135,57 +154,39 @@ func (interactor *DomainInteractor) GetPackageSummary(group string, location *ti
 		}
 	} else {
 		log.Debug("retrieve all groups")
-		for _, g := range all.Groups {
+		for _, g := range groups {
 			summary.Deployment = append(summary.Deployment, g.Clone(location))
 			summary.PackageCount = summary.PackageCount + len(g.Packages)
 			for _, p := range g.Packages {
 				hostMap[strings.ToLower(p.Host)]++
 			}
 		}
-		summary.GroupCount = len(all.Groups)
+		summary.GroupCount = len(groups)
 	}
 
 	for _, v := range hostMap {
 		summary.HostCount = summary.HostCount + v
 	}
 
+	interactor.fillPackageState(summary.Deployment, location)
 	report["summary"] = summary
 	return report
 }
 
-func refreshPackageHealth(summary *domain.JunoSummary) {
-	size := 0
-	for _, g := range summary.Groups {
-		size = size + len(g.Packages)
-	}
-
-	if size == 0 {
-		return
-	}
-
-	log.Debug("check package health")
-	cyBarrier := lib.NewCyclicBarrier(size, func() { log.Debug("finish refreshing juno health") })
-	for i := 0; i < len(summary.Groups); i++ {
-		group := &summary.Groups[i]
-		for j := 0; j < len(group.Packages); j++ {
-			pack := &group.Packages[j]
-			cyBarrier.Dispatch(func() { callHealthCheck(pack) })
+// fillPackageState fills last checked time, lease expiry and health of report which are kept in memory
+func (interactor *DomainInteractor) fillPackageState(groups []domain.JunoGroup, location *time.Location) {
+	for i := 0; i < len(groups); i++ {
+		for j := 0; j < len(groups[i].Packages); j++ {
+			pack := &groups[i].Packages[j]
+			pack.Health = interactor.healthHistory.report(*pack, location)
+			if checked, ok := interactor.healthMonitor.lastChecked(pack.Endpoint); ok {
+				pack.LastChecked = checked.In(location).Format(domain.TIME_YYYYMMDDHHMMSS)
+			}
+			if expire, ok := interactor.leaseReaper.expireAt(pack.Endpoint); ok && pack.LeaseSeconds > 0 {
+				pack.LeaseExpire = expire.In(location).Format(domain.TIME_YYYYMMDDHHMMSS)
+			}
 		}
 	}
-	cyBarrier.Wait()
-}
-
-func callHealthCheck(pack *domain.JunoPackage) bool {
-	httpClient := web.NewHttpClient(nil)
-	_, err := httpClient.Post(buildRestUrl(pack.Endpoint, "/package/health/v1"), nil)
-	if err != nil {
-		log.Warn("fail to check health for %s[%s] : %s", pack.Name, pack.Host, err.Error())
-		pack.Status = domain.JUNO_STATUS_DEAD
-	} else {
-		pack.Status = domain.JUNO_STATUS_ALIVE
-	}
-
-	return true
 }
 
 func buildRestUrl(endpoint string, suffix string) string {
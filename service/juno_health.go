@@ -0,0 +1,256 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 19. 오후 7:40
+ */
+package service
+
+import (
+	"github.com/fatima-go/fatima-core"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/web"
+	"sync"
+	"time"
+)
+
+// juno.health.interval.seconds=30
+// juno.health.timeout.seconds=3
+// juno.health.concurrency=16
+// juno.health.failure.threshold=3
+const (
+	propJunoHealthInterval         = "juno.health.interval.seconds"
+	propJunoHealthTimeout          = "juno.health.timeout.seconds"
+	propJunoHealthConcurrency      = "juno.health.concurrency"
+	propJunoHealthFailureThreshold = "juno.health.failure.threshold"
+
+	defaultJunoHealthInterval         = 30
+	defaultJunoHealthTimeout          = 3
+	defaultJunoHealthConcurrency      = 16
+	defaultJunoHealthFailureThreshold = 3
+
+	junoHealthUri = "/package/health/v1"
+)
+
+// junoHealthMonitor probes every registered juno in background and updates status in juno repository.
+// juno is marked dead after consecutive failures in health history reach threshold and alive on first success.
+// last checked time is kept in memory, so juno repository is rewritten only when status changes
+type junoHealthMonitor struct {
+	repository     domain.JunoRepository
+	history        *junoHealthHistory
+	interval       time.Duration
+	timeoutSeconds int
+	concurrency    int
+	threshold      int
+	checked        map[string]time.Time
+	checkedMutex   sync.Mutex
+	stopChan       chan struct{}
+	wg             sync.WaitGroup
+}
+
+func newJunoHealthMonitor(fatimaRuntime fatima.FatimaRuntime, repository domain.JunoRepository, history *junoHealthHistory) *junoHealthMonitor {
+	monitor := new(junoHealthMonitor)
+	monitor.repository = repository
+	monitor.history = history
+	monitor.checked = make(map[string]time.Time)
+
+	config := fatimaRuntime.GetConfig()
+	seconds, err := config.GetInt(propJunoHealthInterval)
+	if err != nil {
+		seconds = defaultJunoHealthInterval
+	}
+	monitor.interval = time.Second * time.Duration(seconds)
+
+	monitor.timeoutSeconds, err = config.GetInt(propJunoHealthTimeout)
+	if err != nil || monitor.timeoutSeconds < 1 {
+		monitor.timeoutSeconds = defaultJunoHealthTimeout
+	}
+
+	monitor.concurrency, err = config.GetInt(propJunoHealthConcurrency)
+	if err != nil || monitor.concurrency < 1 {
+		monitor.concurrency = defaultJunoHealthConcurrency
+	}
+
+	monitor.threshold, err = config.GetInt(propJunoHealthFailureThreshold)
+	if err != nil || monitor.threshold < 1 {
+		monitor.threshold = defaultJunoHealthFailureThreshold
+	}
+
+	return monitor
+}
+
+func (monitor *junoHealthMonitor) start() {
+	if monitor.interval <= 0 {
+		log.Info("juno health monitor is disabled")
+		return
+	}
+
+	log.Info("start juno health monitor. interval=%s, timeout=%ds, concurrency=%d, threshold=%d",
+		monitor.interval, monitor.timeoutSeconds, monitor.concurrency, monitor.threshold)
+	monitor.stopChan = make(chan struct{})
+	monitor.wg.Add(1)
+	go func() {
+		defer monitor.wg.Done()
+		ticker := time.NewTicker(monitor.interval)
+		defer ticker.Stop()
+
+		monitor.check()
+		for {
+			select {
+			case <-monitor.stopChan:
+				return
+			case <-ticker.C:
+				monitor.check()
+			}
+		}
+	}()
+}
+
+// stop waits running check to finish
+func (monitor *junoHealthMonitor) stop() {
+	if monitor.stopChan == nil {
+		return
+	}
+
+	close(monitor.stopChan)
+	monitor.wg.Wait()
+	monitor.stopChan = nil
+	log.Info("juno health monitor stopped")
+}
+
+// check probes junos concurrently without lock and applies results to current repository state,
+// so junos registered or removed during probing are kept as they are
+func (monitor *junoHealthMonitor) check() {
+	endpoints := monitor.endpoints()
+	if len(endpoints) == 0 {
+		return
+	}
+
+	results := make(map[string]domain.HealthResult, len(endpoints))
+	var resultMutex sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, monitor.concurrency)
+	for _, endpoint := range endpoints {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(endpoint string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			result := monitor.probe(endpoint)
+			resultMutex.Lock()
+			results[endpoint] = result
+			resultMutex.Unlock()
+		}(endpoint)
+	}
+	wg.Wait()
+
+	monitor.apply(results)
+}
+
+func (monitor *junoHealthMonitor) endpoints() []string {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	endpoints := make([]string, 0)
+	for _, g := range monitor.repository.FindAll().Groups {
+		for _, p := range g.Packages {
+			// juno with lease pushes heartbeat
+			if p.LeaseSeconds > 0 {
+				continue
+			}
+			endpoints = append(endpoints, p.Endpoint)
+		}
+	}
+	return endpoints
+}
+
+func (monitor *junoHealthMonitor) probe(endpoint string) domain.HealthResult {
+	result := domain.HealthResult{Time: time.Now()}
+	httpClient := web.NewHttpClient(nil)
+	_, err := httpClient.PostWithTimeout(buildRestUrl(endpoint, junoHealthUri), nil, monitor.timeoutSeconds)
+	result.Latency = time.Since(result.Time)
+	if err != nil {
+		log.Debug("fail to check health for %s : %s", endpoint, err.Error())
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// apply updates status of junos. repository is written only when status of any juno is changed
+func (monitor *junoHealthMonitor) apply(results map[string]domain.HealthResult) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	checked := make(map[string]time.Time, len(results))
+	monitor.repository.Update(func(summary *domain.JunoSummary) bool {
+		changed := false
+		for endpoint, result := range results {
+			pack := summary.FindByEndpoint(endpoint)
+			if pack == nil {
+				continue
+			}
+
+			checked[endpoint] = result.Time
+			failures := monitor.history.record(endpoint, result)
+			if result.IsAlive() {
+				if pack.Status != domain.JUNO_STATUS_ALIVE {
+					log.Info("juno %s[%s] is alive", pack.Name, pack.Host)
+					pack.Status = domain.JUNO_STATUS_ALIVE
+					changed = true
+				}
+				continue
+			}
+
+			if failures >= monitor.threshold && pack.Status != domain.JUNO_STATUS_DEAD {
+				log.Warn("juno %s[%s] is dead after %d failed health checks", pack.Name, pack.Host, failures)
+				pack.Status = domain.JUNO_STATUS_DEAD
+				changed = true
+			}
+		}
+		return changed
+	})
+
+	// junos removed or changed to lease are dropped with previous check
+	monitor.checkedMutex.Lock()
+	defer monitor.checkedMutex.Unlock()
+	monitor.checked = checked
+}
+
+// lastChecked returns time of last health check of endpoint
+func (monitor *junoHealthMonitor) lastChecked(endpoint string) (time.Time, bool) {
+	monitor.checkedMutex.Lock()
+	defer monitor.checkedMutex.Unlock()
+
+	checked, ok := monitor.checked[endpoint]
+	return checked, ok
+}
+
+// StartJunoHealthMonitor starts background health check of registered junos
+func (interactor *DomainInteractor) StartJunoHealthMonitor() {
+	interactor.healthHistory.start()
+	interactor.healthMonitor.start()
+}
+
+// StopJunoHealthMonitor stops health check and saves health history
+func (interactor *DomainInteractor) StopJunoHealthMonitor() {
+	interactor.healthMonitor.stop()
+	interactor.healthHistory.stop()
+}
@@ -0,0 +1,130 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 8:10
+ */
+package service
+
+import (
+	"github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/infra"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestJunoHealthMonitor(threshold int, endpoints ...string) *junoHealthMonitor {
+	repository := infra.NewMemoryDeployRepository()
+	for i, endpoint := range endpoints {
+		registration := domain.JunoRegistration{Group: "payment"}
+		registration.Host = "host-" + string(rune('a'+i))
+		registration.Name = "api"
+		registration.Endpoint = endpoint
+		repository.Save(registration)
+	}
+
+	return &junoHealthMonitor{
+		repository: repository,
+		history: &junoHealthHistory{
+			repository:    repository,
+			size:          10,
+			flapThreshold: defaultJunoHealthFlapThreshold,
+			histories:     make(map[string]*domain.HealthHistory),
+		},
+		timeoutSeconds: 1,
+		concurrency:    2,
+		threshold:      threshold,
+		checked:        make(map[string]time.Time),
+	}
+}
+
+func TestJunoHealthMonitorApply(t *testing.T) {
+	endpoint := "http://10.0.0.1:9180"
+	monitor := newTestJunoHealthMonitor(3, endpoint)
+	alive := domain.HealthResult{Time: time.Now()}
+	dead := domain.HealthResult{Time: time.Now(), Error: "connection refused"}
+
+	cases := []struct {
+		name   string
+		result domain.HealthResult
+		status string
+	}{
+		{"alive", alive, domain.JUNO_STATUS_ALIVE},
+		{"first failure", dead, domain.JUNO_STATUS_ALIVE},
+		{"second failure", dead, domain.JUNO_STATUS_ALIVE},
+		{"threshold reached", dead, domain.JUNO_STATUS_DEAD},
+		{"still dead", dead, domain.JUNO_STATUS_DEAD},
+		{"recovered on first success", alive, domain.JUNO_STATUS_ALIVE},
+		{"failure count restarts", dead, domain.JUNO_STATUS_ALIVE},
+	}
+
+	for _, c := range cases {
+		monitor.apply(map[string]domain.HealthResult{endpoint: c.result, "http://10.0.0.9:9180": dead})
+		pack := monitor.repository.FindByEndpoint(endpoint)
+		if pack.Status != c.status {
+			t.Fatalf("%s : status %s, expected %s", c.name, pack.Status, c.status)
+		}
+		if checked, ok := monitor.lastChecked(endpoint); !ok || !checked.Equal(c.result.Time) {
+			t.Fatalf("%s : last checked is not updated", c.name)
+		}
+	}
+
+	if _, ok := monitor.lastChecked("http://10.0.0.9:9180"); ok {
+		t.Fatalf("unregistered endpoint is checked")
+	}
+}
+
+func TestJunoHealthMonitorCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	stopped := httptest.NewServer(http.NotFoundHandler())
+	stopped.Close()
+
+	monitor := newTestJunoHealthMonitor(2, server.URL, stopped.URL)
+	for i := 0; i < 2; i++ {
+		monitor.check()
+	}
+
+	cases := []struct {
+		name     string
+		endpoint string
+		status   string
+		failures int
+	}{
+		{"running juno", server.URL, domain.JUNO_STATUS_ALIVE, 0},
+		{"stopped juno", stopped.URL, domain.JUNO_STATUS_DEAD, 2},
+	}
+
+	for _, c := range cases {
+		pack := monitor.repository.FindByEndpoint(c.endpoint)
+		if pack.Status != c.status {
+			t.Fatalf("%s : status %s, expected %s", c.name, pack.Status, c.status)
+		}
+		if n := len(monitor.history.results(c.endpoint)); n != 2 {
+			t.Fatalf("%s : %d health results, expected 2", c.name, n)
+		}
+		if health := monitor.history.report(*pack, time.UTC); health.ConsecutiveFailures != c.failures {
+			t.Fatalf("%s : %d failures, expected %d", c.name, health.ConsecutiveFailures, c.failures)
+		}
+	}
+}
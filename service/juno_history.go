@@ -0,0 +1,245 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 19. 오후 9:30
+ */
+package service
+
+import (
+	"github.com/fatima-go/fatima-core"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"sync"
+	"time"
+)
+
+// juno.health.history.size=20
+// juno.health.flap.threshold=4
+// juno.health.history.save.seconds=60
+const (
+	propJunoHealthHistorySize   = "juno.health.history.size"
+	propJunoHealthFlapThreshold = "juno.health.flap.threshold"
+	propJunoHealthHistorySave   = "juno.health.history.save.seconds"
+
+	defaultJunoHealthHistorySize   = 20
+	defaultJunoHealthFlapThreshold = 4
+	defaultJunoHealthHistorySave   = 60
+)
+
+// junoHealthHistory keeps recent health results of each juno endpoint.
+// results come from health monitor, heartbeat and lease expiry.
+// history is saved with juno registry every save interval when changed and on stop, and loaded on start
+type junoHealthHistory struct {
+	repository    domain.JunoRepository
+	size          int
+	flapThreshold int
+	saveInterval  time.Duration
+	histories     map[string]*domain.HealthHistory
+	dirty         bool
+	mutex         sync.Mutex
+	stopChan      chan struct{}
+	wg            sync.WaitGroup
+}
+
+func newJunoHealthHistory(fatimaRuntime fatima.FatimaRuntime, repository domain.JunoRepository) *junoHealthHistory {
+	history := new(junoHealthHistory)
+	history.repository = repository
+	history.histories = make(map[string]*domain.HealthHistory)
+
+	var err error
+	history.size, err = fatimaRuntime.GetConfig().GetInt(propJunoHealthHistorySize)
+	if err != nil || history.size < 2 {
+		history.size = defaultJunoHealthHistorySize
+	}
+
+	history.flapThreshold, err = fatimaRuntime.GetConfig().GetInt(propJunoHealthFlapThreshold)
+	if err != nil || history.flapThreshold < 1 {
+		history.flapThreshold = defaultJunoHealthFlapThreshold
+	}
+
+	seconds, err := fatimaRuntime.GetConfig().GetInt(propJunoHealthHistorySave)
+	if err != nil {
+		seconds = defaultJunoHealthHistorySave
+	}
+	history.saveInterval = time.Second * time.Duration(seconds)
+
+	history.load()
+	return history
+}
+
+// load restores saved history of registered junos. ring keeps latest results when size is reduced
+func (history *junoHealthHistory) load() {
+	registered := make(map[string]bool)
+	for _, g := range history.repository.FindAll().Groups {
+		for _, p := range g.Packages {
+			registered[p.Endpoint] = true
+		}
+	}
+
+	for endpoint, results := range history.repository.FindHealthHistory() {
+		if !registered[endpoint] {
+			continue
+		}
+		h := domain.NewHealthHistory(history.size)
+		for _, r := range results {
+			h.Add(r)
+		}
+		history.histories[endpoint] = h
+	}
+
+	if len(history.histories) > 0 {
+		log.Info("health history of %d junos loaded", len(history.histories))
+	}
+}
+
+// save writes history to repository when it is changed after last save
+func (history *junoHealthHistory) save() error {
+	history.mutex.Lock()
+	if !history.dirty {
+		history.mutex.Unlock()
+		return nil
+	}
+	snapshot := make(map[string][]domain.HealthResult, len(history.histories))
+	for endpoint, h := range history.histories {
+		snapshot[endpoint] = h.Results()
+	}
+	history.dirty = false
+	history.mutex.Unlock()
+
+	err := history.repository.SaveHealthHistory(snapshot)
+	if err != nil {
+		history.mutex.Lock()
+		history.dirty = true
+		history.mutex.Unlock()
+	}
+	return err
+}
+
+func (history *junoHealthHistory) saveOrWarn() {
+	if err := history.save(); err != nil {
+		log.Warn("fail to save health history : %s", err.Error())
+	}
+}
+
+// start saves history in background. history is saved on stop even if save interval is 0
+func (history *junoHealthHistory) start() {
+	if history.saveInterval <= 0 {
+		return
+	}
+
+	history.stopChan = make(chan struct{})
+	history.wg.Add(1)
+	go func() {
+		defer history.wg.Done()
+		ticker := time.NewTicker(history.saveInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-history.stopChan:
+				return
+			case <-ticker.C:
+				history.saveOrWarn()
+			}
+		}
+	}()
+}
+
+func (history *junoHealthHistory) stop() {
+	if history.stopChan != nil {
+		close(history.stopChan)
+		history.wg.Wait()
+		history.stopChan = nil
+	}
+	history.saveOrWarn()
+}
+
+// record appends result and returns consecutive failures of endpoint
+func (history *junoHealthHistory) record(endpoint string, result domain.HealthResult) int {
+	history.mutex.Lock()
+	defer history.mutex.Unlock()
+
+	h, ok := history.histories[endpoint]
+	if !ok {
+		h = domain.NewHealthHistory(history.size)
+		history.histories[endpoint] = h
+	}
+	h.Add(result)
+	history.dirty = true
+	return h.ConsecutiveFailures()
+}
+
+func (history *junoHealthHistory) forget(endpoint string) {
+	history.mutex.Lock()
+	defer history.mutex.Unlock()
+
+	if _, ok := history.histories[endpoint]; ok {
+		delete(history.histories, endpoint)
+		history.dirty = true
+	}
+}
+
+func (history *junoHealthHistory) results(endpoint string) []domain.HealthResult {
+	history.mutex.Lock()
+	defer history.mutex.Unlock()
+
+	h, ok := history.histories[endpoint]
+	if !ok {
+		return nil
+	}
+	return h.Results()
+}
+
+// report returns health of juno. juno is flapping when alive/dead changed flapThreshold times or more in history
+func (history *junoHealthHistory) report(pack domain.JunoPackage, location *time.Location) *domain.JunoHealth {
+	history.mutex.Lock()
+	defer history.mutex.Unlock()
+
+	health := &domain.JunoHealth{State: domain.JUNO_HEALTH_ALIVE}
+	if pack.Status == domain.JUNO_STATUS_DEAD {
+		health.State = domain.JUNO_HEALTH_DEAD
+	}
+
+	h, ok := history.histories[pack.Endpoint]
+	if !ok {
+		return health
+	}
+
+	health.Uptime = h.Uptime()
+	health.ConsecutiveFailures = h.ConsecutiveFailures()
+	health.Transitions = h.Transitions()
+	health.Samples = len(h.Results())
+	if alive := h.LastSeenAlive(); !alive.IsZero() {
+		health.LastSeenAlive = alive.In(location).Format(domain.TIME_YYYYMMDDHHMMSS)
+	}
+	if health.Transitions >= history.flapThreshold {
+		health.State = domain.JUNO_HEALTH_FLAPPING
+	}
+	return health
+}
+
+// FindJunoHealthHistory returns recent health results of juno endpoint on which caller has MONITOR role
+func (interactor *DomainInteractor) FindJunoHealthHistory(caller domain.Session, endpoint string) ([]domain.HealthResult, bool) {
+	summary := interactor.JunoRepository.FindAll()
+	if len(filterEndpoints(caller, summary, []string{endpoint}, domain.ROLE_MONITOR)) == 0 {
+		return nil, false
+	}
+	return interactor.healthHistory.results(endpoint), true
+}
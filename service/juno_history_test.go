@@ -0,0 +1,124 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 8:50
+ */
+package service
+
+import (
+	"github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/infra"
+	"testing"
+	"time"
+)
+
+func TestJunoHealthHistoryReport(t *testing.T) {
+	endpoint := "http://10.0.0.1:9180"
+	base := time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		status   string
+		pattern  string
+		state    string
+		failures int
+	}{
+		{"no history", domain.JUNO_STATUS_ALIVE, "", domain.JUNO_HEALTH_ALIVE, 0},
+		{"no history dead", domain.JUNO_STATUS_DEAD, "", domain.JUNO_HEALTH_DEAD, 0},
+		{"stable", domain.JUNO_STATUS_ALIVE, "oooooo", domain.JUNO_HEALTH_ALIVE, 0},
+		{"dead", domain.JUNO_STATUS_DEAD, "oooxxx", domain.JUNO_HEALTH_DEAD, 3},
+		{"below flap threshold", domain.JUNO_STATUS_ALIVE, "xoooxo", domain.JUNO_HEALTH_ALIVE, 0},
+		{"flapping alive", domain.JUNO_STATUS_ALIVE, "oxoxoo", domain.JUNO_HEALTH_FLAPPING, 0},
+		{"flapping dead", domain.JUNO_STATUS_DEAD, "xoxoxx", domain.JUNO_HEALTH_FLAPPING, 2},
+		{"flap aged out", domain.JUNO_STATUS_ALIVE, "xoxoxo" + "oooooooooo", domain.JUNO_HEALTH_ALIVE, 0},
+	}
+
+	for _, c := range cases {
+		history := &junoHealthHistory{size: 10, flapThreshold: 4, histories: make(map[string]*domain.HealthHistory)}
+		for i, r := range c.pattern {
+			result := domain.HealthResult{Time: base.Add(time.Second * time.Duration(i))}
+			if r == 'x' {
+				result.Error = "connection refused"
+			}
+			history.record(endpoint, result)
+		}
+
+		health := history.report(domain.JunoPackage{Endpoint: endpoint, Status: c.status}, time.UTC)
+		if health.State != c.state {
+			t.Fatalf("%s : state %s, expected %s", c.name, health.State, c.state)
+		}
+		if health.ConsecutiveFailures != c.failures {
+			t.Fatalf("%s : %d failures, expected %d", c.name, health.ConsecutiveFailures, c.failures)
+		}
+		samples := len(c.pattern)
+		if samples > history.size {
+			samples = history.size
+		}
+		if health.Samples != samples {
+			t.Fatalf("%s : %d samples, expected %d", c.name, health.Samples, samples)
+		}
+	}
+}
+
+func TestJunoHealthHistorySaveLoad(t *testing.T) {
+	repository := infra.NewMemoryDeployRepository()
+	registration := domain.JunoRegistration{Group: "payment"}
+	registration.Host = "host-1"
+	registration.Name = "api"
+	registration.Endpoint = "http://10.0.0.1:9180"
+	repository.Save(registration)
+
+	history := &junoHealthHistory{repository: repository, size: 4, histories: make(map[string]*domain.HealthHistory)}
+	base := time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		history.record(registration.Endpoint, domain.HealthResult{Time: base.Add(time.Second * time.Duration(i))})
+		history.record("http://10.0.0.9:9180", domain.HealthResult{Time: base})
+	}
+	if err := history.save(); err != nil {
+		t.Fatalf("fail to save health history : %s", err.Error())
+	}
+	if history.dirty {
+		t.Fatalf("history is dirty after save")
+	}
+
+	cases := []struct {
+		name     string
+		size     int
+		endpoint string
+		samples  int
+		first    time.Time
+	}{
+		{"restored", 4, registration.Endpoint, 3, base},
+		{"latest kept on smaller size", 2, registration.Endpoint, 2, base.Add(time.Second)},
+		{"unregistered dropped", 4, "http://10.0.0.9:9180", 0, time.Time{}},
+	}
+
+	for _, c := range cases {
+		loaded := &junoHealthHistory{repository: repository, size: c.size, histories: make(map[string]*domain.HealthHistory)}
+		loaded.load()
+		results := loaded.results(c.endpoint)
+		if len(results) != c.samples {
+			t.Fatalf("%s : %d samples, expected %d", c.name, len(results), c.samples)
+		}
+		if c.samples > 0 && !results[0].Time.Equal(c.first) {
+			t.Fatalf("%s : first result at %s, expected %s", c.name, results[0].Time, c.first)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 2:41
+ */
+
+package service
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/fatima-go/fatima-core"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/infra"
+	"strings"
+	"sync"
+	"time"
+)
+
+// juno.auth=true
+// juno.secret.grace.seconds=600
+const (
+	propJunoAuth                  = "juno.auth"
+	propJunoSecretGraceSeconds    = "juno.secret.grace.seconds"
+	defaultJunoSecretGraceSeconds = 600
+	junoKeyLength                 = 40
+)
+
+var junoKeyMutex sync.Mutex
+
+func prepareJunoKey(fatimaRuntime fatima.FatimaRuntime, repo domain.JunoKeyRepository) bool {
+	enabled, err := fatimaRuntime.GetConfig().GetBool(propJunoAuth)
+	if err != nil {
+		enabled = true
+	}
+
+	if !enabled {
+		log.Warn("juno registration authentication is disabled")
+		return false
+	}
+
+	data := repo.Find()
+	if len(data.Secret) == 0 {
+		data.Secret = infra.GenerateSecret(junoKeyLength)
+		err = repo.Save(data)
+		if err != nil {
+			log.Warn("fail to create juno registration secret : %s", err.Error())
+		} else {
+			log.Info("created juno registration secret. see %s", infra.JUNO_KEY_DATA_FILE)
+		}
+	}
+
+	return true
+}
+
+// AuthenticateJuno checks key presented by juno agent and returns key id to record on package.
+// enrolled host only accepts its own enrollment key
+func (interactor *DomainInteractor) AuthenticateJuno(host string, key string) (string, error) {
+	if !interactor.junoAuth {
+		return "", nil
+	}
+
+	if len(key) == 0 {
+		return "", errors.New("not found juno key")
+	}
+
+	data := interactor.junoKeyRepository.Find()
+	if len(host) > 0 {
+		if hostKey, ok := data.FindHostKey(host); ok {
+			if equalKey(hostKey, key) {
+				return domain.BuildJunoKeyId(domain.JUNO_KEY_KIND_HOST, hostKey), nil
+			}
+			return "", fmt.Errorf("missmatch enrollment key for host %s", host)
+		}
+	}
+
+	if equalKey(data.Secret, key) {
+		return domain.BuildJunoKeyId(domain.JUNO_KEY_KIND_SECRET, data.Secret), nil
+	}
+
+	if len(data.PreviousSecret) > 0 && time.Now().Unix() < data.PreviousExpire {
+		if equalKey(data.PreviousSecret, key) {
+			log.Info("juno %s uses previous registration secret", host)
+			return domain.BuildJunoKeyId(domain.JUNO_KEY_KIND_SECRET, data.PreviousSecret), nil
+		}
+	}
+
+	return "", errors.New("missmatch juno key")
+}
+
+// AuthenticateJunoEndpoint checks key for already registered endpoint
+func (interactor *DomainInteractor) AuthenticateJunoEndpoint(endpoint string, key string) error {
+	host := ""
+	juno := interactor.JunoRepository.FindByEndpoint(endpoint)
+	if juno != nil {
+		host = juno.Host
+	}
+
+	_, err := interactor.AuthenticateJuno(host, key)
+	return err
+}
+
+// RotateJunoSecret replaces registration secret.
+// previous secret is still acceptable during juno.secret.grace.seconds
+func (interactor *DomainInteractor) RotateJunoSecret() (string, error) {
+	junoKeyMutex.Lock()
+	defer junoKeyMutex.Unlock()
+
+	grace, err := interactor.fatimaRuntime.GetConfig().GetInt(propJunoSecretGraceSeconds)
+	if err != nil {
+		grace = defaultJunoSecretGraceSeconds
+	}
+
+	data := interactor.junoKeyRepository.Find()
+	if len(data.Secret) > 0 && grace > 0 {
+		data.PreviousSecret = data.Secret
+		data.PreviousExpire = time.Now().Add(time.Second * time.Duration(grace)).Unix()
+	} else {
+		data.PreviousSecret = ""
+		data.PreviousExpire = 0
+	}
+	data.Secret = infra.GenerateSecret(junoKeyLength)
+
+	err = interactor.junoKeyRepository.Save(data)
+	if err != nil {
+		return "", err
+	}
+
+	log.Info("juno registration secret rotated. previous one expires after %d seconds", grace)
+	return data.Secret, nil
+}
+
+func (interactor *DomainInteractor) EnrollJunoHost(host string) (string, error) {
+	if len(host) == 0 {
+		return "", errors.New("empty host")
+	}
+
+	junoKeyMutex.Lock()
+	defer junoKeyMutex.Unlock()
+
+	data := interactor.junoKeyRepository.Find()
+	key := infra.GenerateSecret(junoKeyLength)
+	data.Hosts[strings.ToLower(host)] = key
+	err := interactor.junoKeyRepository.Save(data)
+	if err != nil {
+		return "", err
+	}
+
+	log.Info("juno host %s enrolled", host)
+	return key, nil
+}
+
+func (interactor *DomainInteractor) RevokeJunoHost(host string) error {
+	junoKeyMutex.Lock()
+	defer junoKeyMutex.Unlock()
+
+	data := interactor.junoKeyRepository.Find()
+	if _, ok := data.FindHostKey(host); !ok {
+		return fmt.Errorf("not found enrolled host %s", host)
+	}
+
+	delete(data.Hosts, strings.ToLower(host))
+	err := interactor.junoKeyRepository.Save(data)
+	if err != nil {
+		return err
+	}
+
+	log.Info("juno host %s enrollment revoked", host)
+	return nil
+}
+
+func equalKey(expected string, given string) bool {
+	if len(expected) == 0 {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(given)) == 1
+}
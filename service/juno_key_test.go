@@ -0,0 +1,151 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 4:10
+ */
+package service
+
+import (
+	"github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/infra"
+	"testing"
+	"time"
+)
+
+// testJunoKeyRepository keeps juno key data in memory
+type testJunoKeyRepository struct {
+	data domain.JunoKeyData
+}
+
+func (r *testJunoKeyRepository) Find() domain.JunoKeyData {
+	data := r.data
+	data.Hosts = make(map[string]string)
+	for k, v := range r.data.Hosts {
+		data.Hosts[k] = v
+	}
+	return data
+}
+
+func (r *testJunoKeyRepository) Save(data domain.JunoKeyData) error {
+	r.data = data
+	return nil
+}
+
+func newTestJunoKeyInteractor(data domain.JunoKeyData) *DomainInteractor {
+	return &DomainInteractor{
+		JunoRepository:    infra.NewMemoryDeployRepository(),
+		junoKeyRepository: &testJunoKeyRepository{data: data},
+		junoAuth:          true,
+	}
+}
+
+func TestAuthenticateJuno(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name     string
+		data     domain.JunoKeyData
+		host     string
+		key      string
+		accepted bool
+	}{
+		{"secret", domain.JunoKeyData{Secret: "current"}, "host-1", "current", true},
+		{"wrong secret", domain.JunoKeyData{Secret: "current"}, "host-1", "wrong", false},
+		{"empty key", domain.JunoKeyData{Secret: "current"}, "host-1", "", false},
+		{"empty secret", domain.JunoKeyData{}, "host-1", "", false},
+		{"previous secret in grace",
+			domain.JunoKeyData{Secret: "current", PreviousSecret: "previous", PreviousExpire: now.Add(time.Minute).Unix()},
+			"host-1", "previous", true},
+		{"previous secret after grace",
+			domain.JunoKeyData{Secret: "current", PreviousSecret: "previous", PreviousExpire: now.Add(-time.Second).Unix()},
+			"host-1", "previous", false},
+		{"enrolled host key",
+			domain.JunoKeyData{Secret: "current", Hosts: map[string]string{"host-1": "host-key"}},
+			"HOST-1", "host-key", true},
+		{"enrolled host with secret",
+			domain.JunoKeyData{Secret: "current", Hosts: map[string]string{"host-1": "host-key"}},
+			"host-1", "current", false},
+		{"key of other host",
+			domain.JunoKeyData{Secret: "current", Hosts: map[string]string{"host-1": "host-key"}},
+			"host-2", "host-key", false},
+	}
+
+	for _, c := range cases {
+		interactor := newTestJunoKeyInteractor(c.data)
+		keyId, err := interactor.AuthenticateJuno(c.host, c.key)
+		if (err == nil) != c.accepted {
+			t.Fatalf("%s : accepted %t", c.name, err == nil)
+		}
+		if c.accepted && len(keyId) == 0 {
+			t.Fatalf("%s : empty key id", c.name)
+		}
+	}
+}
+
+func TestAuthenticateJunoDisabled(t *testing.T) {
+	interactor := newTestJunoKeyInteractor(domain.JunoKeyData{Secret: "current"})
+	interactor.junoAuth = false
+	if _, err := interactor.AuthenticateJuno("host-1", ""); err != nil {
+		t.Fatalf("juno is rejected without juno.auth : %s", err.Error())
+	}
+}
+
+func TestEnrollJunoHost(t *testing.T) {
+	interactor := newTestJunoKeyInteractor(domain.JunoKeyData{Secret: "current"})
+
+	key, err := interactor.EnrollJunoHost("Host-1")
+	if err != nil {
+		t.Fatalf("fail to enroll host : %s", err.Error())
+	}
+	if _, err = interactor.AuthenticateJuno("host-1", key); err != nil {
+		t.Fatalf("enrollment key is rejected : %s", err.Error())
+	}
+	if _, err = interactor.AuthenticateJuno("host-1", "current"); err == nil {
+		t.Fatalf("enrolled host is accepted with registration secret")
+	}
+
+	if err = interactor.RevokeJunoHost("host-1"); err != nil {
+		t.Fatalf("fail to revoke host : %s", err.Error())
+	}
+	if _, err = interactor.AuthenticateJuno("host-1", key); err == nil {
+		t.Fatalf("revoked enrollment key is accepted")
+	}
+	if err = interactor.RevokeJunoHost("host-1"); err == nil {
+		t.Fatalf("revoked host is revoked again")
+	}
+}
+
+func TestAuthenticateJunoEndpoint(t *testing.T) {
+	interactor := newTestJunoKeyInteractor(domain.JunoKeyData{Secret: "current", Hosts: map[string]string{"host-1": "host-key"}})
+	registration := domain.JunoRegistration{Group: "payment"}
+	registration.Endpoint = "http://10.0.0.1:9180"
+	registration.Host = "host-1"
+	registration.Name = "default"
+	interactor.JunoRepository.Save(registration)
+
+	if err := interactor.AuthenticateJunoEndpoint(registration.Endpoint, "host-key"); err != nil {
+		t.Fatalf("host key of endpoint is rejected : %s", err.Error())
+	}
+	if err := interactor.AuthenticateJunoEndpoint(registration.Endpoint, "current"); err == nil {
+		t.Fatalf("registration secret is accepted for enrolled endpoint")
+	}
+	if err := interactor.AuthenticateJunoEndpoint("http://10.0.0.2:9180", "current"); err != nil {
+		t.Fatalf("registration secret is rejected for unknown endpoint : %s", err.Error())
+	}
+}
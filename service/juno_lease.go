@@ -0,0 +1,293 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 19. 오후 8:30
+ */
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fatima-go/fatima-core"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"sync"
+	"time"
+)
+
+// juno.lease.seconds=0
+// juno.lease.max.seconds=3600
+// juno.lease.reap.seconds=10
+// juno.lease.remove.grace.seconds=0
+const (
+	propJunoLeaseSeconds     = "juno.lease.seconds"
+	propJunoLeaseMax         = "juno.lease.max.seconds"
+	propJunoLeaseReap        = "juno.lease.reap.seconds"
+	propJunoLeaseRemoveGrace = "juno.lease.remove.grace.seconds"
+
+	defaultJunoLeaseMax  = 3600
+	defaultJunoLeaseReap = 10
+)
+
+// junoLeaseReaper keeps lease expiry of junos sending heartbeat and marks expired junos dead.
+// expiry is kept in memory only, so heartbeat does not rewrite juno repository unless status changes.
+// after restart every leased juno gets a full lease to send next heartbeat
+type junoLeaseReaper struct {
+	repository     domain.JunoRepository
+	history        *junoHealthHistory
+	defaultSeconds int
+	maxSeconds     int
+	interval       time.Duration
+	removeGrace    time.Duration
+	leases         map[string]time.Time
+	leaseMutex     sync.Mutex
+	stopChan       chan struct{}
+	wg             sync.WaitGroup
+}
+
+func newJunoLeaseReaper(fatimaRuntime fatima.FatimaRuntime, repository domain.JunoRepository, history *junoHealthHistory) *junoLeaseReaper {
+	reaper := new(junoLeaseReaper)
+	reaper.repository = repository
+	reaper.history = history
+	reaper.leases = make(map[string]time.Time)
+
+	config := fatimaRuntime.GetConfig()
+	var err error
+	reaper.maxSeconds, err = config.GetInt(propJunoLeaseMax)
+	if err != nil || reaper.maxSeconds < 1 {
+		reaper.maxSeconds = defaultJunoLeaseMax
+	}
+
+	seconds, err := config.GetInt(propJunoLeaseSeconds)
+	if err == nil && seconds > 0 {
+		reaper.defaultSeconds = seconds
+	}
+	if reaper.defaultSeconds > reaper.maxSeconds {
+		log.Warn("%s(%d) is clamped to %s(%d)", propJunoLeaseSeconds, reaper.defaultSeconds, propJunoLeaseMax, reaper.maxSeconds)
+		reaper.defaultSeconds = reaper.maxSeconds
+	}
+
+	seconds, err = config.GetInt(propJunoLeaseReap)
+	if err != nil || seconds < 1 {
+		seconds = defaultJunoLeaseReap
+	}
+	reaper.interval = time.Second * time.Duration(seconds)
+
+	seconds, err = config.GetInt(propJunoLeaseRemoveGrace)
+	if err == nil && seconds > 0 {
+		reaper.removeGrace = time.Second * time.Duration(seconds)
+	}
+
+	return reaper
+}
+
+func (reaper *junoLeaseReaper) start() {
+	log.Info("start juno lease reaper. interval=%s, default lease=%ds, remove grace=%s",
+		reaper.interval, reaper.defaultSeconds, reaper.removeGrace)
+	reaper.stopChan = make(chan struct{})
+	reaper.wg.Add(1)
+	go func() {
+		defer reaper.wg.Done()
+		ticker := time.NewTicker(reaper.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-reaper.stopChan:
+				return
+			case now := <-ticker.C:
+				reaper.reap(now)
+			}
+		}
+	}()
+}
+
+func (reaper *junoLeaseReaper) stop() {
+	if reaper.stopChan == nil {
+		return
+	}
+
+	close(reaper.stopChan)
+	reaper.wg.Wait()
+	reaper.stopChan = nil
+	log.Info("juno lease reaper stopped")
+}
+
+// leaseSeconds returns lease of registration. 0 means juno does not send heartbeat.
+// lease longer than maximum (e.g. stored before maximum is lowered) is clamped
+func (reaper *junoLeaseReaper) leaseSeconds(requested int) int {
+	if requested > reaper.maxSeconds {
+		return reaper.maxSeconds
+	}
+	if requested > 0 {
+		return requested
+	}
+	return reaper.defaultSeconds
+}
+
+// validate checks lease requested by juno. 0 uses default lease
+func (reaper *junoLeaseReaper) validate(requested int) error {
+	if requested < 0 {
+		return fmt.Errorf("invalid lease_seconds %d", requested)
+	}
+	if requested > reaper.maxSeconds {
+		return fmt.Errorf("lease_seconds %d exceeds maximum %d", requested, reaper.maxSeconds)
+	}
+	return nil
+}
+
+func (reaper *junoLeaseReaper) renew(endpoint string, seconds int, now time.Time) time.Time {
+	reaper.leaseMutex.Lock()
+	defer reaper.leaseMutex.Unlock()
+
+	expire := now.Add(time.Second * time.Duration(seconds))
+	reaper.leases[endpoint] = expire
+	return expire
+}
+
+func (reaper *junoLeaseReaper) release(endpoint string) {
+	reaper.leaseMutex.Lock()
+	defer reaper.leaseMutex.Unlock()
+
+	delete(reaper.leases, endpoint)
+}
+
+func (reaper *junoLeaseReaper) expireAt(endpoint string) (time.Time, bool) {
+	reaper.leaseMutex.Lock()
+	defer reaper.leaseMutex.Unlock()
+
+	expire, ok := reaper.leases[endpoint]
+	return expire, ok
+}
+
+// reap marks juno dead when lease is expired and removes it after remove grace
+func (reaper *junoLeaseReaper) reap(now time.Time) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	reaper.leaseMutex.Lock()
+	defer reaper.leaseMutex.Unlock()
+
+	removes := make([]string, 0)
+	registered := make(map[string]bool)
+	reaper.repository.Update(func(summary *domain.JunoSummary) bool {
+		changed := false
+		for i := 0; i < len(summary.Groups); i++ {
+			for j := 0; j < len(summary.Groups[i].Packages); j++ {
+				pack := &summary.Groups[i].Packages[j]
+				if pack.LeaseSeconds <= 0 {
+					continue
+				}
+
+				registered[pack.Endpoint] = true
+				expire, ok := reaper.leases[pack.Endpoint]
+				if !ok {
+					reaper.leases[pack.Endpoint] = now.Add(time.Second * time.Duration(reaper.leaseSeconds(pack.LeaseSeconds)))
+					continue
+				}
+				if !now.After(expire) {
+					continue
+				}
+
+				if pack.Status != domain.JUNO_STATUS_DEAD {
+					log.Warn("juno %s[%s] lease expired at %s", pack.Name, pack.Host, expire.Format(domain.TIME_YYYYMMDDHHMMSS))
+					pack.Status = domain.JUNO_STATUS_DEAD
+					changed = true
+					reaper.history.record(pack.Endpoint, domain.HealthResult{Time: now, Error: "lease expired"})
+				}
+				if reaper.removeGrace > 0 && now.After(expire.Add(reaper.removeGrace)) {
+					removes = append(removes, pack.Endpoint)
+				}
+			}
+		}
+		return changed
+	})
+
+	for endpoint := range reaper.leases {
+		if !registered[endpoint] {
+			delete(reaper.leases, endpoint)
+		}
+	}
+
+	for _, endpoint := range removes {
+		log.Warn("remove juno %s. no heartbeat during remove grace", endpoint)
+		reaper.repository.Delete(endpoint)
+		delete(reaper.leases, endpoint)
+		reaper.history.forget(endpoint)
+	}
+}
+
+// HeartbeatJunoPackage renews lease of juno and marks it alive. leaseSeconds 0 keeps current lease of juno.
+// returns lease seconds which juno has to send next heartbeat within
+func (interactor *DomainInteractor) HeartbeatJunoPackage(endpoint string, leaseSeconds int) (int, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	var err error
+	interactor.JunoRepository.Update(func(summary *domain.JunoSummary) bool {
+		pack := summary.FindByEndpoint(endpoint)
+		if pack == nil {
+			err = fmt.Errorf("not found juno for endpoint %s", endpoint)
+			return false
+		}
+
+		changed := false
+		if leaseSeconds <= 0 {
+			leaseSeconds = pack.LeaseSeconds
+		}
+		leaseSeconds = interactor.leaseReaper.leaseSeconds(leaseSeconds)
+		if leaseSeconds <= 0 {
+			err = errors.New("lease seconds is required")
+			return false
+		}
+		if pack.LeaseSeconds != leaseSeconds {
+			pack.LeaseSeconds = leaseSeconds
+			changed = true
+		}
+
+		now := time.Now()
+		interactor.leaseReaper.renew(endpoint, leaseSeconds, now)
+		interactor.healthHistory.record(endpoint, domain.HealthResult{Time: now})
+		if pack.Status != domain.JUNO_STATUS_ALIVE {
+			log.Info("juno %s[%s] is alive by heartbeat", pack.Name, pack.Host)
+			pack.Status = domain.JUNO_STATUS_ALIVE
+			changed = true
+		}
+		return changed
+	})
+	if err != nil {
+		return 0, err
+	}
+	return leaseSeconds, nil
+}
+
+// ValidateJunoLease checks lease seconds requested on registration and heartbeat
+func (interactor *DomainInteractor) ValidateJunoLease(leaseSeconds int) error {
+	return interactor.leaseReaper.validate(leaseSeconds)
+}
+
+// StartJunoLeaseReaper starts background expiry of juno leases
+func (interactor *DomainInteractor) StartJunoLeaseReaper() {
+	interactor.leaseReaper.start()
+}
+
+func (interactor *DomainInteractor) StopJunoLeaseReaper() {
+	interactor.leaseReaper.stop()
+}
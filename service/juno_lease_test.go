@@ -0,0 +1,147 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 8:25
+ */
+package service
+
+import (
+	"github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/infra"
+	"testing"
+	"time"
+)
+
+func newTestLeaseInteractor(removeGrace time.Duration) *DomainInteractor {
+	repository := infra.NewMemoryDeployRepository()
+	history := &junoHealthHistory{
+		repository:    repository,
+		size:          10,
+		flapThreshold: defaultJunoHealthFlapThreshold,
+		histories:     make(map[string]*domain.HealthHistory),
+	}
+	return &DomainInteractor{
+		JunoRepository: repository,
+		healthHistory:  history,
+		leaseReaper: &junoLeaseReaper{
+			repository:  repository,
+			history:     history,
+			maxSeconds:  60,
+			interval:    time.Second,
+			removeGrace: removeGrace,
+			leases:      make(map[string]time.Time),
+		},
+	}
+}
+
+func TestJunoLeaseSeconds(t *testing.T) {
+	reaper := &junoLeaseReaper{defaultSeconds: 30, maxSeconds: 60}
+
+	cases := []struct {
+		name      string
+		requested int
+		valid     bool
+		seconds   int
+	}{
+		{"default", 0, true, 30},
+		{"requested", 10, true, 10},
+		{"maximum", 60, true, 60},
+		{"over maximum", 61, false, 60},
+		{"negative", -1, false, 30},
+	}
+
+	for _, c := range cases {
+		if err := reaper.validate(c.requested); (err == nil) != c.valid {
+			t.Fatalf("%s : valid %t", c.name, err == nil)
+		}
+		if seconds := reaper.leaseSeconds(c.requested); seconds != c.seconds {
+			t.Fatalf("%s : lease %d, expected %d", c.name, seconds, c.seconds)
+		}
+	}
+
+	reaper.defaultSeconds = 0
+	if seconds := reaper.leaseSeconds(0); seconds != 0 {
+		t.Fatalf("lease %d without default lease", seconds)
+	}
+}
+
+func TestJunoLeaseReap(t *testing.T) {
+	endpoint := "http://10.0.0.1:9180"
+	interactor := newTestLeaseInteractor(time.Minute)
+	registration := domain.JunoRegistration{Group: "payment"}
+	registration.Host = "host-1"
+	registration.Name = "api"
+	registration.Endpoint = endpoint
+	registration.Status = domain.JUNO_STATUS_ALIVE
+	registration.LeaseSeconds = 10
+	interactor.JunoRepository.Save(registration)
+
+	reaper := interactor.leaseReaper
+	var start time.Time
+	cases := []struct {
+		name      string
+		heartbeat bool
+		after     time.Duration
+		status    string
+		removed   bool
+	}{
+		{"lease after restart", false, 0, domain.JUNO_STATUS_ALIVE, false},
+		{"heartbeat", true, 0, domain.JUNO_STATUS_ALIVE, false},
+		{"within lease", false, time.Second * 10, domain.JUNO_STATUS_ALIVE, false},
+		{"lease expired", false, time.Second * 11, domain.JUNO_STATUS_DEAD, false},
+		{"alive by heartbeat", true, 0, domain.JUNO_STATUS_ALIVE, false},
+		{"expired again", false, time.Second * 20, domain.JUNO_STATUS_DEAD, false},
+		{"removed after grace", false, time.Second * 71, "", true},
+	}
+
+	for _, c := range cases {
+		if c.heartbeat {
+			seconds, err := interactor.HeartbeatJunoPackage(endpoint, 0)
+			if err != nil || seconds != 10 {
+				t.Fatalf("%s : heartbeat lease %d, %v", c.name, seconds, err)
+			}
+			start, _ = reaper.expireAt(endpoint)
+			start = start.Add(-time.Second * 10)
+		} else {
+			if start.IsZero() {
+				start = time.Now()
+			}
+			reaper.reap(start.Add(c.after))
+		}
+
+		pack := interactor.JunoRepository.FindByEndpoint(endpoint)
+		if c.removed {
+			if pack != nil {
+				t.Fatalf("%s : juno is not removed", c.name)
+			}
+			if _, ok := reaper.expireAt(endpoint); ok {
+				t.Fatalf("%s : lease of removed juno is kept", c.name)
+			}
+			continue
+		}
+		if pack == nil || pack.Status != c.status {
+			t.Fatalf("%s : juno %+v, expected status %s", c.name, pack, c.status)
+		}
+	}
+
+	if _, err := interactor.HeartbeatJunoPackage(endpoint, 0); err == nil {
+		t.Fatalf("heartbeat of removed juno is accepted")
+	}
+}
@@ -43,12 +43,28 @@ import (
 )
 
 type DeployRequest struct {
-	filename      string `json:"file_name"`
+	filename      string
 	group         string
 	pack          string
 	localpath     string
 	clientAddress string
-	when          string `json:"when"`
+	when          string
+	selector      string
+}
+
+// auditTarget returns far name with target group or package
+func (d DeployRequest) auditTarget() string {
+	switch {
+	case len(d.selector) > 0 && len(d.group) > 0:
+		return fmt.Sprintf("%s -> group %s, selector %s", d.filename, d.group, d.selector)
+	case len(d.selector) > 0:
+		return fmt.Sprintf("%s -> selector %s", d.filename, d.selector)
+	case len(d.group) > 0:
+		return fmt.Sprintf("%s -> group %s", d.filename, d.group)
+	case len(d.pack) > 0:
+		return fmt.Sprintf("%s -> package %s", d.filename, d.pack)
+	}
+	return fmt.Sprintf("%s -> %s", d.filename, d.clientAddress)
 }
 
 func (d DeployRequest) removeLocalFile() {
@@ -57,11 +73,16 @@ func (d DeployRequest) removeLocalFile() {
 	}
 }
 
-func (interactor *DomainInteractor) DeployPackage(mr *multipart.Reader, clientAddress string, token string) (string, error) {
+// DeployPackage sends far to target junos. only packages on which caller has OPERATOR role are deployed
+func (interactor *DomainInteractor) DeployPackage(caller domain.Session, mr *multipart.Reader, clientAddress string, token string) (result string, err error) {
 	req, err := buildDeployRequest(interactor.fatimaRuntime.GetEnv(), mr)
 	if err != nil {
+		interactor.auditAction(caller, clientAddress, domain.AUDIT_ACTION_DEPLOY, "", err)
 		return "", err
 	}
+	defer func() {
+		interactor.auditAction(caller, clientAddress, domain.AUDIT_ACTION_DEPLOY, req.auditTarget(), err)
+	}()
 
 	req.clientAddress = clientAddress
 	defer req.removeLocalFile()
@@ -74,9 +95,17 @@ func (interactor *DomainInteractor) DeployPackage(mr *multipart.Reader, clientAd
 	// get target juno list
 	var endpointList []string
 	endpointList, err = getEndpointList(req, interactor.JunoRepository)
+	if err != nil {
+		return "", err
+	}
+	if len(endpointList) == 0 {
+		return "", errors.New("not found endpoint")
+	}
+
+	endpointList = filterEndpoints(caller, interactor.JunoRepository.FindAll(), endpointList, domain.ROLE_OPERATOR)
 	endpointLen := len(endpointList)
 	if endpointLen == 0 {
-		return "", errors.New("not found endpoint")
+		return "", fmt.Errorf("user %s is not permitted to deploy to target", caller.UserId)
 	}
 
 	for _, e := range endpointList {
@@ -85,7 +114,7 @@ func (interactor *DomainInteractor) DeployPackage(mr *multipart.Reader, clientAd
 
 	// send to juno
 	stat, _ := os.Stat(req.localpath)
-	result := fmt.Sprintf("far name : %s (%d bytes). target : %d juno enqueued", req.filename, stat.Size(), len(endpointList))
+	result = fmt.Sprintf("far name : %s (%d bytes). target : %d juno enqueued", req.filename, stat.Size(), len(endpointList))
 	log.Info(result)
 
 	cyBarrier := lib.NewCyclicBarrier(endpointLen, func() { log.Info("%s 디플로이 완료", req.filename) })
@@ -181,6 +210,7 @@ func buildDeployRequest(env fatima.FatimaEnv, mr *multipart.Reader) (*DeployRequ
 			}
 			r.group = items["group"]
 			r.pack = items["package"]
+			r.selector = items["selector"]
 			r.filename = items["file"]
 			if len(r.filename) > 0 {
 				lastIndex := strings.LastIndex(r.filename, "/")
@@ -204,7 +234,16 @@ func buildDeployRequest(env fatima.FatimaEnv, mr *multipart.Reader) (*DeployRequ
 // get target juno list
 func getEndpointList(req *DeployRequest, repo domain.JunoRepository) ([]string, error) {
 	endpointList := make([]string, 0)
-	if len(req.group) > 0 {
+	if len(req.selector) > 0 {
+		selector, err := domain.ParseLabelSelector(req.selector)
+		if err != nil {
+			return nil, err
+		}
+		endpointList = retrieveBySelector(repo.FindAll(), req.group, selector)
+		if len(endpointList) == 0 {
+			return nil, fmt.Errorf("there are no endpoint for selector %s", req.selector)
+		}
+	} else if len(req.group) > 0 {
 		g := repo.FindGroup(req.group)
 		if g == nil {
 			return nil, fmt.Errorf("there are no endpoint for group %s", req.group)
@@ -216,7 +255,7 @@ func getEndpointList(req *DeployRequest, repo domain.JunoRepository) ([]string,
 		point := domain.NewPackagePoint(req.pack)
 		pack := repo.FindByPoint(point)
 		if pack == nil {
-			return nil, fmt.Errorf("not found endpoint for package %s", pack)
+			return nil, fmt.Errorf("not found endpoint for package %s", req.pack)
 		}
 		endpointList = append(endpointList, pack.Endpoint)
 	} else {
@@ -28,12 +28,16 @@ import (
 	"strings"
 )
 
-func (interactor *DomainInteractor) GetEndpointList(groupName string, point domain.PackagePoint, address string) []string {
+// GetEndpointList returns endpoints of target packages on which caller has OPERATOR role.
+// selector narrows packages of group (every group when group is empty)
+func (interactor *DomainInteractor) GetEndpointList(caller domain.Session, groupName string, selector domain.LabelSelector, point domain.PackagePoint, address string) []string {
 	list := make([]string, 0)
+	summary := interactor.JunoRepository.FindAll()
 
-	if len(groupName) > 0 {
-		summary := interactor.JunoRepository.FindAll()
-		return retrieveByGroup(summary, groupName)
+	if !selector.IsEmpty() {
+		list = retrieveBySelector(summary, groupName, selector)
+	} else if len(groupName) > 0 {
+		list = retrieveByGroup(summary, groupName)
 	} else if !point.IsEmpty() {
 		juno := interactor.JunoRepository.FindByPoint(point)
 		if juno != nil {
@@ -46,7 +50,7 @@ func (interactor *DomainInteractor) GetEndpointList(groupName string, point doma
 		}
 	}
 
-	return list
+	return filterEndpoints(caller, summary, list, domain.ROLE_OPERATOR)
 }
 
 func retrieveByGroup(summary *domain.JunoSummary, groupName string) []string {
@@ -64,3 +68,20 @@ func retrieveByGroup(summary *domain.JunoSummary, groupName string) []string {
 
 	return list
 }
+
+// retrieveBySelector returns endpoints of packages matched by selector. empty groupName means every group
+func retrieveBySelector(summary *domain.JunoSummary, groupName string, selector domain.LabelSelector) []string {
+	list := make([]string, 0)
+
+	compName := strings.ToLower(groupName)
+	for _, g := range selector.SelectGroups(summary.Groups) {
+		if len(compName) > 0 && compName != strings.ToLower(g.Name) {
+			continue
+		}
+		for _, p := range g.Packages {
+			list = append(list, p.Endpoint)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,75 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 8:20
+ */
+
+package service
+
+import (
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"strings"
+)
+
+// filterGroups returns groups with packages on which caller has role. groups without such package are dropped
+func filterGroups(caller domain.Session, groups []domain.JunoGroup, role domain.Role) []domain.JunoGroup {
+	grant := caller.Grant()
+	filtered := make([]domain.JunoGroup, 0, len(groups))
+	for _, g := range groups {
+		group := domain.JunoGroup{Name: g.Name}
+		for _, p := range g.Packages {
+			if grant.Permits(g.Name, domain.PackagePoint{Host: p.Host, Name: p.Name}, role) {
+				group.Packages = append(group.Packages, p)
+			}
+		}
+		if len(group.Packages) > 0 {
+			filtered = append(filtered, group)
+		}
+	}
+	return filtered
+}
+
+// filterEndpoints returns endpoints of packages on which caller has role
+func filterEndpoints(caller domain.Session, summary *domain.JunoSummary, endpoints []string, role domain.Role) []string {
+	permitted := make(map[string]bool)
+	for _, g := range filterGroups(caller, summary.Groups, role) {
+		for _, p := range g.Packages {
+			permitted[strings.ToLower(p.Endpoint)] = true
+		}
+	}
+
+	list := make([]string, 0, len(endpoints))
+	for _, e := range endpoints {
+		if permitted[strings.ToLower(e)] {
+			list = append(list, e)
+			continue
+		}
+		log.Warn("user %s has no %s role on endpoint %s", caller.UserId, role, e)
+	}
+	return list
+}
+
+// PermitsJunoEndpoint returns true when caller has role on package of registered endpoint.
+// unknown endpoint is never permitted
+func (interactor *DomainInteractor) PermitsJunoEndpoint(caller domain.Session, endpoint string, role domain.Role) bool {
+	summary := interactor.JunoRepository.FindAll()
+	return len(filterEndpoints(caller, summary, []string{endpoint}, role)) > 0
+}
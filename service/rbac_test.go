@@ -0,0 +1,96 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 5:20
+ */
+package service
+
+import (
+	"github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/infra"
+	"testing"
+)
+
+func newTestRbacInteractor() *DomainInteractor {
+	interactor := &DomainInteractor{JunoRepository: infra.NewMemoryDeployRepository()}
+	for _, r := range []struct{ group, host, name, endpoint string }{
+		{"payment", "host-1", "api", "http://10.0.0.1:9180"},
+		{"payment", "host-2", "batch", "http://10.0.0.2:9180"},
+		{"billing", "host-3", "api", "http://10.0.0.3:9180"},
+	} {
+		registration := domain.JunoRegistration{Group: r.group}
+		registration.Host = r.host
+		registration.Name = r.name
+		registration.Endpoint = r.endpoint
+		interactor.JunoRepository.Save(registration)
+	}
+	return interactor
+}
+
+func TestPermitsJunoEndpoint(t *testing.T) {
+	interactor := newTestRbacInteractor()
+	paymentOperator := domain.Session{UserId: "alice", Role: domain.ROLE_MONITOR,
+		Bindings: []domain.RoleBinding{domain.NewRoleBinding("payment", "OPERATOR")}}
+	batchOperator := domain.Session{UserId: "bob", Role: domain.ROLE_MONITOR,
+		Bindings: []domain.RoleBinding{domain.NewRoleBinding("*", "MONITOR"), domain.NewRoleBinding("host-2:batch", "OPERATOR")}}
+
+	cases := []struct {
+		name     string
+		caller   domain.Session
+		endpoint string
+		role     domain.Role
+		permits  bool
+	}{
+		{"global operator", domain.Session{Role: domain.ROLE_OPERATOR}, "http://10.0.0.3:9180", domain.ROLE_OPERATOR, true},
+		{"global monitor", domain.Session{Role: domain.ROLE_MONITOR}, "http://10.0.0.3:9180", domain.ROLE_OPERATOR, false},
+		{"bound group", paymentOperator, "http://10.0.0.1:9180", domain.ROLE_OPERATOR, true},
+		{"foreign group", paymentOperator, "http://10.0.0.3:9180", domain.ROLE_OPERATOR, false},
+		{"foreign group monitor", paymentOperator, "http://10.0.0.3:9180", domain.ROLE_MONITOR, false},
+		{"bound package", batchOperator, "http://10.0.0.2:9180", domain.ROLE_OPERATOR, true},
+		{"other package", batchOperator, "http://10.0.0.1:9180", domain.ROLE_OPERATOR, false},
+		{"wildcard monitor", batchOperator, "http://10.0.0.1:9180", domain.ROLE_MONITOR, true},
+		{"unknown endpoint", domain.Session{Role: domain.ROLE_OPERATOR}, "http://10.0.0.9:9180", domain.ROLE_OPERATOR, false},
+	}
+
+	for _, c := range cases {
+		if interactor.PermitsJunoEndpoint(c.caller, c.endpoint, c.role) != c.permits {
+			t.Fatalf("%s : permits %t", c.name, !c.permits)
+		}
+	}
+}
+
+func TestFilterGroups(t *testing.T) {
+	interactor := newTestRbacInteractor()
+	caller := domain.Session{UserId: "bob", Role: domain.ROLE_MONITOR,
+		Bindings: []domain.RoleBinding{domain.NewRoleBinding("billing", "MONITOR"), domain.NewRoleBinding("host-2:batch", "MONITOR")}}
+
+	groups := filterGroups(caller, interactor.JunoRepository.FindAll().Groups, domain.ROLE_MONITOR)
+	packages := make(map[string]int)
+	for _, g := range groups {
+		packages[g.Name] = len(g.Packages)
+	}
+	if len(packages) != 2 || packages["payment"] != 1 || packages["billing"] != 1 {
+		t.Fatalf("filtered groups : %v", packages)
+	}
+
+	if groups = filterGroups(caller, interactor.JunoRepository.FindAll().Groups, domain.ROLE_OPERATOR); len(groups) != 0 {
+		t.Fatalf("groups without OPERATOR binding are reported : %v", groups)
+	}
+}
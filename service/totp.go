@@ -0,0 +1,207 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 19. 오후 2:20
+ */
+package service
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/service/auth"
+	"time"
+)
+
+// auth.totp.issuer=jupiter
+const (
+	propAuthTotpIssuer    = "auth.totp.issuer"
+	defaultAuthTotpIssuer = "jupiter"
+	propAuthTotpSkew      = "auth.totp.skew"
+	defaultAuthTotpSkew   = 1
+)
+
+// RequiresSecondFactor returns true when user has confirmed TOTP enrollment
+func (interactor *DomainInteractor) RequiresSecondFactor(userId string) bool {
+	found := interactor.userRepository.FindById(userId)
+	return found != nil && found.Totp.IsEnabled()
+}
+
+// EnrollTotp creates new TOTP secret and recovery codes of user.
+// enrollment becomes effective after ConfirmTotp, enabled enrollment should be disabled first
+func (interactor *DomainInteractor) EnrollTotp(userId string) (domain.TotpEnrollment, error) {
+	userMutex.Lock()
+	defer userMutex.Unlock()
+
+	found := interactor.userRepository.FindById(userId)
+	if found == nil {
+		return domain.TotpEnrollment{}, fmt.Errorf("user %s is not in user repository", userId)
+	}
+	if found.Totp.IsEnabled() {
+		return domain.TotpEnrollment{}, fmt.Errorf("totp is already enabled for user %s", userId)
+	}
+
+	secret, err := auth.GenerateTotpSecret()
+	if err != nil {
+		return domain.TotpEnrollment{}, err
+	}
+	codes, err := auth.GenerateRecoveryCodes()
+	if err != nil {
+		return domain.TotpEnrollment{}, err
+	}
+
+	updated := *found
+	updated.Totp = domain.Totp{Secret: secret, RecoveryCodes: make([]string, 0, len(codes))}
+	for _, c := range codes {
+		updated.Totp.RecoveryCodes = append(updated.Totp.RecoveryCodes, auth.DigestRecoveryCode(c))
+	}
+	err = interactor.userRepository.Save(updated)
+	if err != nil {
+		return domain.TotpEnrollment{}, err
+	}
+
+	log.Info("user %s started totp enrollment", userId)
+	return domain.TotpEnrollment{
+		Secret:        secret,
+		Uri:           auth.TotpUri(interactor.totpIssuer(), userId, secret),
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// ConfirmTotp enables enrollment with first code from authenticator app
+func (interactor *DomainInteractor) ConfirmTotp(userId string, code string) error {
+	userMutex.Lock()
+	defer userMutex.Unlock()
+
+	found := interactor.userRepository.FindById(userId)
+	if found == nil || len(found.Totp.Secret) == 0 {
+		return fmt.Errorf("totp is not enrolled for user %s", userId)
+	}
+	if found.Totp.Confirmed {
+		return fmt.Errorf("totp is already enabled for user %s", userId)
+	}
+
+	step, ok := auth.VerifyTotp(found.Totp.Secret, code, time.Now(), interactor.totpSkew(), 0)
+	if !ok {
+		return errors.New("invalid totp code")
+	}
+
+	updated := *found
+	updated.Totp.Confirmed = true
+	updated.Totp.LastStep = step
+	err := interactor.userRepository.Save(updated)
+	if err != nil {
+		return err
+	}
+
+	log.Info("user %s enabled totp", userId)
+	return nil
+}
+
+// VerifySecondFactor checks TOTP code or recovery code of user.
+// failure is counted by login guard same as wrong password
+func (interactor *DomainInteractor) VerifySecondFactor(userId string, code string, clientAddress string) error {
+	err := interactor.loginGuard.Check(userId, clientAddress)
+	if err != nil {
+		return err
+	}
+
+	err = interactor.verifySecondFactor(userId, code)
+	if err != nil {
+		interactor.loginGuard.Failure(userId, clientAddress)
+		return err
+	}
+	interactor.loginGuard.Success(userId)
+	return nil
+}
+
+func (interactor *DomainInteractor) verifySecondFactor(userId string, code string) error {
+	userMutex.Lock()
+	defer userMutex.Unlock()
+
+	found := interactor.userRepository.FindById(userId)
+	if found == nil || !found.Totp.IsEnabled() {
+		return fmt.Errorf("totp is not enabled for user %s", userId)
+	}
+
+	updated := *found
+	if step, ok := auth.VerifyTotp(found.Totp.Secret, code, time.Now(), interactor.totpSkew(), found.Totp.LastStep); ok {
+		updated.Totp.LastStep = step
+		return interactor.userRepository.Save(updated)
+	}
+
+	digest := auth.DigestRecoveryCode(code)
+	for i, d := range found.Totp.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(d), []byte(digest)) != 1 {
+			continue
+		}
+
+		updated.Totp.RecoveryCodes = make([]string, 0, len(found.Totp.RecoveryCodes)-1)
+		updated.Totp.RecoveryCodes = append(updated.Totp.RecoveryCodes, found.Totp.RecoveryCodes[:i]...)
+		updated.Totp.RecoveryCodes = append(updated.Totp.RecoveryCodes, found.Totp.RecoveryCodes[i+1:]...)
+		err := interactor.userRepository.Save(updated)
+		if err != nil {
+			return err
+		}
+		log.Warn("user %s used recovery code. %d codes left", userId, len(updated.Totp.RecoveryCodes))
+		return nil
+	}
+
+	return fmt.Errorf("invalid second factor code for user %s", userId)
+}
+
+// DisableTotp removes TOTP enrollment and recovery codes of user
+func (interactor *DomainInteractor) DisableTotp(userId string) error {
+	userMutex.Lock()
+	defer userMutex.Unlock()
+
+	found := interactor.userRepository.FindById(userId)
+	if found == nil || len(found.Totp.Secret) == 0 {
+		return fmt.Errorf("totp is not enrolled for user %s", userId)
+	}
+
+	updated := *found
+	updated.Totp = domain.Totp{}
+	err := interactor.userRepository.Save(updated)
+	if err != nil {
+		return err
+	}
+
+	log.Info("user %s disabled totp", userId)
+	return nil
+}
+
+func (interactor *DomainInteractor) totpIssuer() string {
+	issuer, ok := interactor.fatimaRuntime.GetConfig().GetValue(propAuthTotpIssuer)
+	if !ok || len(issuer) == 0 {
+		return defaultAuthTotpIssuer
+	}
+	return issuer
+}
+
+func (interactor *DomainInteractor) totpSkew() int {
+	skew, err := interactor.fatimaRuntime.GetConfig().GetInt(propAuthTotpSkew)
+	if err != nil || skew < 0 {
+		return defaultAuthTotpSkew
+	}
+	return skew
+}
@@ -0,0 +1,210 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 3:35
+ */
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"sync"
+)
+
+const (
+	minUserIdLength       = 3
+	minUserPasswordLength = 8
+	maxUserPasswordLength = 72 // bcrypt limit
+)
+
+var userMutex sync.Mutex
+
+// FindAllUsers returns users without password and totp secret
+func (interactor *DomainInteractor) FindAllUsers() []domain.User {
+	list := interactor.userRepository.FindAll()
+	for i := range list {
+		list[i].Password = ""
+		list[i].Totp = domain.Totp{Confirmed: list[i].Totp.IsEnabled()}
+	}
+	return list
+}
+
+func (interactor *DomainInteractor) CreateUser(user domain.User) error {
+	userMutex.Lock()
+	defer userMutex.Unlock()
+
+	if len(user.Id) < minUserIdLength {
+		return fmt.Errorf("user id should be at least %d characters", minUserIdLength)
+	}
+	if user.Role == domain.ROLE_UNKNOWN {
+		return errors.New("unknown role")
+	}
+	if interactor.userRepository.Exists(user.Id) {
+		return fmt.Errorf("user %s already exists", user.Id)
+	}
+	hashed, err := interactor.hashPassword(user.Password)
+	if err != nil {
+		return err
+	}
+
+	user.Password = hashed
+	err = interactor.userRepository.Save(user)
+	if err != nil {
+		return err
+	}
+
+	log.Info("user %s created. role=%s", user.Id, user.Role)
+	return nil
+}
+
+// UpdateUser changes password, role and bindings of user.
+// empty password, unknown role or nil bindings keeps current value. every session of user is revoked
+func (interactor *DomainInteractor) UpdateUser(user domain.User) error {
+	userMutex.Lock()
+	defer userMutex.Unlock()
+
+	found := interactor.userRepository.FindById(user.Id)
+	if found == nil {
+		return fmt.Errorf("not found user %s", user.Id)
+	}
+
+	updated := *found
+	if user.Role != domain.ROLE_UNKNOWN {
+		updated.Role = user.Role
+	}
+	if user.Bindings != nil {
+		updated.Bindings = user.Bindings
+	}
+
+	if isAdministrator(*found) && !isAdministrator(updated) && interactor.countOperators(found.Id) == 0 {
+		return errors.New("cannot change role of last OPERATOR")
+	}
+
+	if len(user.Password) > 0 {
+		hashed, err := interactor.hashPassword(user.Password)
+		if err != nil {
+			return err
+		}
+		updated.Password = hashed
+	}
+
+	err := interactor.userRepository.Save(updated)
+	if err != nil {
+		return err
+	}
+
+	interactor.RevokeUserSessions(updated.Id)
+	log.Info("user %s updated. role=%s, bindings=%d", updated.Id, updated.Role, len(updated.Bindings))
+	return nil
+}
+
+func (interactor *DomainInteractor) DeleteUser(id string) error {
+	userMutex.Lock()
+	defer userMutex.Unlock()
+
+	found := interactor.userRepository.FindById(id)
+	if found == nil {
+		return fmt.Errorf("not found user %s", id)
+	}
+
+	if isAdministrator(*found) && interactor.countOperators(id) == 0 {
+		return errors.New("cannot delete last OPERATOR")
+	}
+
+	err := interactor.userRepository.Delete(id)
+	if err != nil {
+		return err
+	}
+
+	interactor.RevokeUserSessions(id)
+	log.Info("user %s deleted", id)
+	return nil
+}
+
+// ChangePassword changes password of user itself. current password is required.
+// every session of user including caller is revoked
+func (interactor *DomainInteractor) ChangePassword(id string, password string, newPassword string) error {
+	userMutex.Lock()
+	defer userMutex.Unlock()
+
+	found := interactor.userRepository.FindById(id)
+	if found == nil {
+		return fmt.Errorf("not found user %s", id)
+	}
+
+	if !interactor.encdec.Verify(password, found.Password) {
+		return fmt.Errorf("missmatch password for user %s", id)
+	}
+
+	hashed, err := interactor.hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	found.Password = hashed
+	err = interactor.userRepository.Save(*found)
+	if err != nil {
+		return err
+	}
+
+	interactor.RevokeUserSessions(id)
+	log.Info("user %s changed password", id)
+	return nil
+}
+
+// countOperators returns number of OPERATOR on every group except given user id
+func (interactor *DomainInteractor) countOperators(exceptId string) int {
+	count := 0
+	for _, u := range interactor.userRepository.FindAll() {
+		if u.Id != exceptId && isAdministrator(u) {
+			count++
+		}
+	}
+	return count
+}
+
+func isAdministrator(user domain.User) bool {
+	return user.Grant().PermitsAll(domain.ROLE_OPERATOR)
+}
+
+func (interactor *DomainInteractor) hashPassword(password string) (string, error) {
+	if err := validatePassword(password); err != nil {
+		return "", err
+	}
+
+	hashed := interactor.encdec.Hash(password)
+	if len(hashed) == 0 {
+		return "", errors.New("fail to hash password")
+	}
+	return hashed, nil
+}
+
+func validatePassword(password string) error {
+	if len(password) < minUserPasswordLength {
+		return fmt.Errorf("password should be at least %d characters", minUserPasswordLength)
+	}
+	if len(password) > maxUserPasswordLength {
+		return fmt.Errorf("password should be at most %d characters", maxUserPasswordLength)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 19. 오전 10:20
+ */
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fatima-go/fatima-core"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"strings"
+	"sync"
+	"time"
+)
+
+// auth.ldap.group.bindings=jupiter-ops:OPERATOR;pay-dev:MONITOR@pay;batch:OPERATOR@host1:batch
+const (
+	propAuthLdapGroupBindings   = "auth.ldap.group.bindings"
+	propAuthLdapCacheSeconds    = "auth.ldap.cache.seconds"
+	defaultAuthLdapCacheSeconds = 60
+)
+
+// newUserDirectory wraps directory of authenticator with cache. nil when no authenticator provides directory
+func newUserDirectory(fatimaRuntime fatima.FatimaRuntime, authenticator domain.Authenticate) *userDirectory {
+	provider, ok := authenticator.(interface{ Directory() domain.UserDirectory })
+	if !ok || provider.Directory() == nil {
+		return nil
+	}
+
+	d := &userDirectory{directory: provider.Directory()}
+	d.profiles = make(map[string]cachedProfile)
+	d.owners = make(map[string]cachedOwner)
+
+	seconds, err := fatimaRuntime.GetConfig().GetInt(propAuthLdapCacheSeconds)
+	if err != nil {
+		seconds = defaultAuthLdapCacheSeconds
+	}
+	d.ttl = time.Second * time.Duration(seconds)
+
+	if value, ok := fatimaRuntime.GetConfig().GetValue(propAuthLdapGroupBindings); ok {
+		d.groupBindings = parseGroupBindings(value)
+	}
+
+	log.Info("user directory enabled. cache : %d seconds, group bindings : %d", seconds, len(d.groupBindings))
+	return d
+}
+
+type groupBinding struct {
+	group   string
+	binding domain.RoleBinding
+}
+
+type cachedProfile struct {
+	profile  domain.UserProfile
+	loadedAt time.Time
+}
+
+type cachedOwner struct {
+	role      domain.Role
+	err       error
+	checkedAt time.Time
+}
+
+type userDirectory struct {
+	directory     domain.UserDirectory
+	ttl           time.Duration
+	groupBindings []groupBinding
+	mutex         sync.Mutex
+	profiles      map[string]cachedProfile
+	owners        map[string]cachedOwner
+}
+
+// parseGroupBindings parses group:ROLE@scope list separated by semicolon. scope is * when omitted
+func parseGroupBindings(value string) []groupBinding {
+	list := make([]groupBinding, 0)
+	for _, item := range strings.Split(value, ";") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+
+		entry, scope := item, domain.SCOPE_ALL
+		if at := strings.LastIndex(entry, "@"); at >= 0 {
+			entry, scope = entry[:at], entry[at+1:]
+		}
+		idx := strings.LastIndex(entry, ":")
+		if idx < 1 {
+			log.Warn("invalid group binding %s", item)
+			continue
+		}
+		group, role := entry[:idx], entry[idx+1:]
+
+		if domain.ToRole(role) == domain.ROLE_UNKNOWN || len(scope) == 0 {
+			log.Warn("invalid group binding %s", item)
+			continue
+		}
+		list = append(list, groupBinding{group: strings.TrimSpace(group), binding: domain.NewRoleBinding(scope, role)})
+	}
+	return list
+}
+
+// lookup returns profile of user from cache or directory
+func (d *userDirectory) lookup(id string) (domain.UserProfile, bool) {
+	d.mutex.Lock()
+	cached, ok := d.profiles[id]
+	d.mutex.Unlock()
+	if ok && time.Since(cached.loadedAt) < d.ttl {
+		return cached.profile, true
+	}
+
+	profile, err := d.directory.LookupUser(id)
+	if err != nil {
+		log.Debug("fail to lookup user %s : %s", id, err.Error())
+		return domain.UserProfile{}, false
+	}
+
+	d.mutex.Lock()
+	d.profiles[id] = cachedProfile{profile: profile, loadedAt: time.Now()}
+	d.mutex.Unlock()
+	return profile, true
+}
+
+// resolveBindings returns role bindings mapped from directory groups of user
+func (d *userDirectory) resolveBindings(id string) []domain.RoleBinding {
+	if len(d.groupBindings) == 0 {
+		return nil
+	}
+
+	profile, ok := d.lookup(id)
+	if !ok {
+		return nil
+	}
+
+	bindings := make([]domain.RoleBinding, 0)
+	for _, gb := range d.groupBindings {
+		for _, group := range profile.Groups {
+			if strings.EqualFold(gb.group, group) {
+				bindings = append(bindings, gb.binding)
+				break
+			}
+		}
+	}
+	return bindings
+}
+
+// validateOwner checks api key owner on directory. ErrNotMine means owner is not a directory user
+func (d *userDirectory) validateOwner(id string) (domain.Role, error) {
+	d.mutex.Lock()
+	cached, ok := d.owners[id]
+	d.mutex.Unlock()
+	if ok && time.Since(cached.checkedAt) < d.ttl {
+		return cached.role, cached.err
+	}
+
+	role, err := d.directory.ValidateUser(id)
+
+	d.mutex.Lock()
+	d.owners[id] = cachedOwner{role: role, err: err, checkedAt: time.Now()}
+	d.mutex.Unlock()
+	return role, err
+}
+
+// FindUserProfile returns directory profile of user. false when directory is not available or user is unknown
+func (interactor *DomainInteractor) FindUserProfile(id string) (domain.UserProfile, bool) {
+	if interactor.userDirectory == nil || domain.IsApiKey(id) {
+		return domain.UserProfile{}, false
+	}
+	return interactor.userDirectory.lookup(id)
+}
+
+func (interactor *DomainInteractor) ListDirectoryGroups(prefix string) ([]string, error) {
+	if interactor.userDirectory == nil {
+		return nil, errors.New("user directory is not available. set auth.ldap.helper.protocol=v2")
+	}
+	return interactor.userDirectory.directory.ListGroups(prefix)
+}
+
+// validateApiKeyOwner rejects api key whose owner is disabled or demoted in directory.
+// owner unknown to directory (e.g. local user) or unreachable directory does not reject key
+func (interactor *DomainInteractor) validateApiKeyOwner(key domain.ApiKey) error {
+	if interactor.userDirectory == nil || len(key.CreatedBy) == 0 {
+		return nil
+	}
+
+	role, err := interactor.userDirectory.validateOwner(key.CreatedBy)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotMine) {
+			return nil
+		}
+		return fmt.Errorf("owner %s of api key %s is not valid : %s", key.CreatedBy, key.Name, err.Error())
+	}
+
+	if !role.Acceptable(key.Role) {
+		return fmt.Errorf("owner %s of api key %s has no %s role", key.CreatedBy, key.Name, key.Role)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 4:30
+ */
+package service
+
+import (
+	"github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/infra"
+	"testing"
+)
+
+// testTokenService records revoked users. other token methods are not used
+type testTokenService struct {
+	domain.TokenService
+	revoked []string
+}
+
+func (s *testTokenService) RevokeUserSessions(userId string) int {
+	s.revoked = append(s.revoked, userId)
+	return 1
+}
+
+func newTestUserInteractor(t *testing.T) (*DomainInteractor, *testTokenService) {
+	encdec, err := infra.NewHashEncdec(infra.HASH_ALGORITHM_ARGON2ID)
+	if err != nil {
+		t.Fatalf("fail to create encdec : %s", err.Error())
+	}
+	tokens := &testTokenService{}
+	return &DomainInteractor{
+		userRepository: infra.NewMemoryUserRepository(),
+		tokenService:   tokens,
+		encdec:         encdec,
+	}, tokens
+}
+
+func TestCreateUser(t *testing.T) {
+	cases := []struct {
+		name     string
+		user     domain.User
+		accepted bool
+	}{
+		{"valid", domain.User{Id: "alice", Password: "alice-password", Role: domain.ROLE_MONITOR}, true},
+		{"short id", domain.User{Id: "al", Password: "alice-password", Role: domain.ROLE_MONITOR}, false},
+		{"unknown role", domain.User{Id: "alice", Password: "alice-password", Role: domain.ROLE_UNKNOWN}, false},
+		{"short password", domain.User{Id: "alice", Password: "short", Role: domain.ROLE_MONITOR}, false},
+		{"existing user", domain.User{Id: "admin", Password: "admin-password", Role: domain.ROLE_MONITOR}, false},
+	}
+
+	for _, c := range cases {
+		interactor, _ := newTestUserInteractor(t)
+		err := interactor.CreateUser(c.user)
+		if (err == nil) != c.accepted {
+			t.Fatalf("%s : accepted %t", c.name, err == nil)
+		}
+		if !c.accepted {
+			continue
+		}
+		found := interactor.userRepository.FindById(c.user.Id)
+		if found == nil || found.Password == c.user.Password || !interactor.encdec.Verify(c.user.Password, found.Password) {
+			t.Fatalf("%s : password is not hashed", c.name)
+		}
+	}
+}
+
+func TestUpdateUserLastOperator(t *testing.T) {
+	interactor, tokens := newTestUserInteractor(t)
+
+	if err := interactor.UpdateUser(domain.User{Id: "admin", Role: domain.ROLE_MONITOR}); err == nil {
+		t.Fatalf("last OPERATOR is demoted")
+	}
+	if err := interactor.DeleteUser("admin"); err == nil {
+		t.Fatalf("last OPERATOR is deleted")
+	}
+	if len(tokens.revoked) > 0 {
+		t.Fatalf("sessions are revoked on rejected change")
+	}
+
+	interactor.CreateUser(domain.User{Id: "alice", Password: "alice-password", Role: domain.ROLE_OPERATOR})
+	if err := interactor.UpdateUser(domain.User{Id: "admin", Role: domain.ROLE_MONITOR}); err != nil {
+		t.Fatalf("fail to demote OPERATOR : %s", err.Error())
+	}
+	if err := interactor.DeleteUser("alice"); err == nil {
+		t.Fatalf("last OPERATOR is deleted")
+	}
+	if err := interactor.DeleteUser("admin"); err != nil {
+		t.Fatalf("fail to delete user : %s", err.Error())
+	}
+	if len(tokens.revoked) != 2 {
+		t.Fatalf("sessions of updated and deleted user are not revoked : %v", tokens.revoked)
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	interactor, tokens := newTestUserInteractor(t)
+	interactor.CreateUser(domain.User{Id: "alice", Password: "alice-password", Role: domain.ROLE_MONITOR})
+
+	cases := []struct {
+		name        string
+		password    string
+		newPassword string
+		accepted    bool
+	}{
+		{"wrong password", "wrong-password", "alice-password-2", false},
+		{"short password", "alice-password", "short", false},
+		{"valid", "alice-password", "alice-password-2", true},
+		{"old password", "alice-password", "alice-password-3", false},
+	}
+
+	for _, c := range cases {
+		revoked := len(tokens.revoked)
+		err := interactor.ChangePassword("alice", c.password, c.newPassword)
+		if (err == nil) != c.accepted {
+			t.Fatalf("%s : accepted %t", c.name, err == nil)
+		}
+		if (len(tokens.revoked) > revoked) != c.accepted {
+			t.Fatalf("%s : sessions revoked %t", c.name, len(tokens.revoked) > revoked)
+		}
+	}
+
+	found := interactor.userRepository.FindById("alice")
+	if !interactor.encdec.Verify("alice-password-2", found.Password) {
+		t.Fatalf("password is not changed")
+	}
+}
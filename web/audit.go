@@ -0,0 +1,84 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 19. 오후 6:30
+ */
+package web
+
+import (
+	"context"
+	"github.com/fatima-go/jupiter/domain"
+	"net/http"
+)
+
+type auditContextKey struct{}
+
+// AuditRecord collects who, target and result while request is handled.
+// user is filled by WithSession, status and message by response functions
+type AuditRecord struct {
+	UserId  string
+	Role    string
+	Target  string
+	Message string
+	Status  int
+}
+
+// WithAudit returns request carrying empty audit record
+func WithAudit(req *http.Request) (*http.Request, *AuditRecord) {
+	record := &AuditRecord{}
+	return req.WithContext(context.WithValue(req.Context(), auditContextKey{}, record)), record
+}
+
+func getAuditRecord(req *http.Request) *AuditRecord {
+	record, _ := req.Context().Value(auditContextKey{}).(*AuditRecord)
+	return record
+}
+
+// SetAuditUser sets user of request which is not authorized by token (e.g. login)
+func SetAuditUser(req *http.Request, userId string, role domain.Role) {
+	if record := getAuditRecord(req); record != nil {
+		record.UserId = userId
+		if role != domain.ROLE_UNKNOWN {
+			record.Role = role.String()
+		}
+	}
+}
+
+// SetAuditTarget sets target of action. e.g) user id, group/package, endpoint
+func SetAuditTarget(req *http.Request, target string) {
+	if record := getAuditRecord(req); record != nil {
+		record.Target = target
+	}
+}
+
+func SetAuditMessage(req *http.Request, message string) {
+	if record := getAuditRecord(req); record != nil {
+		record.Message = message
+	}
+}
+
+func recordAuditStatus(req *http.Request, httpStatusCode int, message string) {
+	if record := getAuditRecord(req); record != nil {
+		record.Status = httpStatusCode
+		if len(record.Message) == 0 {
+			record.Message = message
+		}
+	}
+}
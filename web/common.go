@@ -24,6 +24,7 @@
 package web
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/fatima-go/fatima-log"
@@ -44,6 +45,8 @@ const (
 	HeaderFatimaTimezone            = "Fatima-Timezone"
 	HeaderFatimaResTime             = "Fatima-Response-Time"
 	HeaderFatimaTokenRole           = "Fatima-Token-Role"
+	HeaderFatimaJunoKey             = "Fatima-Juno-Key"
+	HeaderFatimaApiKey              = "Fatima-Api-Key"
 
 	HeaderValueUserAgent   = "fatima-application-jupiter"
 	HeaderValueCharset     = "UTF-8"
@@ -64,6 +67,27 @@ func GetFatimaAuthToken(req *http.Request) string {
 	return ""
 }
 
+// HasClientCertificate returns true when request came with client certificate verified by web server TLS
+func HasClientCertificate(req *http.Request) bool {
+	return req.TLS != nil && len(req.TLS.VerifiedChains) > 0 && len(req.TLS.VerifiedChains[0]) > 0
+}
+
+type sessionContextKey struct{}
+
+// WithSession returns request carrying session of validated token
+func WithSession(req *http.Request, session domain.Session) *http.Request {
+	SetAuditUser(req, session.UserId, session.Role)
+	return req.WithContext(context.WithValue(req.Context(), sessionContextKey{}, session))
+}
+
+// GetSession returns session stored by WithSession. empty session is returned when request is not authorized
+func GetSession(req *http.Request) domain.Session {
+	if session, ok := req.Context().Value(sessionContextKey{}).(domain.Session); ok {
+		return session
+	}
+	return domain.Session{}
+}
+
 func writeResponseHeader(res http.ResponseWriter, req *http.Request, httpStatusCode int) {
 	res.Header().Set(HeaderAccessControlAllowOrigin, "*")
 	res.Header().Set(HeaderContentType, HeaderValueContentType)
@@ -75,6 +99,7 @@ func writeResponseHeader(res http.ResponseWriter, req *http.Request, httpStatusC
 			res.Header().Set(HeaderFatimaResTime, time.Now().In(loc).Format(TIME_YYYYMMDDHHMMSS))
 		}
 	}
+	recordAuditStatus(req, httpStatusCode, "")
 	res.WriteHeader(httpStatusCode)
 }
 
@@ -97,6 +122,7 @@ func ResponseSuccess(res http.ResponseWriter, req *http.Request, message string)
 }
 
 func ResponseError(res http.ResponseWriter, req *http.Request, httpStatusCode int, message string) {
+	recordAuditStatus(req, httpStatusCode, message)
 	writeResponseHeader(res, req, httpStatusCode)
 
 	if len(message) > 0 {
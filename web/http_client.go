@@ -120,6 +120,7 @@ func (hc HttpClient) post(netClient *http.Client, url string, body []byte) ([]by
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, errors.New(resp.Status)
@@ -170,6 +171,7 @@ func (hc HttpClient) PostWithBuffer(url string, body *bytes.Buffer) ([]byte, err
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, errors.New(resp.Status)
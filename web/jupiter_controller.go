@@ -24,21 +24,60 @@
 package web
 
 import (
+	"crypto/x509"
 	"github.com/fatima-go/jupiter/domain"
 	"mime/multipart"
 	"time"
 )
 
 type JupiterServiceController interface {
-	GenerateInstantToken(role domain.Role) string
-	GenerateToken(role domain.Role) string
-	ValidateUser(user domain.User) (domain.Role, error)
-	ValidateToken(token string, role domain.Role) error
+	GenerateInstantToken(owner domain.Session) string
+	GenerateToken(owner domain.Session) string
+	GenerateRefreshToken(owner domain.Session) string
+	GeneratePendingToken(owner domain.Session) string
+	RedeemPendingToken(token string) (domain.Session, error)
+	RefreshToken(refreshToken string) (string, string, error)
+	ValidateUser(user domain.User, clientAddress string) (domain.Session, error)
+	FindAllLockouts() []domain.Lockout
+	Unlock(kind string, key string) error
+	ValidateToken(token string, role domain.Role) (domain.Session, error)
+	Logout(token string)
+	FindAllSessions() []domain.Session
+	RevokeSession(id string) error
+	RevokeUserSessions(userId string) int
 	GetJunoEndpoint(point domain.PackagePoint, remoteAddr string) *domain.JunoPackage
 	RegistJunoPackage(juno domain.JunoRegistration)
 	UnregistJunoPackage(endpoint string)
 	RemoveJunoPackage(endpoint string)
-	GetPackageSummary(group string, location *time.Location) map[string]domain.PackageSummary
-	GetEndpointList(groupName string, point domain.PackagePoint, address string) []string
-	DeployPackage(mr *multipart.Reader, clientAddress string, token string) (string, error)
+	HeartbeatJunoPackage(endpoint string, leaseSeconds int) (int, error)
+	ValidateJunoLease(leaseSeconds int) error
+	FindJunoHealthHistory(caller domain.Session, endpoint string) ([]domain.HealthResult, bool)
+	AuthenticateJuno(host string, key string) (string, error)
+	AuthenticateJunoEndpoint(endpoint string, key string) error
+	PermitsJunoEndpoint(caller domain.Session, endpoint string, role domain.Role) bool
+	RotateJunoSecret() (string, error)
+	EnrollJunoHost(host string) (string, error)
+	RevokeJunoHost(host string) error
+	GetPackageSummary(caller domain.Session, group string, selector domain.LabelSelector, location *time.Location) map[string]domain.PackageSummary
+	GetEndpointList(caller domain.Session, groupName string, selector domain.LabelSelector, point domain.PackagePoint, address string) []string
+	DeployPackage(caller domain.Session, mr *multipart.Reader, clientAddress string, token string) (string, error)
+	FindAllUsers() []domain.User
+	CreateUser(user domain.User) error
+	UpdateUser(user domain.User) error
+	DeleteUser(id string) error
+	ChangePassword(id string, password string, newPassword string) error
+	RequiresSecondFactor(userId string) bool
+	EnrollTotp(userId string) (domain.TotpEnrollment, error)
+	ConfirmTotp(userId string, code string) error
+	VerifySecondFactor(userId string, code string, clientAddress string) error
+	DisableTotp(userId string) error
+	CreateApiKey(caller domain.Session, name string, role domain.Role, scope string, expireAt time.Time) (string, error)
+	FindAllApiKeys() []domain.ApiKey
+	RevokeApiKey(name string) error
+	ValidateApiKey(key string, role domain.Role, clientAddress string) (domain.Session, error)
+	ValidateClientCertificate(cert *x509.Certificate, role domain.Role, clientAddress string) (domain.Session, error)
+	FindUserProfile(id string) (domain.UserProfile, bool)
+	ListDirectoryGroups(prefix string) ([]string, error)
+	RecordAudit(event domain.AuditEvent)
+	FindAuditEvents(filter domain.AuditFilter) ([]domain.AuditEvent, error)
 }
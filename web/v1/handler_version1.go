@@ -40,6 +40,33 @@ type JupiterResponse struct {
 	System domain.SystemMessage `json:"system"`
 }
 
+// auditedActions are recorded on audit trail. deploy.insert is recorded by deploy service itself
+var auditedActions = map[string]bool{
+	"auth.login":     true,
+	"auth.verify":    true,
+	"auth.logout":    true,
+	"auth.passwd":    true,
+	"auth.enroll":    true,
+	"auth.confirm":   true,
+	"auth.disable":   true,
+	"juno.regist":    true,
+	"juno.unregist":  true,
+	"juno.remove":    true,
+	"juno.secret":    true,
+	"juno.enroll":    true,
+	"juno.revoke":    true,
+	"proc.regist":    true,
+	"proc.unregist":  true,
+	"user.create":    true,
+	"user.update":    true,
+	"user.delete":    true,
+	"user.unlock":    true,
+	"user.totpreset": true,
+	"session.revoke": true,
+	"apikey.create":  true,
+	"apikey.revoke":  true,
+}
+
 type HandlerFunc func(web.JupiterServiceController, http.ResponseWriter, *http.Request)
 
 func NewWebService(domainInteractor web.JupiterServiceController) web.WebServiceHandler {
@@ -56,11 +83,39 @@ func (version1 *Version1Handler) GetVersion() string {
 	return "v1"
 }
 
-func (version1 *Version1Handler) HandleLogin(res http.ResponseWriter, req *http.Request) {
-	authorize(version1.controller, res, req)
+func (version1 *Version1Handler) HandleAuth(method string, res http.ResponseWriter, req *http.Request) {
+	req, done := version1.beginAudit("auth", method, req)
+	defer done()
+
+	switch method {
+	case "login":
+		authorize(version1.controller, res, req)
+	case "passwd":
+		changePassword(version1.controller, res, req)
+	case "logout":
+		version1.secureHandle(domain.ROLE_MONITOR, res, req, logout)
+	case "whoami":
+		version1.secureHandle(domain.ROLE_MONITOR, res, req, whoami)
+	case "verify":
+		verifySecondFactor(version1.controller, res, req)
+	case "enroll":
+		version1.secureHandle(domain.ROLE_MONITOR, res, req, enrollTotp)
+	case "confirm":
+		version1.secureHandle(domain.ROLE_MONITOR, res, req, confirmTotp)
+	case "disable":
+		version1.secureHandle(domain.ROLE_MONITOR, res, req, disableTotp)
+	default:
+		web.ResponseError(res, req, http.StatusNotFound, "")
+		return
+	}
 }
 
 func (version1 *Version1Handler) HandleToken(res http.ResponseWriter, req *http.Request) {
+	refreshToken, err := parsingRequest(req, "refresh_token")
+	if err == nil && len(refreshToken) > 0 {
+		refreshAccessToken(version1.controller, res, req, refreshToken)
+		return
+	}
 	version1.secureHandle(domain.ROLE_MONITOR, res, req, token)
 }
 
@@ -69,6 +124,9 @@ func (version1 *Version1Handler) HandlePack(res http.ResponseWriter, req *http.R
 }
 
 func (version1 *Version1Handler) HandleJuno(method string, res http.ResponseWriter, req *http.Request) {
+	req, done := version1.beginAudit("juno", method, req)
+	defer done()
+
 	switch method {
 	case "retrieve":
 		version1.secureHandle(domain.ROLE_MONITOR, res, req, retrieveJuno)
@@ -78,6 +136,16 @@ func (version1 *Version1Handler) HandleJuno(method string, res http.ResponseWrit
 		unregistJuno(version1.controller, res, req)
 	case "remove":
 		removeJuno(version1.controller, res, req)
+	case "heartbeat":
+		heartbeatJuno(version1.controller, res, req)
+	case "history":
+		version1.secureHandle(domain.ROLE_MONITOR, res, req, junoHealthHistory)
+	case "secret":
+		version1.secureGlobalHandle(domain.ROLE_OPERATOR, res, req, rotateJunoSecret)
+	case "enroll":
+		version1.secureGlobalHandle(domain.ROLE_OPERATOR, res, req, enrollJunoHost)
+	case "revoke":
+		version1.secureGlobalHandle(domain.ROLE_OPERATOR, res, req, revokeJunoHost)
 	default:
 		web.ResponseError(res, req, http.StatusNotFound, "")
 		return
@@ -85,6 +153,9 @@ func (version1 *Version1Handler) HandleJuno(method string, res http.ResponseWrit
 }
 
 func (version1 *Version1Handler) HandleProc(method string, res http.ResponseWriter, req *http.Request) {
+	req, done := version1.beginAudit("proc", method, req)
+	defer done()
+
 	switch method {
 	case "regist":
 		version1.secureHandle(domain.ROLE_OPERATOR, res, req, registProc)
@@ -106,22 +177,158 @@ func (version1 *Version1Handler) HandleDeploy(method string, res http.ResponseWr
 	}
 }
 
+func (version1 *Version1Handler) HandleUser(method string, res http.ResponseWriter, req *http.Request) {
+	req, done := version1.beginAudit("user", method, req)
+	defer done()
+
+	switch method {
+	case "list":
+		version1.secureGlobalHandle(domain.ROLE_OPERATOR, res, req, listUser)
+	case "create":
+		version1.secureGlobalHandle(domain.ROLE_OPERATOR, res, req, createUser)
+	case "update":
+		version1.secureGlobalHandle(domain.ROLE_OPERATOR, res, req, updateUser)
+	case "delete":
+		version1.secureGlobalHandle(domain.ROLE_OPERATOR, res, req, deleteUser)
+	case "lockout":
+		version1.secureGlobalHandle(domain.ROLE_OPERATOR, res, req, listLockout)
+	case "unlock":
+		version1.secureGlobalHandle(domain.ROLE_OPERATOR, res, req, unlockUser)
+	case "groups":
+		version1.secureGlobalHandle(domain.ROLE_OPERATOR, res, req, listDirectoryGroups)
+	case "totpreset":
+		version1.secureGlobalHandle(domain.ROLE_OPERATOR, res, req, resetTotp)
+	default:
+		web.ResponseError(res, req, http.StatusNotFound, "")
+		return
+	}
+}
+
+func (version1 *Version1Handler) HandleSession(method string, res http.ResponseWriter, req *http.Request) {
+	req, done := version1.beginAudit("session", method, req)
+	defer done()
+
+	switch method {
+	case "list":
+		version1.secureGlobalHandle(domain.ROLE_OPERATOR, res, req, listSession)
+	case "revoke":
+		version1.secureGlobalHandle(domain.ROLE_OPERATOR, res, req, revokeSession)
+	default:
+		web.ResponseError(res, req, http.StatusNotFound, "")
+		return
+	}
+}
+
+func (version1 *Version1Handler) HandleApiKey(method string, res http.ResponseWriter, req *http.Request) {
+	req, done := version1.beginAudit("apikey", method, req)
+	defer done()
+
+	switch method {
+	case "list":
+		version1.secureGlobalHandle(domain.ROLE_OPERATOR, res, req, listApiKey)
+	case "create":
+		version1.secureGlobalHandle(domain.ROLE_OPERATOR, res, req, createApiKey)
+	case "revoke":
+		version1.secureGlobalHandle(domain.ROLE_OPERATOR, res, req, revokeApiKey)
+	default:
+		web.ResponseError(res, req, http.StatusNotFound, "")
+		return
+	}
+}
+
+func (version1 *Version1Handler) HandleAudit(method string, res http.ResponseWriter, req *http.Request) {
+	switch method {
+	case "list":
+		version1.secureGlobalHandle(domain.ROLE_OPERATOR, res, req, listAudit)
+	default:
+		web.ResponseError(res, req, http.StatusNotFound, "")
+		return
+	}
+}
+
+// beginAudit returns request carrying audit record when action is audited.
+// returned function records result of the action and should be deferred
+func (version1 *Version1Handler) beginAudit(category string, method string, req *http.Request) (*http.Request, func()) {
+	action := category + "." + method
+	if !auditedActions[action] {
+		return req, func() {}
+	}
+
+	req, record := web.WithAudit(req)
+	return req, func() {
+		event := domain.AuditEvent{
+			UserId:        record.UserId,
+			Role:          record.Role,
+			ClientAddress: req.RemoteAddr,
+			Action:        action,
+			Target:        record.Target,
+			Result:        domain.AUDIT_RESULT_SUCCESS,
+			Message:       record.Message,
+		}
+		if record.Status >= http.StatusBadRequest {
+			event.Result = domain.AUDIT_RESULT_FAIL
+		}
+		version1.controller.RecordAudit(event)
+	}
+}
+
+// secureHandle validates token on Fatima-Auth-Token or api key on Fatima-Api-Key.
+// api key is also accepted on Fatima-Auth-Token. verified client certificate is used when request has neither
 func (version1 *Version1Handler) secureHandle(userRole domain.Role, res http.ResponseWriter, req *http.Request, businessHandler HandlerFunc) {
 	token := req.Header.Get(HEADER_FATIMA_AUTH_TOKEN)
+	if key := req.Header.Get(web.HeaderFatimaApiKey); len(key) > 0 {
+		token = key
+	}
+	if len(token) < 1 && web.HasClientCertificate(req) {
+		session, err := version1.controller.ValidateClientCertificate(req.TLS.VerifiedChains[0][0], userRole, req.RemoteAddr)
+		if err != nil {
+			log.Warn("authorization fail :: %s :: %s", err.Error(), req.RemoteAddr)
+			web.ResponseError(res, req, http.StatusUnauthorized, "invalid access")
+			return
+		}
+		businessHandler(version1.controller, res, web.WithSession(req, session))
+		return
+	}
+
 	if len(token) < 1 {
 		log.Warn("Unauthorized : not found fatima token")
 		web.ResponseError(res, req, http.StatusUnauthorized, "invalid access")
 		return
 	}
 
-	err := version1.controller.ValidateToken(token, userRole)
+	if domain.IsApiKey(token) {
+		session, err := version1.controller.ValidateApiKey(token, userRole, req.RemoteAddr)
+		if err != nil {
+			log.Warn("authorization fail :: %s :: %s", err.Error(), req.RemoteAddr)
+			web.ResponseError(res, req, http.StatusUnauthorized, "invalid access")
+			return
+		}
+		businessHandler(version1.controller, res, web.WithSession(req, session))
+		return
+	}
+
+	session, err := version1.controller.ValidateToken(token, userRole)
 	if err != nil {
 		log.Warn("authorization fail :: %s :: %s", err.Error(), token)
 		web.ResponseError(res, req, http.StatusUnauthorized, "invalid access")
 		return
 	}
 
-	businessHandler(version1.controller, res, req)
+	businessHandler(version1.controller, res, web.WithSession(req, session))
+}
+
+// secureGlobalHandle is secureHandle for operation which is not bound to group (e.g. user management).
+// user with role bindings needs binding on * scope
+func (version1 *Version1Handler) secureGlobalHandle(userRole domain.Role, res http.ResponseWriter, req *http.Request, businessHandler HandlerFunc) {
+	version1.secureHandle(userRole, res, req, func(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+		session := web.GetSession(req)
+		if !session.Grant().PermitsAll(userRole) {
+			log.Warn("authorization fail :: user %s has no %s role on every group", session.UserId, userRole)
+			web.ResponseError(res, req, http.StatusForbidden, "invalid access")
+			return
+		}
+		businessHandler(controller, res, req)
+	})
 }
 
 func parsingRequest(req *http.Request, name string) (string, error) {
@@ -129,22 +336,33 @@ func parsingRequest(req *http.Request, name string) (string, error) {
 		return "", nil
 	}
 
-	b, err := ioutil.ReadAll(req.Body)
+	params, err := parsingRequestParams(req)
 	if err != nil {
 		return "", err
 	}
 
+	return params[name], nil
+}
+
+// parsingRequestParams reads json object of string values from body. empty body gives empty map
+func parsingRequestParams(req *http.Request) (map[string]string, error) {
+	params := make(map[string]string)
+
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return params, err
+	}
+
 	if len(b) == 0 {
-		return "", nil
+		return params, nil
 	}
 
-	params := make(map[string]string)
 	err = json.Unmarshal(b, &params)
 	if err != nil {
-		return "", err
+		return params, err
 	}
 
-	return params[name], nil
+	return params, nil
 }
 
 func sendSuccessResponse(res http.ResponseWriter, req *http.Request) {
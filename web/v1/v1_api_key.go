@@ -0,0 +1,137 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 9:40
+ */
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/web"
+	"io"
+	"net/http"
+	"time"
+)
+
+type ApiKeyParam struct {
+	Name       string `json:"name"`
+	Role       string `json:"role"`
+	Scope      string `json:"scope,omitempty"`
+	ExpireDays int    `json:"expire_days,omitempty"`
+}
+
+type ApiKeyView struct {
+	Name            string `json:"name"`
+	Role            string `json:"role"`
+	Scope           string `json:"scope,omitempty"`
+	CreatedBy       string `json:"created_by,omitempty"`
+	CreatedAt       string `json:"created_at"`
+	ExpireAt        string `json:"expire_at,omitempty"`
+	LastUsedAt      string `json:"last_used_at,omitempty"`
+	LastUsedAddress string `json:"last_used_address,omitempty"`
+}
+
+func newApiKeyView(key domain.ApiKey, location *time.Location) ApiKeyView {
+	view := ApiKeyView{}
+	view.Name = key.Name
+	view.Role = key.Role.String()
+	view.Scope = key.Scope
+	view.CreatedBy = key.CreatedBy
+	view.CreatedAt = key.CreatedAt.In(location).Format(domain.TIME_YYYYMMDDHHMMSS)
+	if !key.ExpireAt.IsZero() {
+		view.ExpireAt = key.ExpireAt.In(location).Format(domain.TIME_YYYYMMDDHHMMSS)
+	}
+	if !key.LastUsedAt.IsZero() {
+		view.LastUsedAt = key.LastUsedAt.In(location).Format(domain.TIME_YYYYMMDDHHMMSS)
+	}
+	view.LastUsedAddress = key.LastUsedAddress
+	return view
+}
+
+func listApiKey(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	location := web.GetFatimaClientTimezone(req)
+	list := make([]ApiKeyView, 0)
+	for _, k := range controller.FindAllApiKeys() {
+		list = append(list, newApiKeyView(k, location))
+	}
+
+	b, err := json.Marshal(map[string][]ApiKeyView{"api_keys": list})
+	if err != nil {
+		log.Warn("fail to build json response : %s", err.Error())
+		web.ResponseError(res, req, http.StatusInternalServerError, err.Error())
+		return
+	}
+	web.ResponseSuccess(res, req, string(b))
+}
+
+// createApiKey returns new key. key is not retrievable afterwards
+func createApiKey(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	var param ApiKeyParam
+
+	b, _ := io.ReadAll(req.Body)
+	if err := json.Unmarshal(b, &param); err != nil {
+		log.Warn("invalid request data : %s", err.Error())
+		web.ResponseError(res, req, http.StatusBadRequest, fmt.Sprintf("fail to parse data : %s", err))
+		return
+	}
+
+	var expireAt time.Time
+	if param.ExpireDays > 0 {
+		expireAt = time.Now().AddDate(0, 0, param.ExpireDays)
+	}
+
+	web.SetAuditTarget(req, param.Name)
+	key, err := controller.CreateApiKey(web.GetSession(req), param.Name, domain.ToRole(param.Role), param.Scope, expireAt)
+	if err != nil {
+		log.Warn("fail to create api key %s : %s", param.Name, err.Error())
+		web.ResponseError(res, req, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	b, err = json.Marshal(map[string]string{"name": param.Name, "key": key})
+	if err != nil {
+		log.Warn("fail to build json response : %s", err.Error())
+		web.ResponseError(res, req, http.StatusInternalServerError, err.Error())
+		return
+	}
+	web.ResponseSuccess(res, req, string(b))
+}
+
+func revokeApiKey(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	name, err := parsingRequest(req, "name")
+	if err != nil || len(name) == 0 {
+		log.Warn("invalid request data : %s", err)
+		web.ResponseError(res, req, http.StatusBadRequest, "invalid name")
+		return
+	}
+
+	web.SetAuditTarget(req, name)
+	err = controller.RevokeApiKey(name)
+	if err != nil {
+		web.ResponseError(res, req, http.StatusNotFound, err.Error())
+		return
+	}
+
+	sendSuccessResponse(res, req)
+}
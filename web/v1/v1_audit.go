@@ -0,0 +1,127 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 19. 오후 6:50
+ */
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/web"
+	"io"
+	"net/http"
+	"time"
+)
+
+// AuditParam is filter of audit list. from and to are TIME_YYYYMMDDHHMMSS on client timezone
+type AuditParam struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	UserId string `json:"user_id"`
+	Action string `json:"action"`
+	Limit  int    `json:"limit"`
+}
+
+func (param AuditParam) ToAuditFilter(location *time.Location) (domain.AuditFilter, error) {
+	filter := domain.AuditFilter{UserId: param.UserId, Action: param.Action, Limit: param.Limit}
+
+	var err error
+	if len(param.From) > 0 {
+		filter.From, err = time.ParseInLocation(domain.TIME_YYYYMMDDHHMMSS, param.From, location)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from : %s", param.From)
+		}
+	}
+	if len(param.To) > 0 {
+		filter.To, err = time.ParseInLocation(domain.TIME_YYYYMMDDHHMMSS, param.To, location)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to : %s", param.To)
+		}
+	}
+	return filter, nil
+}
+
+type AuditView struct {
+	Time          string `json:"time"`
+	UserId        string `json:"user_id"`
+	Role          string `json:"role"`
+	ClientAddress string `json:"client_address"`
+	Action        string `json:"action"`
+	Target        string `json:"target"`
+	Result        string `json:"result"`
+	Message       string `json:"message,omitempty"`
+}
+
+func newAuditView(event domain.AuditEvent, location *time.Location) AuditView {
+	view := AuditView{}
+	view.Time = event.Time.In(location).Format(domain.TIME_YYYYMMDDHHMMSS)
+	view.UserId = event.UserId
+	view.Role = event.Role
+	view.ClientAddress = event.ClientAddress
+	view.Action = event.Action
+	view.Target = event.Target
+	view.Result = event.Result
+	view.Message = event.Message
+	return view
+}
+
+// listAudit shows audit events newest first
+func listAudit(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	var param AuditParam
+
+	b, _ := io.ReadAll(req.Body)
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &param); err != nil {
+			log.Warn("invalid request data : %s", err.Error())
+			web.ResponseError(res, req, http.StatusBadRequest, fmt.Sprintf("fail to parse data : %s", err))
+			return
+		}
+	}
+
+	location := web.GetFatimaClientTimezone(req)
+	filter, err := param.ToAuditFilter(location)
+	if err != nil {
+		web.ResponseError(res, req, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	events, err := controller.FindAuditEvents(filter)
+	if err != nil {
+		log.Warn("fail to find audit events : %s", err.Error())
+		web.ResponseError(res, req, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	list := make([]AuditView, 0, len(events))
+	for _, e := range events {
+		list = append(list, newAuditView(e, location))
+	}
+
+	b, err = json.Marshal(map[string][]AuditView{"events": list})
+	if err != nil {
+		log.Warn("fail to build json response : %s", err.Error())
+		web.ResponseError(res, req, http.StatusInternalServerError, err.Error())
+		return
+	}
+	web.ResponseSuccess(res, req, string(b))
+}
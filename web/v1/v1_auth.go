@@ -32,36 +32,115 @@ import (
 	"github.com/fatima-go/jupiter/domain"
 	"github.com/fatima-go/jupiter/web"
 	"io"
+	"math"
 	"net/http"
+	"strconv"
 )
 
+// LoginParam is login request. Otp is TOTP or recovery code of user with second factor
+type LoginParam struct {
+	Id       string `json:"id"`
+	Password string `json:"passwd"`
+	Otp      string `json:"otp,omitempty"`
+}
+
+// authorize issues token after password and second factor.
+// user with second factor and no otp in request gets pending token for /auth/verify/v1
 func authorize(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
-	user, err := parsingUser(req)
+	param, err := parsingLoginParam(req)
 	if err != nil {
 		log.Warn("validation fail : %s", err.Error())
 		web.ResponseError(res, req, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	var role domain.Role
-	role, err = controller.ValidateUser(*user)
+	user := domain.User{Id: param.Id, Password: param.Password}
+	web.SetAuditUser(req, user.Id, domain.ROLE_UNKNOWN)
+	var owner domain.Session
+	owner, err = controller.ValidateUser(user, req.RemoteAddr)
 	if err != nil {
 		log.Warn("unauthorized : %s, %s", user.Id, err.Error())
+		web.SetAuditMessage(req, err.Error())
+		responseAuthorizationFail(res, req, err)
+		return
+	}
+	web.SetAuditUser(req, owner.UserId, owner.Role)
+
+	owner.ClientAddress = req.RemoteAddr
+	owner.UserAgent = req.UserAgent()
+
+	if controller.RequiresSecondFactor(user.Id) {
+		if len(param.Otp) == 0 {
+			web.SetAuditMessage(req, "waiting second factor")
+			responsePendingToken(controller, res, req, owner)
+			return
+		}
+		if err = controller.VerifySecondFactor(user.Id, param.Otp, req.RemoteAddr); err != nil {
+			log.Warn("unauthorized : %s, %s", user.Id, err.Error())
+			web.SetAuditMessage(req, err.Error())
+			responseAuthorizationFail(res, req, err)
+			return
+		}
+	}
+
+	responseToken(controller, res, req, owner)
+}
+
+// verifySecondFactor redeems pending token on Fatima-Auth-Token with {"otp": "..."} and issues token
+func verifySecondFactor(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	owner, err := controller.RedeemPendingToken(web.GetFatimaAuthToken(req))
+	if err != nil {
+		log.Warn("unauthorized : %s", err.Error())
 		web.ResponseError(res, req, http.StatusUnauthorized, "user authorization fail")
 		return
 	}
+	web.SetAuditUser(req, owner.UserId, owner.Role)
+
+	otp, err := parsingRequest(req, "otp")
+	if err != nil || len(otp) == 0 {
+		web.ResponseError(res, req, http.StatusBadRequest, "otp is required")
+		return
+	}
+
+	if err = controller.VerifySecondFactor(owner.UserId, otp, req.RemoteAddr); err != nil {
+		log.Warn("unauthorized : %s, %s", owner.UserId, err.Error())
+		web.SetAuditMessage(req, err.Error())
+		responseAuthorizationFail(res, req, err)
+		return
+	}
+
+	owner.ClientAddress = req.RemoteAddr
+	owner.UserAgent = req.UserAgent()
+	responseToken(controller, res, req, owner)
+}
 
+func responseToken(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request, owner domain.Session) {
+	vars := make(map[string]string)
 	var userToken string
 	if isFatimaClientCli(req) {
-		userToken = controller.GenerateInstantToken(role)
+		userToken = controller.GenerateInstantToken(owner)
 	} else {
-		userToken = controller.GenerateToken(role)
+		userToken = controller.GenerateToken(owner)
+		if refreshToken := controller.GenerateRefreshToken(owner); len(refreshToken) > 0 {
+			vars["refresh_token"] = refreshToken
+		}
 	}
-	log.Debug("user[%s] token generated : %s", user.Id, userToken)
+	log.Debug("user[%s] token generated : %s", owner.UserId, userToken)
 
-	vars := make(map[string]string)
 	vars["token"] = userToken
-	b, err := json.Marshal(vars)
+	sendJsonResponse(res, req, vars)
+}
+
+func responsePendingToken(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request, owner domain.Session) {
+	vars := make(map[string]string)
+	vars["pending_token"] = controller.GeneratePendingToken(owner)
+	vars["second_factor"] = "totp"
+	log.Info("user[%s] passed password. waiting second factor", owner.UserId)
+	sendJsonResponse(res, req, vars)
+}
+
+func sendJsonResponse(res http.ResponseWriter, req *http.Request, v interface{}) {
+	b, err := json.Marshal(v)
 	if err != nil {
 		log.Warn("fail to build json response : %s", err.Error())
 		web.ResponseError(res, req, http.StatusInternalServerError, "system error")
@@ -71,20 +150,119 @@ func authorize(controller web.JupiterServiceController, res http.ResponseWriter,
 	web.ResponseSuccess(res, req, string(b))
 }
 
-func parsingUser(req *http.Request) (*domain.User, error) {
-	var user domain.User
+// WhoamiResponse is caller of request with directory profile when available
+type WhoamiResponse struct {
+	UserId      string          `json:"user_id"`
+	Role        string          `json:"role"`
+	Kind        string          `json:"kind,omitempty"`
+	Bindings    *[]BindingParam `json:"bindings,omitempty"`
+	DisplayName string          `json:"display_name,omitempty"`
+	Email       string          `json:"email,omitempty"`
+	Groups      []string        `json:"groups,omitempty"`
+}
+
+func whoami(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	session := web.GetSession(req)
+	param := newUserParam(domain.User{Id: session.UserId, Role: session.Role, Bindings: session.Bindings})
+
+	who := WhoamiResponse{UserId: session.UserId, Role: param.Role, Kind: session.Kind, Bindings: param.Bindings}
+	if profile, ok := controller.FindUserProfile(session.UserId); ok {
+		who.DisplayName = profile.DisplayName
+		who.Email = profile.Email
+		who.Groups = profile.Groups
+	}
+
+	b, err := json.Marshal(who)
+	if err != nil {
+		log.Warn("fail to build json response : %s", err.Error())
+		web.ResponseError(res, req, http.StatusInternalServerError, err.Error())
+		return
+	}
+	web.ResponseSuccess(res, req, string(b))
+}
+
+func parsingLoginParam(req *http.Request) (*LoginParam, error) {
+	var param LoginParam
 
 	b, _ := io.ReadAll(req.Body)
-	if err := json.Unmarshal(b, &user); err != nil {
+	if err := json.Unmarshal(b, &param); err != nil {
 		return nil, fmt.Errorf("fail to parse data : %s", err)
 	}
 
-	if len(user.Id) < 3 || len(user.Password) < 3 {
+	if len(param.Id) < 3 || len(param.Password) < 3 {
 		return nil, errors.New("unknown id or invalid password")
 	}
 
-	user.Password = crypt.ResolveSecret(user.Password)
-	return &user, nil
+	param.Password = crypt.ResolveSecret(param.Password)
+	return &param, nil
+}
+
+// logout revokes token of request. refresh_token in body is also revoked
+func logout(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	controller.Logout(web.GetFatimaAuthToken(req))
+	refreshToken, err := parsingRequest(req, "refresh_token")
+	if err == nil && len(refreshToken) > 0 {
+		controller.Logout(refreshToken)
+	}
+	sendSuccessResponse(res, req)
+}
+
+type PasswordParam struct {
+	Id          string `json:"id"`
+	Password    string `json:"passwd"`
+	NewPassword string `json:"new_passwd"`
+	Otp         string `json:"otp,omitempty"`
+}
+
+// changePassword lets user change own password. current password is required instead of token
+func changePassword(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	var param PasswordParam
+
+	b, _ := io.ReadAll(req.Body)
+	if err := json.Unmarshal(b, &param); err != nil {
+		log.Warn("invalid request data : %s", err.Error())
+		web.ResponseError(res, req, http.StatusBadRequest, fmt.Sprintf("fail to parse data : %s", err))
+		return
+	}
+
+	param.Password = crypt.ResolveSecret(param.Password)
+	param.NewPassword = crypt.ResolveSecret(param.NewPassword)
+	web.SetAuditUser(req, param.Id, domain.ROLE_UNKNOWN)
+	if _, err := controller.ValidateUser(domain.User{Id: param.Id, Password: param.Password}, req.RemoteAddr); err != nil {
+		log.Warn("unauthorized : %s, %s", param.Id, err.Error())
+		web.SetAuditMessage(req, err.Error())
+		responseAuthorizationFail(res, req, err)
+		return
+	}
+
+	if controller.RequiresSecondFactor(param.Id) {
+		if err := controller.VerifySecondFactor(param.Id, param.Otp, req.RemoteAddr); err != nil {
+			log.Warn("unauthorized : %s, %s", param.Id, err.Error())
+			web.SetAuditMessage(req, err.Error())
+			responseAuthorizationFail(res, req, err)
+			return
+		}
+	}
+
+	err := controller.ChangePassword(param.Id, param.Password, param.NewPassword)
+	if err != nil {
+		log.Warn("fail to change password of %s : %s", param.Id, err.Error())
+		web.ResponseError(res, req, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sendSuccessResponse(res, req)
+}
+
+// responseAuthorizationFail responses 429 with Retry-After for locked login, 401 otherwise
+func responseAuthorizationFail(res http.ResponseWriter, req *http.Request, err error) {
+	var lockout domain.LockoutError
+	if errors.As(err, &lockout) {
+		res.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(lockout.RetryAfter.Seconds()))))
+		web.ResponseError(res, req, http.StatusTooManyRequests, "too many failed login. try later")
+		return
+	}
+	web.ResponseError(res, req, http.StatusUnauthorized, "user authorization fail")
 }
 
 func isFatimaClientCli(req *http.Request) bool {
@@ -45,7 +45,7 @@ func deployPackage(controller web.JupiterServiceController, res http.ResponseWri
 
 	var result string
 	mr := multipart.NewReader(req.Body, params["boundary"])
-	result, err = controller.DeployPackage(mr, req.RemoteAddr, token)
+	result, err = controller.DeployPackage(web.GetSession(req), mr, req.RemoteAddr, token)
 	if err != nil {
 		log.Warn("fail to deploy : %s", err.Error())
 		web.ResponseError(res, req, http.StatusInternalServerError, err.Error())
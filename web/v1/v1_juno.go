@@ -45,6 +45,10 @@ type JunoRegistParam struct {
 	Name     string              `json:"package_name"`
 	Endpoint string              `json:"endpoint"`
 	Platform domain.PlatformInfo `json:"platform"`
+	// LeaseSeconds is heartbeat lease. 0 uses juno.lease.seconds
+	LeaseSeconds int `json:"lease_seconds,omitempty"`
+	// Labels replace labels of previous registration. e.g) {"env": "prod", "region": "kr1"}
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 func (handler *JunoRegistParam) ToJunoRegistration() domain.JunoRegistration {
@@ -56,6 +60,8 @@ func (handler *JunoRegistParam) ToJunoRegistration() domain.JunoRegistration {
 	juno.Status = domain.JUNO_STATUS_ALIVE
 	juno.RegistDate = time.Now().Unix()
 	juno.Platform = handler.Platform
+	juno.LeaseSeconds = handler.LeaseSeconds
+	juno.Labels = handler.Labels
 	return juno
 }
 
@@ -123,8 +129,29 @@ func registJuno(controller web.JupiterServiceController, res http.ResponseWriter
 		return
 	}
 
+	web.SetAuditTarget(req, junoParam.Host)
+	if err = domain.ValidateLabels(junoParam.Labels); err != nil {
+		log.Warn("invalid juno labels : %s, %s", junoParam.Host, err.Error())
+		web.ResponseError(res, req, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err = controller.ValidateJunoLease(junoParam.LeaseSeconds); err != nil {
+		log.Warn("invalid juno lease : %s, %s", junoParam.Host, err.Error())
+		web.ResponseError(res, req, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	keyId, err := controller.AuthenticateJuno(junoParam.Host, req.Header.Get(web.HeaderFatimaJunoKey))
+	if err != nil {
+		log.Warn("unauthorized juno regist : %s, %s", junoParam.Host, err.Error())
+		web.ResponseError(res, req, http.StatusUnauthorized, "invalid access")
+		return
+	}
+
 	log.Info("juno regist candidate : %s", junoParam)
-	controller.RegistJunoPackage(junoParam.ToJunoRegistration())
+	registration := junoParam.ToJunoRegistration()
+	registration.KeyId = keyId
+	controller.RegistJunoPackage(registration)
 	sendJunoSuccessResponse(res, req)
 }
 
@@ -136,6 +163,14 @@ func unregistJuno(controller web.JupiterServiceController, res http.ResponseWrit
 		return
 	}
 
+	web.SetAuditTarget(req, endpoint)
+	status, err := authorizeJunoEndpoint(controller, req, endpoint)
+	if err != nil {
+		log.Warn("unauthorized juno unregist : %s, %s", endpoint, err.Error())
+		web.ResponseError(res, req, status, "invalid access")
+		return
+	}
+
 	log.Info("try to unregist endpoint : %s", endpoint)
 	controller.UnregistJunoPackage(endpoint)
 	sendJunoSuccessResponse(res, req)
@@ -149,11 +184,181 @@ func removeJuno(controller web.JupiterServiceController, res http.ResponseWriter
 		return
 	}
 
+	web.SetAuditTarget(req, endpoint)
+	status, err := authorizeJunoEndpoint(controller, req, endpoint)
+	if err != nil {
+		log.Warn("unauthorized juno remove : %s, %s", endpoint, err.Error())
+		web.ResponseError(res, req, status, "invalid access")
+		return
+	}
+
 	log.Info("try to remove endpoint : %s", endpoint)
 	controller.RemoveJunoPackage(endpoint)
 	sendJunoSuccessResponse(res, req)
 }
 
+type JunoHeartbeatParam struct {
+	Endpoint     string `json:"endpoint"`
+	LeaseSeconds int    `json:"lease_seconds,omitempty"`
+}
+
+type JunoHeartbeatResponse struct {
+	LeaseSeconds int `json:"lease_seconds"`
+	JupiterResponse
+}
+
+// heartbeatJuno renews lease of registered juno. juno gets 404 when it has to regist again
+func heartbeatJuno(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	var param JunoHeartbeatParam
+
+	b, _ := io.ReadAll(req.Body)
+	if err := json.Unmarshal(b, &param); err != nil || len(param.Endpoint) == 0 {
+		log.Warn("invalid heartbeat data : %s", err)
+		web.ResponseError(res, req, http.StatusBadRequest, "invalid endpoint")
+		return
+	}
+	if err := controller.ValidateJunoLease(param.LeaseSeconds); err != nil {
+		log.Warn("invalid heartbeat lease : %s, %s", param.Endpoint, err.Error())
+		web.ResponseError(res, req, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err := controller.AuthenticateJunoEndpoint(param.Endpoint, req.Header.Get(web.HeaderFatimaJunoKey))
+	if err != nil {
+		log.Warn("unauthorized juno heartbeat : %s, %s", param.Endpoint, err.Error())
+		web.ResponseError(res, req, http.StatusUnauthorized, "invalid access")
+		return
+	}
+
+	leaseSeconds, err := controller.HeartbeatJunoPackage(param.Endpoint, param.LeaseSeconds)
+	if err != nil {
+		log.Warn("fail to heartbeat juno %s : %s", param.Endpoint, err.Error())
+		web.ResponseError(res, req, http.StatusNotFound, err.Error())
+		return
+	}
+
+	jr := JunoHeartbeatResponse{LeaseSeconds: leaseSeconds}
+	jr.System = domain.NewSuccessSystemMessage()
+	sendJsonResponse(res, req, jr)
+}
+
+type HealthResultView struct {
+	Time          string `json:"time"`
+	LatencyMillis int64  `json:"latency_millis"`
+	Error         string `json:"error,omitempty"`
+}
+
+// junoHealthHistory shows recent health results of juno endpoint, oldest first
+func junoHealthHistory(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	endpoint, err := parsingRequest(req, "endpoint")
+	if err != nil || len(endpoint) == 0 {
+		log.Warn("invalid request data : %s", err)
+		web.ResponseError(res, req, http.StatusBadRequest, "invalid endpoint")
+		return
+	}
+
+	results, ok := controller.FindJunoHealthHistory(web.GetSession(req), endpoint)
+	if !ok {
+		web.ResponseError(res, req, http.StatusNotFound, fmt.Sprintf("not found juno for endpoint %s", endpoint))
+		return
+	}
+
+	location := web.GetFatimaClientTimezone(req)
+	list := make([]HealthResultView, 0, len(results))
+	for _, r := range results {
+		view := HealthResultView{Error: r.Error, LatencyMillis: r.Latency.Milliseconds()}
+		view.Time = r.Time.In(location).Format(domain.TIME_YYYYMMDDHHMMSS)
+		list = append(list, view)
+	}
+
+	sendJsonResponse(res, req, map[string]interface{}{"endpoint": endpoint, "results": list})
+}
+
+// authorizeJunoEndpoint accepts juno key of registered endpoint or OPERATOR token (e.g. from cli).
+// token user needs OPERATOR role on group and package of the endpoint.
+// returned status is http status to response when authorization failed
+func authorizeJunoEndpoint(controller web.JupiterServiceController, req *http.Request, endpoint string) (int, error) {
+	key := req.Header.Get(web.HeaderFatimaJunoKey)
+	if len(key) == 0 {
+		if token := web.GetFatimaAuthToken(req); len(token) > 0 {
+			session, err := controller.ValidateToken(token, domain.ROLE_OPERATOR)
+			if err != nil {
+				return http.StatusUnauthorized, err
+			}
+			web.SetAuditUser(req, session.UserId, session.Role)
+			if !controller.PermitsJunoEndpoint(session, endpoint, domain.ROLE_OPERATOR) {
+				return http.StatusForbidden, fmt.Errorf("user %s has no OPERATOR role on endpoint", session.UserId)
+			}
+			return http.StatusOK, nil
+		}
+	}
+
+	err := controller.AuthenticateJunoEndpoint(endpoint, key)
+	if err != nil {
+		return http.StatusUnauthorized, err
+	}
+	return http.StatusOK, nil
+}
+
+func rotateJunoSecret(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	secret, err := controller.RotateJunoSecret()
+	if err != nil {
+		log.Warn("fail to rotate juno secret : %s", err.Error())
+		web.ResponseError(res, req, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	sendJunoKeyResponse(res, req, map[string]string{"secret": secret})
+}
+
+func enrollJunoHost(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	host, err := parsingRequest(req, "host")
+	if err != nil || len(host) == 0 {
+		log.Warn("invalid request data : %s", err)
+		web.ResponseError(res, req, http.StatusBadRequest, "invalid host")
+		return
+	}
+
+	web.SetAuditTarget(req, host)
+	key, err := controller.EnrollJunoHost(host)
+	if err != nil {
+		log.Warn("fail to enroll juno host : %s", err.Error())
+		web.ResponseError(res, req, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	sendJunoKeyResponse(res, req, map[string]string{"host": host, "key": key})
+}
+
+func revokeJunoHost(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	host, err := parsingRequest(req, "host")
+	if err != nil || len(host) == 0 {
+		log.Warn("invalid request data : %s", err)
+		web.ResponseError(res, req, http.StatusBadRequest, "invalid host")
+		return
+	}
+
+	web.SetAuditTarget(req, host)
+	err = controller.RevokeJunoHost(host)
+	if err != nil {
+		log.Warn("fail to revoke juno host : %s", err.Error())
+		web.ResponseError(res, req, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sendJunoSuccessResponse(res, req)
+}
+
+func sendJunoKeyResponse(res http.ResponseWriter, req *http.Request, keys map[string]string) {
+	b, err := json.Marshal(keys)
+	if err != nil {
+		log.Warn("fail to build json response : %s", err.Error())
+		web.ResponseError(res, req, http.StatusInternalServerError, err.Error())
+		return
+	}
+	web.ResponseSuccess(res, req, string(b))
+}
+
 func parsingJunoRegistParam(req *http.Request) (*JunoRegistParam, error) {
 	var data JunoRegistParam
 
@@ -0,0 +1,148 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 20. 오후 2:10
+ */
+package v1
+
+import (
+	"errors"
+	"github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/web"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testJunoController serves juno handlers from summary. other controller methods are not used
+type testJunoController struct {
+	web.JupiterServiceController
+	sessions map[string]domain.Session
+	summary  *domain.JunoSummary
+	removed  []string
+}
+
+func (c *testJunoController) ValidateToken(token string, role domain.Role) (domain.Session, error) {
+	session, ok := c.sessions[token]
+	if !ok || !session.Grant().HasRole(role) {
+		return domain.Session{}, errors.New("invalid token")
+	}
+	return session, nil
+}
+
+func (c *testJunoController) AuthenticateJunoEndpoint(endpoint string, key string) error {
+	return errors.New("missmatch juno key")
+}
+
+func (c *testJunoController) PermitsJunoEndpoint(caller domain.Session, endpoint string, role domain.Role) bool {
+	for _, g := range c.summary.Groups {
+		for _, p := range g.Packages {
+			if p.Endpoint == endpoint {
+				return caller.Grant().Permits(g.Name, domain.PackagePoint{Host: p.Host, Name: p.Name}, role)
+			}
+		}
+	}
+	return false
+}
+
+func (c *testJunoController) UnregistJunoPackage(endpoint string) {
+	c.removed = append(c.removed, endpoint)
+}
+
+func (c *testJunoController) RemoveJunoPackage(endpoint string) {
+	c.removed = append(c.removed, endpoint)
+}
+
+func newTestJunoController() *testJunoController {
+	return &testJunoController{
+		sessions: map[string]domain.Session{
+			"admin": {UserId: "admin", Role: domain.ROLE_OPERATOR},
+			"ops-a": {UserId: "ops-a", Role: domain.ROLE_OPERATOR,
+				Bindings: []domain.RoleBinding{{Scope: "group-a", Role: domain.ROLE_OPERATOR}}},
+			"ops-b-pkg": {UserId: "ops-b-pkg", Role: domain.ROLE_MONITOR,
+				Bindings: []domain.RoleBinding{
+					{Scope: "group-b", Role: domain.ROLE_MONITOR},
+					{Scope: "host-b:pkg-b", Role: domain.ROLE_OPERATOR},
+				}},
+			"monitor": {UserId: "monitor", Role: domain.ROLE_MONITOR},
+		},
+		summary: &domain.JunoSummary{Groups: []domain.JunoGroup{
+			{Name: "group-a", Packages: []domain.JunoPackage{{Host: "host-a", Name: "pkg-a", Endpoint: "http://host-a:9180"}}},
+			{Name: "group-b", Packages: []domain.JunoPackage{{Host: "host-b", Name: "pkg-b", Endpoint: "http://host-b:9180"}}},
+		}},
+	}
+}
+
+func TestAuthorizeJunoEndpointByToken(t *testing.T) {
+	cases := []struct {
+		token    string
+		endpoint string
+		status   int
+	}{
+		{"admin", "http://host-a:9180", http.StatusOK},
+		{"admin", "http://host-b:9180", http.StatusOK},
+		{"ops-a", "http://host-a:9180", http.StatusOK},
+		{"ops-a", "http://host-b:9180", http.StatusForbidden},
+		{"ops-a", "http://unknown:9180", http.StatusForbidden},
+		{"ops-b-pkg", "http://host-b:9180", http.StatusOK},
+		{"ops-b-pkg", "http://host-a:9180", http.StatusForbidden},
+		{"monitor", "http://host-a:9180", http.StatusUnauthorized},
+		{"unknown", "http://host-a:9180", http.StatusUnauthorized},
+	}
+
+	for _, handler := range []struct {
+		name   string
+		handle func(web.JupiterServiceController, http.ResponseWriter, *http.Request)
+	}{
+		{"unregist", unregistJuno},
+		{"remove", removeJuno},
+	} {
+		for _, c := range cases {
+			controller := newTestJunoController()
+			req := httptest.NewRequest(http.MethodPost, "/juno/"+handler.name+"/v1", strings.NewReader(`{"endpoint":"`+c.endpoint+`"}`))
+			req.Header.Set(web.HeaderFatimaAuthToken, c.token)
+			res := httptest.NewRecorder()
+
+			handler.handle(controller, res, req)
+
+			if res.Code != c.status {
+				t.Fatalf("%s %s on %s : status %d, expected %d", handler.name, c.token, c.endpoint, res.Code, c.status)
+			}
+			if done := len(controller.removed) > 0; done != (c.status == http.StatusOK) {
+				t.Fatalf("%s %s on %s : handled %t", handler.name, c.token, c.endpoint, done)
+			}
+		}
+	}
+}
+
+func TestAuthorizeJunoEndpointByKey(t *testing.T) {
+	controller := newTestJunoController()
+	req := httptest.NewRequest(http.MethodPost, "/juno/remove/v1", strings.NewReader(`{"endpoint":"http://host-a:9180"}`))
+	req.Header.Set(web.HeaderFatimaJunoKey, "wrong")
+	req.Header.Set(web.HeaderFatimaAuthToken, "admin")
+	res := httptest.NewRecorder()
+
+	removeJuno(controller, res, req)
+
+	if res.Code != http.StatusUnauthorized || len(controller.removed) > 0 {
+		t.Fatalf("wrong juno key is accepted : status %d", res.Code)
+	}
+}
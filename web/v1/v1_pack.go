@@ -26,20 +26,28 @@ package v1
 import (
 	"encoding/json"
 	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
 	"github.com/fatima-go/jupiter/web"
 	"net/http"
 )
 
 func pack(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
-	group, err := parsingRequest(req, "group")
+	params, err := parsingRequestParams(req)
 	if err != nil {
 		log.Warn("invalid request data : %s", err.Error())
 		web.ResponseError(res, req, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	selector, err := domain.ParseLabelSelector(params["selector"])
+	if err != nil {
+		log.Warn("invalid selector : %s", err.Error())
+		web.ResponseError(res, req, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	location := web.GetFatimaClientTimezone(req)
-	report := controller.GetPackageSummary(group, location)
+	report := controller.GetPackageSummary(web.GetSession(req), params["group"], selector, location)
 	log.Debug("report : %s", report)
 	b, err := json.Marshal(report)
 	if err != nil {
@@ -40,7 +40,7 @@ const (
 
 func registProc(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
 	/*
-		{"process": "testapp", "group_id": "4", "group": "basic", "package": "xfp-stg"}
+		{"process": "testapp", "group_id": "4", "group": "basic", "package": "xfp-stg", "selector": "env=prod"}
 	*/
 	params, err := parsingProcRequestParam(req)
 	if err != nil || len(params.GroupId) == 0 {
@@ -56,8 +56,15 @@ func registProc(controller web.JupiterServiceController, res http.ResponseWriter
 	}
 
 	log.Debug("proc regist request : %s", params)
+	web.SetAuditTarget(req, procAuditTarget(params))
+	selector, err := domain.ParseLabelSelector(params.Selector)
+	if err != nil {
+		log.Warn("invalid selector : %s", err.Error())
+		web.ResponseError(res, req, http.StatusBadRequest, err.Error())
+		return
+	}
 	point := domain.NewPackagePoint(params.Package)
-	endpointList := controller.GetEndpointList(params.Group, point, params.ClientAddress)
+	endpointList := controller.GetEndpointList(web.GetSession(req), params.Group, selector, point, params.ClientAddress)
 	log.Debug("list : %s", endpointList)
 
 	httpClient := web.NewHttpClient(req)
@@ -85,9 +92,25 @@ func registProc(controller web.JupiterServiceController, res http.ResponseWriter
 		{"system": {"message": "total 1 juno. process 1 registed", "code": 200}}
 	*/
 	message := fmt.Sprintf("Regist juno. total=%d, success=%d", total, success)
+	web.SetAuditMessage(req, message)
 	responseSuccessWithMessage(res, req, message)
 }
 
+// procAuditTarget returns process with target group or package
+func procAuditTarget(params *domain.ProcRequest) string {
+	switch {
+	case len(params.Selector) > 0 && len(params.Group) > 0:
+		return fmt.Sprintf("%s -> group %s, selector %s", params.Process, params.Group, params.Selector)
+	case len(params.Selector) > 0:
+		return fmt.Sprintf("%s -> selector %s", params.Process, params.Selector)
+	case len(params.Group) > 0:
+		return fmt.Sprintf("%s -> group %s", params.Process, params.Group)
+	case len(params.Package) > 0:
+		return fmt.Sprintf("%s -> package %s", params.Process, params.Package)
+	}
+	return fmt.Sprintf("%s -> %s", params.Process, params.ClientAddress)
+}
+
 func buildRestUrl(endpoint string, suffix string) string {
 	var url string
 	if endpoint[len(endpoint)-1] == '/' {
@@ -118,8 +141,15 @@ func unregistProc(controller web.JupiterServiceController, res http.ResponseWrit
 	}
 
 	log.Debug("proc unregist param : %s", params)
+	web.SetAuditTarget(req, procAuditTarget(params))
+	selector, err := domain.ParseLabelSelector(params.Selector)
+	if err != nil {
+		log.Warn("invalid selector : %s", err.Error())
+		web.ResponseError(res, req, http.StatusBadRequest, err.Error())
+		return
+	}
 	point := domain.NewPackagePoint(params.Package)
-	endpointList := controller.GetEndpointList(params.Group, point, params.ClientAddress)
+	endpointList := controller.GetEndpointList(web.GetSession(req), params.Group, selector, point, params.ClientAddress)
 	log.Debug("list : %s", endpointList)
 
 	httpClient := web.NewHttpClient(req)
@@ -147,6 +177,7 @@ func unregistProc(controller web.JupiterServiceController, res http.ResponseWrit
 		{"system": {"message": "total 1 juno. 1 unregisted", "code": 200}}
 	*/
 	message := fmt.Sprintf("UnRegist juno. total=%d, success=%d", total, success)
+	web.SetAuditMessage(req, message)
 	responseSuccessWithMessage(res, req, message)
 }
 
@@ -0,0 +1,119 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 6:20
+ */
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/web"
+	"io"
+	"net/http"
+	"time"
+)
+
+type SessionView struct {
+	Id            string `json:"id"`
+	UserId        string `json:"user_id"`
+	Role          string `json:"role"`
+	IssuedAt      string `json:"issued_at"`
+	ExpireAt      string `json:"expire_at"`
+	ClientAddress string `json:"client_address"`
+	UserAgent     string `json:"user_agent"`
+	Kind          string `json:"kind"`
+	DisplayName   string `json:"display_name,omitempty"`
+	Email         string `json:"email,omitempty"`
+}
+
+func newSessionView(session domain.Session, location *time.Location) SessionView {
+	view := SessionView{}
+	view.Id = session.Id
+	view.UserId = session.UserId
+	view.Role = session.Role.String()
+	view.IssuedAt = session.IssuedAt.In(location).Format(domain.TIME_YYYYMMDDHHMMSS)
+	view.ExpireAt = session.ExpireAt.In(location).Format(domain.TIME_YYYYMMDDHHMMSS)
+	view.ClientAddress = session.ClientAddress
+	view.UserAgent = session.UserAgent
+	view.Kind = session.Kind
+	return view
+}
+
+type SessionRevokeParam struct {
+	Id     string `json:"id"`
+	UserId string `json:"user_id"`
+}
+
+func listSession(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	location := web.GetFatimaClientTimezone(req)
+	list := make([]SessionView, 0)
+	for _, s := range controller.FindAllSessions() {
+		view := newSessionView(s, location)
+		if profile, ok := controller.FindUserProfile(s.UserId); ok {
+			view.DisplayName = profile.DisplayName
+			view.Email = profile.Email
+		}
+		list = append(list, view)
+	}
+
+	b, err := json.Marshal(map[string][]SessionView{"sessions": list})
+	if err != nil {
+		log.Warn("fail to build json response : %s", err.Error())
+		web.ResponseError(res, req, http.StatusInternalServerError, err.Error())
+		return
+	}
+	web.ResponseSuccess(res, req, string(b))
+}
+
+// revokeSession revokes single session with id or every session of user with user_id
+func revokeSession(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	var param SessionRevokeParam
+
+	b, _ := io.ReadAll(req.Body)
+	if err := json.Unmarshal(b, &param); err != nil {
+		log.Warn("invalid request data : %s", err.Error())
+		web.ResponseError(res, req, http.StatusBadRequest, fmt.Sprintf("fail to parse data : %s", err))
+		return
+	}
+
+	if len(param.Id) > 0 {
+		web.SetAuditTarget(req, "session "+param.Id)
+		err := controller.RevokeSession(param.Id)
+		if err != nil {
+			web.ResponseError(res, req, http.StatusNotFound, err.Error())
+			return
+		}
+		sendSuccessResponse(res, req)
+		return
+	}
+
+	if len(param.UserId) > 0 {
+		web.SetAuditTarget(req, "user "+param.UserId)
+		count := controller.RevokeUserSessions(param.UserId)
+		responseSuccessWithMessage(res, req, fmt.Sprintf("%d sessions revoked", count))
+		return
+	}
+
+	web.ResponseError(res, req, http.StatusBadRequest, "id or user_id is required")
+}
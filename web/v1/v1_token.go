@@ -24,6 +24,8 @@
 package v1
 
 import (
+	"encoding/json"
+	"github.com/fatima-go/fatima-log"
 	"github.com/fatima-go/jupiter/web"
 	"net/http"
 )
@@ -31,3 +33,27 @@ import (
 func token(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
 	web.ResponseSuccess(res, req, "")
 }
+
+// refreshAccessToken issues new token pair with refresh token. refresh token can be used only once
+func refreshAccessToken(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request, refreshToken string) {
+	userToken, newRefreshToken, err := controller.RefreshToken(refreshToken)
+	if err != nil {
+		log.Warn("fail to refresh token : %s", err.Error())
+		web.ResponseError(res, req, http.StatusUnauthorized, "invalid access")
+		return
+	}
+
+	vars := make(map[string]string)
+	vars["token"] = userToken
+	if len(newRefreshToken) > 0 {
+		vars["refresh_token"] = newRefreshToken
+	}
+	b, err := json.Marshal(vars)
+	if err != nil {
+		log.Warn("fail to build json response : %s", err.Error())
+		web.ResponseError(res, req, http.StatusInternalServerError, "system error")
+		return
+	}
+
+	web.ResponseSuccess(res, req, string(b))
+}
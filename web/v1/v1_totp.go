@@ -0,0 +1,107 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 19. 오후 3:00
+ */
+package v1
+
+import (
+	"fmt"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/web"
+	"net/http"
+)
+
+// enrollTotp starts TOTP enrollment of caller. secret, otpauth uri and recovery codes are shown only once
+func enrollTotp(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	session := web.GetSession(req)
+	enrollment, err := controller.EnrollTotp(session.UserId)
+	if err != nil {
+		log.Warn("fail to enroll totp of %s : %s", session.UserId, err.Error())
+		web.ResponseError(res, req, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sendJsonResponse(res, req, enrollment)
+}
+
+// confirmTotp enables TOTP of caller with {"otp": "..."}
+func confirmTotp(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	session := web.GetSession(req)
+	otp, err := parsingRequest(req, "otp")
+	if err != nil || len(otp) == 0 {
+		web.ResponseError(res, req, http.StatusBadRequest, "otp is required")
+		return
+	}
+
+	err = controller.ConfirmTotp(session.UserId, otp)
+	if err != nil {
+		log.Warn("fail to confirm totp of %s : %s", session.UserId, err.Error())
+		web.ResponseError(res, req, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sendSuccessResponse(res, req)
+}
+
+// disableTotp disables TOTP of caller. current code or recovery code is required
+func disableTotp(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	session := web.GetSession(req)
+	otp, err := parsingRequest(req, "otp")
+	if err != nil || len(otp) == 0 {
+		web.ResponseError(res, req, http.StatusBadRequest, "otp is required")
+		return
+	}
+
+	if controller.RequiresSecondFactor(session.UserId) {
+		if err = controller.VerifySecondFactor(session.UserId, otp, req.RemoteAddr); err != nil {
+			log.Warn("unauthorized : %s, %s", session.UserId, err.Error())
+			responseAuthorizationFail(res, req, err)
+			return
+		}
+	}
+
+	err = controller.DisableTotp(session.UserId)
+	if err != nil {
+		web.ResponseError(res, req, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sendSuccessResponse(res, req)
+}
+
+// resetTotp removes TOTP of user who lost authenticator and recovery codes
+func resetTotp(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	id, err := parsingRequest(req, "id")
+	if err != nil || len(id) == 0 {
+		web.ResponseError(res, req, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	web.SetAuditTarget(req, id)
+	err = controller.DisableTotp(id)
+	if err != nil {
+		log.Warn("fail to reset totp of %s : %s", id, err.Error())
+		web.ResponseError(res, req, http.StatusBadRequest, fmt.Sprintf("fail to reset totp : %s", err.Error()))
+		return
+	}
+
+	sendSuccessResponse(res, req)
+}
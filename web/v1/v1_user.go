@@ -0,0 +1,264 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with p work for additional information
+ * regarding copyright ownership.  The ASF licenses p file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use p file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ *
+ * @project jupiter
+ * @author DeockJin Chung (jin.freestyle@gmail.com)
+ * @date 26. 10. 18. 오후 4:02
+ */
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/fatima-go/fatima-log"
+	"github.com/fatima-go/jupiter/domain"
+	"github.com/fatima-go/jupiter/web"
+	"io"
+	"net/http"
+)
+
+type UserParam struct {
+	Id       string          `json:"id"`
+	Password string          `json:"passwd,omitempty"`
+	Role     string          `json:"role,omitempty"`
+	Bindings *[]BindingParam `json:"bindings,omitempty"`
+	Totp     bool            `json:"totp,omitempty"`
+}
+
+// BindingParam is role on scope. scope is group name, host:package or *
+type BindingParam struct {
+	Scope string `json:"scope"`
+	Role  string `json:"role"`
+}
+
+// ToUser converts param to user. absent bindings remains nil, so update keeps current bindings
+func (param UserParam) ToUser() domain.User {
+	user := domain.User{}
+	user.Id = param.Id
+	user.Password = param.Password
+	user.Role = domain.ToRole(param.Role)
+	if param.Bindings != nil {
+		user.Bindings = make([]domain.RoleBinding, 0, len(*param.Bindings))
+		for _, b := range *param.Bindings {
+			user.Bindings = append(user.Bindings, domain.NewRoleBinding(b.Scope, b.Role))
+		}
+	}
+	return user
+}
+
+func newUserParam(user domain.User) UserParam {
+	param := UserParam{Id: user.Id, Role: user.Role.String(), Totp: user.Totp.Confirmed}
+	if len(user.Bindings) > 0 {
+		bindings := make([]BindingParam, 0, len(user.Bindings))
+		for _, b := range user.Bindings {
+			bindings = append(bindings, BindingParam{Scope: b.Scope, Role: b.Role.String()})
+		}
+		param.Bindings = &bindings
+	}
+	return param
+}
+
+type UserListResponse struct {
+	Users []UserParam `json:"users"`
+}
+
+func listUser(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	list := UserListResponse{Users: make([]UserParam, 0)}
+	for _, u := range controller.FindAllUsers() {
+		list.Users = append(list.Users, newUserParam(u))
+	}
+
+	b, err := json.Marshal(list)
+	if err != nil {
+		log.Warn("fail to build json response : %s", err.Error())
+		web.ResponseError(res, req, http.StatusInternalServerError, err.Error())
+		return
+	}
+	web.ResponseSuccess(res, req, string(b))
+}
+
+func createUser(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	param, err := parsingUserParam(req)
+	if err != nil {
+		log.Warn("invalid request data : %s", err.Error())
+		web.ResponseError(res, req, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	web.SetAuditTarget(req, param.Id)
+	err = controller.CreateUser(param.ToUser())
+	if err != nil {
+		log.Warn("fail to create user %s : %s", param.Id, err.Error())
+		web.ResponseError(res, req, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sendSuccessResponse(res, req)
+}
+
+func updateUser(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	param, err := parsingUserParam(req)
+	if err != nil {
+		log.Warn("invalid request data : %s", err.Error())
+		web.ResponseError(res, req, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	web.SetAuditTarget(req, param.Id)
+	if len(param.Role) > 0 && domain.ToRole(param.Role) == domain.ROLE_UNKNOWN {
+		web.ResponseError(res, req, http.StatusBadRequest, fmt.Sprintf("unknown role %s", param.Role))
+		return
+	}
+
+	err = controller.UpdateUser(param.ToUser())
+	if err != nil {
+		log.Warn("fail to update user %s : %s", param.Id, err.Error())
+		web.ResponseError(res, req, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sendSuccessResponse(res, req)
+}
+
+func deleteUser(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	id, err := parsingRequest(req, "id")
+	if err != nil || len(id) == 0 {
+		log.Warn("invalid request data : %s", err)
+		web.ResponseError(res, req, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	web.SetAuditTarget(req, id)
+	err = controller.DeleteUser(id)
+	if err != nil {
+		log.Warn("fail to delete user %s : %s", id, err.Error())
+		web.ResponseError(res, req, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sendSuccessResponse(res, req)
+}
+
+type LockoutView struct {
+	Kind        string `json:"kind"`
+	Key         string `json:"key"`
+	Failures    int    `json:"failures"`
+	LastFailure string `json:"last_failure"`
+	LockedUntil string `json:"locked_until,omitempty"`
+}
+
+// listLockout shows failed login state of users and client addresses
+func listLockout(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	location := web.GetFatimaClientTimezone(req)
+	list := make([]LockoutView, 0)
+	for _, l := range controller.FindAllLockouts() {
+		view := LockoutView{Kind: l.Kind, Key: l.Key, Failures: l.Failures}
+		view.LastFailure = l.LastFailure.In(location).Format(domain.TIME_YYYYMMDDHHMMSS)
+		if l.IsLocked() {
+			view.LockedUntil = l.LockedUntil.In(location).Format(domain.TIME_YYYYMMDDHHMMSS)
+		}
+		list = append(list, view)
+	}
+
+	b, err := json.Marshal(map[string][]LockoutView{"lockouts": list})
+	if err != nil {
+		log.Warn("fail to build json response : %s", err.Error())
+		web.ResponseError(res, req, http.StatusInternalServerError, err.Error())
+		return
+	}
+	web.ResponseSuccess(res, req, string(b))
+}
+
+// unlockUser clears failed logins of {"id": "..."} or {"client": "..."}
+func unlockUser(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	param := make(map[string]string)
+
+	b, _ := io.ReadAll(req.Body)
+	if err := json.Unmarshal(b, &param); err != nil {
+		log.Warn("invalid request data : %s", err.Error())
+		web.ResponseError(res, req, http.StatusBadRequest, fmt.Sprintf("fail to parse data : %s", err))
+		return
+	}
+
+	kind, key := domain.LOCKOUT_KIND_USER, param["id"]
+	if len(key) == 0 {
+		kind, key = domain.LOCKOUT_KIND_CLIENT, param["client"]
+	}
+	if len(key) == 0 {
+		web.ResponseError(res, req, http.StatusBadRequest, "id or client is required")
+		return
+	}
+
+	web.SetAuditTarget(req, kind+" "+key)
+	err := controller.Unlock(kind, key)
+	if err != nil {
+		web.ResponseError(res, req, http.StatusNotFound, err.Error())
+		return
+	}
+
+	sendSuccessResponse(res, req)
+}
+
+func parsingUserParam(req *http.Request) (*UserParam, error) {
+	var param UserParam
+
+	b, _ := io.ReadAll(req.Body)
+	if err := json.Unmarshal(b, &param); err != nil {
+		return nil, fmt.Errorf("fail to parse data : %s", err)
+	}
+
+	if len(param.Id) == 0 {
+		return nil, fmt.Errorf("empty user id")
+	}
+
+	if param.Bindings != nil {
+		for _, b := range *param.Bindings {
+			if len(b.Scope) == 0 || domain.ToRole(b.Role) == domain.ROLE_UNKNOWN {
+				return nil, fmt.Errorf("invalid binding %s=%s", b.Scope, b.Role)
+			}
+		}
+	}
+
+	return &param, nil
+}
+
+// listDirectoryGroups shows groups of user directory(ldap helper v2) to build role bindings
+func listDirectoryGroups(controller web.JupiterServiceController, res http.ResponseWriter, req *http.Request) {
+	prefix, err := parsingRequest(req, "prefix")
+	if err != nil {
+		log.Warn("invalid request data : %s", err.Error())
+		web.ResponseError(res, req, http.StatusBadRequest, fmt.Sprintf("fail to parse data : %s", err))
+		return
+	}
+
+	groups, err := controller.ListDirectoryGroups(prefix)
+	if err != nil {
+		log.Warn("fail to list directory groups : %s", err.Error())
+		web.ResponseError(res, req, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	b, err := json.Marshal(map[string][]string{"groups": groups})
+	if err != nil {
+		log.Warn("fail to build json response : %s", err.Error())
+		web.ResponseError(res, req, http.StatusInternalServerError, err.Error())
+		return
+	}
+	web.ResponseSuccess(res, req, string(b))
+}
@@ -45,12 +45,16 @@ func GetWebService() *WebService {
 
 type WebServiceHandler interface {
 	GetVersion() string
-	HandleLogin(res http.ResponseWriter, req *http.Request)
+	HandleAuth(method string, res http.ResponseWriter, req *http.Request)
 	HandleToken(res http.ResponseWriter, req *http.Request)
 	HandlePack(res http.ResponseWriter, req *http.Request)
 	HandleJuno(method string, res http.ResponseWriter, req *http.Request)
 	HandleProc(method string, res http.ResponseWriter, req *http.Request)
 	HandleDeploy(method string, res http.ResponseWriter, req *http.Request)
+	HandleUser(method string, res http.ResponseWriter, req *http.Request)
+	HandleSession(method string, res http.ResponseWriter, req *http.Request)
+	HandleApiKey(method string, res http.ResponseWriter, req *http.Request)
+	HandleAudit(method string, res http.ResponseWriter, req *http.Request)
 }
 
 func (handler *WebService) Regist(service WebServiceHandler) {
@@ -71,7 +75,7 @@ func (handler *WebService) GenerateSubRouter(router *mux.Router) {
 		HeadersRegexp("Content-Type", "application/json*").
 		Subrouter()
 
-	subrouter.HandleFunc("/login/{version}", handler.Login)
+	subrouter.HandleFunc("/{method}/{version}", handler.Auth)
 
 	subrouter = router.PathPrefix("/token").
 		Methods("POST").
@@ -107,10 +111,38 @@ func (handler *WebService) GenerateSubRouter(router *mux.Router) {
 		Subrouter()
 
 	subrouter.HandleFunc("/{method}/{version}", handler.Deploy)
+
+	subrouter = router.PathPrefix("/user").
+		Methods("POST").
+		HeadersRegexp("Content-Type", "application/json*").
+		Subrouter()
+
+	subrouter.HandleFunc("/{method}/{version}", handler.User)
+
+	subrouter = router.PathPrefix("/session").
+		Methods("POST").
+		HeadersRegexp("Content-Type", "application/json*").
+		Subrouter()
+
+	subrouter.HandleFunc("/{method}/{version}", handler.Session)
+
+	subrouter = router.PathPrefix("/apikey").
+		Methods("POST").
+		HeadersRegexp("Content-Type", "application/json*").
+		Subrouter()
+
+	subrouter.HandleFunc("/{method}/{version}", handler.ApiKey)
+
+	subrouter = router.PathPrefix("/audit").
+		Methods("POST").
+		HeadersRegexp("Content-Type", "application/json*").
+		Subrouter()
+
+	subrouter.HandleFunc("/{method}/{version}", handler.Audit)
 }
 
 // var AccessControlAllowHeaderList = "Content-Type, Access-Control-Allow-Headers, Authorization, Fatima-Auth-Token, Fatima-Timezone"
-var AccessControlAllowHeaderList = "Content-Type, Fatima-Auth-Token, Fatima-Timezone"
+var AccessControlAllowHeaderList = "Content-Type, Fatima-Auth-Token, Fatima-Timezone, Fatima-Juno-Key, Fatima-Api-Key"
 
 func writeCORSResponse(res http.ResponseWriter, req *http.Request) {
 	res.Header().Set(HeaderAccessControlAllowOrigin, "*")
@@ -123,7 +155,10 @@ func writeCORSResponse(res http.ResponseWriter, req *http.Request) {
 	res.WriteHeader(http.StatusOK)
 }
 
-func (handler *WebService) Login(res http.ResponseWriter, req *http.Request) {
+func (handler *WebService) Auth(res http.ResponseWriter, req *http.Request) {
+	var method string
+	var ok bool
+
 	vars := mux.Vars(req)
 	version, ok := vars["version"]
 	if !ok {
@@ -137,7 +172,13 @@ func (handler *WebService) Login(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	service.HandleLogin(res, req)
+	method, ok = vars["method"]
+	if !ok {
+		ResponseError(res, req, http.StatusBadRequest, "resouce path not found")
+		return
+	}
+
+	service.HandleAuth(method, res, req)
 }
 
 func (handler *WebService) Token(res http.ResponseWriter, req *http.Request) {
@@ -251,3 +292,107 @@ func (handler *WebService) Deploy(res http.ResponseWriter, req *http.Request) {
 
 	service.HandleDeploy(method, res, req)
 }
+
+func (handler *WebService) User(res http.ResponseWriter, req *http.Request) {
+	var method string
+	var ok bool
+
+	vars := mux.Vars(req)
+	version, ok := vars["version"]
+	if !ok {
+		ResponseError(res, req, http.StatusBadRequest, "not found version")
+		return
+	}
+
+	service := handler.versions[version]
+	if service == nil {
+		ResponseError(res, req, http.StatusNotImplemented, "unsupported version")
+		return
+	}
+
+	method, ok = vars["method"]
+	if !ok {
+		ResponseError(res, req, http.StatusBadRequest, "resouce path not found")
+		return
+	}
+
+	service.HandleUser(method, res, req)
+}
+
+func (handler *WebService) Session(res http.ResponseWriter, req *http.Request) {
+	var method string
+	var ok bool
+
+	vars := mux.Vars(req)
+	version, ok := vars["version"]
+	if !ok {
+		ResponseError(res, req, http.StatusBadRequest, "not found version")
+		return
+	}
+
+	service := handler.versions[version]
+	if service == nil {
+		ResponseError(res, req, http.StatusNotImplemented, "unsupported version")
+		return
+	}
+
+	method, ok = vars["method"]
+	if !ok {
+		ResponseError(res, req, http.StatusBadRequest, "resouce path not found")
+		return
+	}
+
+	service.HandleSession(method, res, req)
+}
+
+func (handler *WebService) ApiKey(res http.ResponseWriter, req *http.Request) {
+	var method string
+	var ok bool
+
+	vars := mux.Vars(req)
+	version, ok := vars["version"]
+	if !ok {
+		ResponseError(res, req, http.StatusBadRequest, "not found version")
+		return
+	}
+
+	service := handler.versions[version]
+	if service == nil {
+		ResponseError(res, req, http.StatusNotImplemented, "unsupported version")
+		return
+	}
+
+	method, ok = vars["method"]
+	if !ok {
+		ResponseError(res, req, http.StatusBadRequest, "resouce path not found")
+		return
+	}
+
+	service.HandleApiKey(method, res, req)
+}
+
+func (handler *WebService) Audit(res http.ResponseWriter, req *http.Request) {
+	var method string
+	var ok bool
+
+	vars := mux.Vars(req)
+	version, ok := vars["version"]
+	if !ok {
+		ResponseError(res, req, http.StatusBadRequest, "not found version")
+		return
+	}
+
+	service := handler.versions[version]
+	if service == nil {
+		ResponseError(res, req, http.StatusNotImplemented, "unsupported version")
+		return
+	}
+
+	method, ok = vars["method"]
+	if !ok {
+		ResponseError(res, req, http.StatusBadRequest, "resouce path not found")
+		return
+	}
+
+	service.HandleAudit(method, res, req)
+}